@@ -0,0 +1,166 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/goldie"
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// runCommand runs a one-shot CLI subcommand instead of the MCP server.
+func runCommand(g *goldie.Goldie, args []string) error {
+	switch args[0] {
+	case "export":
+		return runExport(g, args[1:])
+	case "import":
+		return runImport(g, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q (commands: export, import)", args[0])
+	}
+}
+
+// runExport implements `goldie-mcp export [flags]`.
+func runExport(g *goldie.Goldie, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("o", "", "Write the export to this file, or directory for markdown (default: stdout)")
+	format := fs.String("format", goldie.FormatJSONL, "Export format: jsonl, markdown")
+	name := fs.String("name", "", "Export only the memory with this exact name")
+	typ := fs.String("type", "", "Filter by memory type")
+	agent := fs.String("agent", "", "Filter by agent")
+	source := fs.String("source", "", "Filter by source")
+	tagsAny := fs.String("tags-any", "", "Only memories with at least one of these comma-separated tags")
+	tagsAll := fs.String("tags-all", "", "Only memories with all of these comma-separated tags")
+	includeExpired := fs.Bool("include-expired", false, "Also export memories past their expires_at")
+	embeddings := fs.Bool("embeddings", false, "Include chunks and their vectors")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := goldie.ValidateTransferFormat(*format); err != nil {
+		return err
+	}
+
+	opts := goldie.ExportOptions{
+		Filter: store.MemoryFilter{
+			Name:    *name,
+			Type:    *typ,
+			Agent:   *agent,
+			Source:  *source,
+			TagsAny: splitList(*tagsAny),
+			TagsAll: splitList(*tagsAll),
+
+			IncludeExpired: *includeExpired,
+		},
+		IncludeEmbeddings: *embeddings,
+	}
+	if *out == "" {
+		if *format == goldie.FormatMarkdown {
+			return fmt.Errorf("-o is required for markdown exports")
+		}
+		n, err := g.Export(os.Stdout, opts)
+		if err != nil {
+			return err
+		}
+		errLog.Printf("Exported %d memory(ies)", n)
+		return nil
+	}
+	n, err := exportToPath(g, *out, *format, opts)
+	if err != nil {
+		return err
+	}
+	errLog.Printf("Exported %d memory(ies) to %s", n, *out)
+	return nil
+}
+
+// runImport implements `goldie-mcp import [flags] PATH`. PATH is a JSONL file,
+// "-" for JSONL on stdin, or a directory of Markdown memories.
+func runImport(g *goldie.Goldie, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	onConflict := fs.String("on-conflict", goldie.ConflictSkip, "When a name exists: skip, overwrite, rename, newer")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: goldie-mcp import [-on-conflict policy] PATH")
+	}
+
+	opts := goldie.ImportOptions{OnConflict: *onConflict}
+	var res *goldie.ImportResult
+	var err error
+	if path := fs.Arg(0); path == "-" {
+		res, err = g.Import(os.Stdin, opts)
+	} else {
+		res, err = importFromPath(g, path, opts)
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range res.Errors {
+		errLog.Printf("import: %s", e)
+	}
+	errLog.Printf("Imported %d, overwrote %d, renamed %d, skipped %d memory(ies); re-embedded %d",
+		res.Imported, res.Overwritten, res.Renamed, res.Skipped, res.Reembedded)
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("%d record(s) failed", len(res.Errors))
+	}
+	return nil
+}
+
+// exportToPath writes a JSONL export to the file at path, or a Markdown
+// export to the directory at path.
+func exportToPath(g *goldie.Goldie, path, format string, opts goldie.ExportOptions) (int, error) {
+	if format == goldie.FormatMarkdown {
+		return g.ExportMarkdown(path, opts)
+	}
+	return exportToFile(g, path, opts)
+}
+
+// importFromPath imports a JSONL file, or a Markdown tree when path is a
+// directory.
+func importFromPath(g *goldie.Goldie, path string, opts goldie.ImportOptions) (*goldie.ImportResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return g.ImportMarkdown(path, opts)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return g.Import(f, opts)
+}
+
+// exportToFile writes an export to path, replacing it only once the export
+// has been written completely.
+func exportToFile(g *goldie.Goldie, path string, opts goldie.ExportOptions) (int, error) {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	n, err := g.Export(f, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return n, os.Rename(tmp, path)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
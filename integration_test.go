@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"hash/fnv"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,12 +26,13 @@ import (
 type MockEmbedder struct {
 	dimensions int
 	delay      time.Duration
+	model      string
 }
 
 var _ embedder.Interface = (*MockEmbedder)(nil)
 
 func NewMockEmbedder(dimensions int, delay time.Duration) *MockEmbedder {
-	return &MockEmbedder{dimensions: dimensions, delay: delay}
+	return &MockEmbedder{dimensions: dimensions, delay: delay, model: "fnv-hash"}
 }
 
 func (m *MockEmbedder) Embed(text string) ([]float32, error) {
@@ -47,6 +54,8 @@ func (m *MockEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
 }
 
 func (m *MockEmbedder) GetDimensions() int { return m.dimensions }
+func (m *MockEmbedder) Backend() string    { return "mock" }
+func (m *MockEmbedder) Model() string      { return m.model }
 func (m *MockEmbedder) Warmup() error      { return nil }
 func (m *MockEmbedder) Close() error       { return nil }
 
@@ -73,6 +82,13 @@ type TestSetup struct {
 
 func NewTestSetup(t *testing.T) *TestSetup {
 	t.Helper()
+	return newTestSetupWithConfig(t, goldie.Config{Embedder: NewMockEmbedder(384, 10*time.Millisecond)})
+}
+
+// newTestSetupWithConfig is NewTestSetup with a custom goldie config; the
+// database path is filled in.
+func newTestSetupWithConfig(t *testing.T, cfg goldie.Config) *TestSetup {
+	t.Helper()
 
 	tempDir, err := os.MkdirTemp("", "goldie-test-*")
 	if err != nil {
@@ -80,12 +96,7 @@ func NewTestSetup(t *testing.T) *TestSetup {
 	}
 
 	dbPath := filepath.Join(tempDir, "test.db")
-	mockEmb := NewMockEmbedder(384, 10*time.Millisecond)
-
-	cfg := goldie.Config{
-		DBPath:   dbPath,
-		Embedder: mockEmb,
-	}
+	cfg.DBPath = dbPath
 
 	r, err := goldie.New(cfg)
 	if err != nil {
@@ -134,8 +145,16 @@ func (ts *TestSetup) CallTool(t *testing.T, toolName string, args map[string]any
 		result, err = handleRemember(ctx, req)
 	case "recall":
 		result, err = handleRecall(ctx, req)
+	case "bootstrap":
+		result, err = handleBootstrap(ctx, req)
+	case "get_memory":
+		result, err = handleGetMemory(ctx, req)
 	case "update_memory":
 		result, err = handleUpdateMemory(ctx, req)
+	case "rename_memory":
+		result, err = handleRenameMemory(ctx, req)
+	case "merge_memories":
+		result, err = handleMergeMemories(ctx, req)
 	case "forget":
 		result, err = handleForget(ctx, req)
 	case "list_memories":
@@ -152,6 +171,34 @@ func (ts *TestSetup) CallTool(t *testing.T, toolName string, args map[string]any
 		result, err = handleListJobs(ctx, req)
 	case "clear_queue":
 		result, err = handleClearQueue(ctx, req)
+	case "memory_history":
+		result, err = handleMemoryHistory(ctx, req)
+	case "memory_diff":
+		result, err = handleMemoryDiff(ctx, req)
+	case "restore_memory":
+		result, err = handleRestoreMemory(ctx, req)
+	case "list_trash":
+		result, err = handleListTrash(ctx, req)
+	case "due_reminders":
+		result, err = handleDueReminders(ctx, req)
+	case "complete_memory":
+		result, err = handleCompleteMemory(ctx, req)
+	case "snooze_memory":
+		result, err = handleSnoozeMemory(ctx, req)
+	case "link_memories":
+		result, err = handleLinkMemories(ctx, req)
+	case "unlink_memories":
+		result, err = handleUnlinkMemories(ctx, req)
+	case "related":
+		result, err = handleRelated(ctx, req)
+	case "find_duplicates":
+		result, err = handleFindDuplicates(ctx, req)
+	case "resolve_duplicates":
+		result, err = handleResolveDuplicates(ctx, req)
+	case "export_memories":
+		result, err = handleExportMemories(ctx, req)
+	case "import_memories":
+		result, err = handleImportMemories(ctx, req)
 	default:
 		t.Fatalf("unknown tool: %s", toolName)
 	}
@@ -202,7 +249,7 @@ func TestJobQueueBasicFlow(t *testing.T) {
 		t.Fatalf("failed to create test file: %v", err)
 	}
 
-	jobID, err := ts.Queue.EnqueueIndexFile(testFile, "test-agent")
+	jobID, err := ts.Queue.EnqueueIndexFile(store.DefaultNamespace, testFile, "test-agent")
 	if err != nil {
 		t.Fatalf("failed to enqueue job: %v", err)
 	}
@@ -269,7 +316,7 @@ func TestJobQueueDirectoryIndexing(t *testing.T) {
 		}
 	}
 
-	jobID, err := ts.Queue.EnqueueIndexDirectory(testDir, "*.txt", false, "test-agent")
+	jobID, err := ts.Queue.EnqueueIndexDirectory(store.DefaultNamespace, testDir, "*.txt", false, "test-agent")
 	if err != nil {
 		t.Fatalf("failed to enqueue job: %v", err)
 	}
@@ -426,7 +473,7 @@ func TestForgetByFilter(t *testing.T) {
 		t.Fatalf("seed keep failed: %v", err)
 	}
 
-	deleted, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Type: "opinion"}, "", 0)
+	deleted, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Type: "opinion"}, goldie.ForgetQuery{})
 	if err != nil {
 		t.Fatalf("ForgetMemory failed: %v", err)
 	}
@@ -444,7 +491,7 @@ func TestForgetRefusesEmptyFilterAndQuery(t *testing.T) {
 	ts := NewTestSetup(t)
 	defer ts.Cleanup()
 
-	_, err := ts.Goldie.ForgetMemory(store.MemoryFilter{}, "", 0)
+	_, err := ts.Goldie.ForgetMemory(store.MemoryFilter{}, goldie.ForgetQuery{})
 	if err == nil {
 		t.Fatal("expected error for empty filter + empty query")
 	}
@@ -465,7 +512,7 @@ func TestForgetByQueryWithFilter(t *testing.T) {
 		Body: "Dark mode is easier on the eyes",
 	})
 
-	deleted, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Type: "feedback"}, "database connections", 5)
+	deleted, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Type: "feedback"}, goldie.ForgetQuery{Text: "database connections", Limit: 5})
 	if err != nil {
 		t.Fatalf("forget failed: %v", err)
 	}
@@ -631,7 +678,7 @@ func TestMCP_IndexFileCreatesMemory(t *testing.T) {
 	}
 
 	absPath, _ := filepath.Abs(testFile)
-	m, err := ts.Store.GetMemoryByName(absPath)
+	m, err := ts.Store.GetMemoryByName(store.DefaultNamespace, absPath)
 	if err != nil {
 		t.Fatalf("lookup failed: %v", err)
 	}
@@ -691,7 +738,7 @@ func TestMCP_IndexFileReindexesOnChange(t *testing.T) {
 	}
 
 	absPath, _ := filepath.Abs(testFile)
-	m, _ := ts.Store.GetMemoryByName(absPath)
+	m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, absPath)
 	if m == nil {
 		t.Fatal("memory not found after re-index")
 	}
@@ -699,3 +746,2581 @@ func TestMCP_IndexFileReindexesOnChange(t *testing.T) {
 		t.Errorf("expected updated body, got %q", m.Body)
 	}
 }
+
+// ============================================================================
+// Hybrid recall tests
+// ============================================================================
+
+func TestRecallKeywordFindsExactIdentifier(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	if !ts.Store.FullTextEnabled() {
+		t.Fatal("full-text search unavailable: run the tests with -tags sqlite_fts5")
+	}
+
+	for name, body := range map[string]string{
+		"conn_reset":   "Retry on ERR_CONN_RESET from the payments gateway, at most three times.",
+		"deploy_notes": "Deploys go out on Tuesdays after the release train.",
+		"ui_spacing":   "Use 8px spacing increments in the dashboard.",
+	} {
+		if _, err := ts.Goldie.Remember(goldie.RememberInput{Name: name, Type: "reference", Body: body}); err != nil {
+			t.Fatalf("seed %s failed: %v", name, err)
+		}
+	}
+
+	results, err := ts.Goldie.Recall("ERR_CONN_RESET", goldie.RecallOptions{Limit: 5, Mode: goldie.RecallModeKeyword})
+	if err != nil {
+		t.Fatalf("keyword recall failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Memory.Name != "conn_reset" {
+		t.Fatalf("expected only conn_reset, got %+v", results)
+	}
+
+	results, err = ts.Goldie.Recall("ERR_CONN_RESET", goldie.RecallOptions{Limit: 3})
+	if err != nil {
+		t.Fatalf("hybrid recall failed: %v", err)
+	}
+	if len(results) == 0 || results[0].Memory.Name != "conn_reset" {
+		t.Errorf("expected conn_reset to top hybrid results, got %+v", results)
+	}
+}
+
+func TestRecallKeywordIndexFollowsUpdatesAndDeletes(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	if !ts.Store.FullTextEnabled() {
+		t.Fatal("full-text search unavailable: run the tests with -tags sqlite_fts5")
+	}
+
+	if _, err := ts.Goldie.Remember(goldie.RememberInput{Name: "ticket", Type: "project", Body: "Tracking PROJ-101"}); err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	body := "Tracking PROJ-202 now"
+	if _, err := ts.Goldie.UpdateMemory("ticket", goldie.UpdateMemoryInput{Body: &body}); err != nil {
+		t.Fatalf("UpdateMemory failed: %v", err)
+	}
+
+	kw := goldie.RecallOptions{Limit: 5, Mode: goldie.RecallModeKeyword}
+	if res, _ := ts.Goldie.Recall("PROJ-101", kw); len(res) != 0 {
+		t.Errorf("stale body still indexed: %+v", res)
+	}
+	if res, _ := ts.Goldie.Recall("PROJ-202", kw); len(res) != 1 {
+		t.Errorf("expected updated body to be indexed, got %d results", len(res))
+	}
+
+	if _, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Name: "ticket"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+	if res, _ := ts.Goldie.Recall("PROJ-202", kw); len(res) != 0 {
+		t.Errorf("deleted memory still indexed: %+v", res)
+	}
+}
+
+func TestMCP_RecallRejectsUnknownMode(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	resp := ts.CallTool(t, "recall", map[string]any{"query": "anything", "mode": "fuzzy"})
+	if _, ok := resp["results"]; ok {
+		t.Errorf("expected invalid mode to fail, got %v", resp)
+	}
+}
+
+// ============================================================================
+// Schema migration tests
+// ============================================================================
+
+// fixtureEmbedding matches the 4-dimensional vectors in testdata/schema.
+var fixtureEmbedding = store.EmbeddingInfo{Backend: "mock", Model: "fixture", Dimensions: 4}
+
+// loadSchemaFixture materializes a SQL fixture into a fresh database file.
+func loadSchemaFixture(t *testing.T, fixture string) string {
+	t.Helper()
+
+	script, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "fixture.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("opening fixture db: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatalf("loading fixture %s: %v", fixture, err)
+	}
+	return dbPath
+}
+
+func TestSchemaMigratesEveryPriorVersion(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "schema", "v*.sql"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("no schema fixtures found: %v", err)
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			dbPath := loadSchemaFixture(t, fixture)
+			st, err := store.New(dbPath, fixtureEmbedding, "")
+			if err != nil {
+				t.Fatalf("opening fixture: %v", err)
+			}
+			defer st.Close()
+
+			v, err := st.SchemaVersion()
+			if err != nil {
+				t.Fatalf("SchemaVersion failed: %v", err)
+			}
+			if v != store.LatestSchemaVersion() {
+				t.Errorf("expected schema version %d, got %d", store.LatestSchemaVersion(), v)
+			}
+
+			m, err := st.GetMemoryByName(store.DefaultNamespace, "fixture_memory")
+			if err != nil || m == nil {
+				t.Fatalf("fixture memory missing after migration: %v", err)
+			}
+			if m.Agent != "fixture-agent" || m.CreatedAt.Year() != 2024 || m.Namespace != store.DefaultNamespace {
+				t.Errorf("fixture memory fields not preserved: %+v", m)
+			}
+			if m.RecallCount != 0 || m.LastRecalledAt != nil || m.Importance != 0 {
+				t.Errorf("expected no recall stats or importance after migration: %+v", m)
+			}
+
+			if todo, _ := st.GetMemoryByName(store.DefaultNamespace, "fixture_todo"); todo != nil && todo.Status != store.MemoryStatusOpen {
+				t.Errorf("expected existing todo to be open after migration, got %q", todo.Status)
+			}
+
+			results, err := st.SearchMemories([]float32{0.1, 0.2, 0.3, 0.4}, 5, store.MemoryFilter{})
+			if err != nil {
+				t.Fatalf("vector search failed: %v", err)
+			}
+			if len(results) != 1 || results[0].Memory.ID != m.ID {
+				t.Errorf("expected fixture memory from vector search, got %+v", results)
+			}
+			// A scaled copy of the fixture vector is at cosine distance 0.
+			if scaled, _ := st.SearchMemories([]float32{0.2, 0.4, 0.6, 0.8}, 1, store.MemoryFilter{}); len(scaled) != 1 || scaled[0].Score < 0.999 || scaled[0].Metric != store.MetricCosine {
+				t.Errorf("expected vectors ranked by cosine distance after migration, got %+v", scaled)
+			}
+			if st.FullTextEnabled() {
+				kw, err := st.SearchMemoriesKeyword("FIXTURE_TOKEN", 5, store.MemoryFilter{})
+				if err != nil || len(kw) != 1 {
+					t.Errorf("expected keyword index backfilled, got %d results (%v)", len(kw), err)
+				}
+			}
+
+			job, err := st.GetJob("j-1")
+			if err != nil || job == nil {
+				t.Errorf("fixture job missing after migration: %v", err)
+			} else if job.Namespace != store.DefaultNamespace {
+				t.Errorf("expected fixture job in the default namespace, got %q", job.Namespace)
+			}
+
+			err = st.AddMemory(&store.Memory{Name: "post_migration", Type: "idea", Body: "new"},
+				[]store.ChunkText{{Content: "new"}}, [][]float32{{0.4, 0.3, 0.2, 0.1}})
+			if err != nil {
+				t.Errorf("write after migration failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestSchemaMigrationIsIdempotent(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "twice.db")
+	for i := range 2 {
+		st, err := store.New(dbPath, fixtureEmbedding, "")
+		if err != nil {
+			t.Fatalf("open %d failed: %v", i, err)
+		}
+		v, _ := st.SchemaVersion()
+		st.Close()
+		if v != store.LatestSchemaVersion() {
+			t.Errorf("open %d: expected version %d, got %d", i, store.LatestSchemaVersion(), v)
+		}
+	}
+}
+
+func TestSchemaRefusesNewerDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "future.db")
+	st, err := store.New(dbPath, fixtureEmbedding, "")
+	if err != nil {
+		t.Fatalf("creating db: %v", err)
+	}
+	st.Close()
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("opening db: %v", err)
+	}
+	_, err = db.Exec("INSERT INTO schema_version (version) VALUES (?)", store.LatestSchemaVersion()+1)
+	db.Close()
+	if err != nil {
+		t.Fatalf("bumping version: %v", err)
+	}
+
+	_, err = store.New(dbPath, fixtureEmbedding, "")
+	if !errors.Is(err, store.ErrSchemaTooNew) {
+		t.Errorf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+// ============================================================================
+// Embedding fingerprint tests
+// ============================================================================
+
+func openGoldie(t *testing.T, dbPath string, emb *MockEmbedder, reembed bool) (*goldie.Goldie, error) {
+	t.Helper()
+	return goldie.New(goldie.Config{
+		DBPath:     dbPath,
+		Dimensions: emb.dimensions,
+		Embedder:   emb,
+		Reembed:    reembed,
+	})
+}
+
+func TestEmbeddingMismatchRefusesToStart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fp.db")
+
+	g, err := openGoldie(t, dbPath, NewMockEmbedder(384, 0), false)
+	if err != nil {
+		t.Fatalf("initial open failed: %v", err)
+	}
+	info, err := g.Store().EmbeddingInfo()
+	if err != nil || info == nil || info.Backend != "mock" || info.Model != "fnv-hash" || info.Dimensions != 384 {
+		t.Fatalf("expected recorded fingerprint, got %+v (%v)", info, err)
+	}
+	g.Close()
+
+	sameDims := NewMockEmbedder(384, 0)
+	sameDims.model = "other-model"
+	if _, err := openGoldie(t, dbPath, sameDims, false); !errors.Is(err, goldie.ErrEmbeddingMismatch) {
+		t.Errorf("expected ErrEmbeddingMismatch for a different model, got %v", err)
+	}
+	if _, err := openGoldie(t, dbPath, NewMockEmbedder(8, 0), false); !errors.Is(err, goldie.ErrEmbeddingMismatch) {
+		t.Errorf("expected ErrEmbeddingMismatch for different dimensions, got %v", err)
+	}
+}
+
+// waitForJob polls until the job completes, failing the test if it fails or
+// does not finish in time.
+func waitForJob(t *testing.T, st *store.Store, jobID string) *store.Job {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := st.GetJob(jobID)
+		if err != nil {
+			t.Fatalf("failed to get job: %v", err)
+		}
+		switch job.Status {
+		case store.JobStatusCompleted:
+			return job
+		case store.JobStatusFailed:
+			t.Fatalf("job failed: %s", job.Error)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not complete in time", jobID)
+	return nil
+}
+
+func seedReembedDB(t *testing.T, dbPath string, names ...string) {
+	t.Helper()
+	g, err := openGoldie(t, dbPath, NewMockEmbedder(384, 0), false)
+	if err != nil {
+		t.Fatalf("initial open failed: %v", err)
+	}
+	defer g.Close()
+	for _, name := range names {
+		if _, err := g.Remember(goldie.RememberInput{Name: name, Type: "idea", Body: "body " + name}); err != nil {
+			t.Fatalf("seed %s failed: %v", name, err)
+		}
+	}
+}
+
+func TestReembedSwitchesModel(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "reembed.db")
+	seedReembedDB(t, dbPath, "one", "two")
+
+	small := NewMockEmbedder(8, 0)
+	g, err := openGoldie(t, dbPath, small, true)
+	if err != nil {
+		t.Fatalf("open with reembed failed: %v", err)
+	}
+	q := queue.New(g.Store(), g, nil)
+	jobID, err := q.EnqueueReembed()
+	if err != nil {
+		t.Fatalf("EnqueueReembed failed: %v", err)
+	}
+	again, err := q.EnqueueReembed()
+	if err != nil || again != jobID {
+		t.Errorf("expected pending job %s to be reused, got %s (%v)", jobID, again, err)
+	}
+
+	q.Start()
+	job := waitForJob(t, g.Store(), jobID)
+	q.Stop()
+	if job.Progress != 2 || job.Total != 2 {
+		t.Errorf("expected progress 2/2, got %d/%d", job.Progress, job.Total)
+	}
+	results, err := g.RecallMemory("body one", 5, store.MemoryFilter{})
+	if err != nil || len(results) != 2 {
+		t.Errorf("expected both memories recallable after reembed, got %d (%v)", len(results), err)
+	}
+	g.Close()
+
+	g, err = openGoldie(t, dbPath, small, false)
+	if err != nil {
+		t.Fatalf("reopen after reembed failed: %v", err)
+	}
+	g.Close()
+}
+
+func TestReembedIncompleteRefusesToStart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "pending.db")
+	seedReembedDB(t, dbPath, "one", "two")
+
+	small := NewMockEmbedder(8, 0)
+	g, err := openGoldie(t, dbPath, small, true)
+	if err != nil {
+		t.Fatalf("open with reembed failed: %v", err)
+	}
+	q := queue.New(g.Store(), g, nil)
+	if _, err := q.EnqueueReembed(); err != nil {
+		t.Fatalf("EnqueueReembed failed: %v", err)
+	}
+	g.Close()
+
+	// While the job is queued, a restart resumes it.
+	g, err = openGoldie(t, dbPath, small, false)
+	if err != nil {
+		t.Fatalf("reopen with a pending job failed: %v", err)
+	}
+	if _, err := g.Store().DeleteJobs("all", ""); err != nil {
+		t.Fatalf("DeleteJobs failed: %v", err)
+	}
+	g.Close()
+
+	// With the job gone, nothing would put the vectors back.
+	if _, err := openGoldie(t, dbPath, small, false); !errors.Is(err, goldie.ErrReembedIncomplete) {
+		t.Fatalf("expected ErrReembedIncomplete, got %v", err)
+	}
+
+	g, err = openGoldie(t, dbPath, small, true)
+	if err != nil {
+		t.Fatalf("open with reembed failed: %v", err)
+	}
+	q = queue.New(g.Store(), g, nil)
+	jobID, err := q.EnqueueReembed()
+	if err != nil {
+		t.Fatalf("EnqueueReembed failed: %v", err)
+	}
+	q.Start()
+	waitForJob(t, g.Store(), jobID)
+	q.Stop()
+	g.Close()
+
+	g, err = openGoldie(t, dbPath, small, false)
+	if err != nil {
+		t.Fatalf("reopen after finished reembed failed: %v", err)
+	}
+	defer g.Close()
+	results, err := g.RecallMemory("body one", 5, store.MemoryFilter{})
+	if err != nil || len(results) != 2 {
+		t.Errorf("expected both memories recallable, got %d (%v)", len(results), err)
+	}
+}
+
+func TestReembedSameDimensionDropsOldVectors(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "samedims.db")
+	seedReembedDB(t, dbPath, "one", "two", "three")
+
+	other := NewMockEmbedder(384, 0)
+	other.model = "other-model"
+	g, err := openGoldie(t, dbPath, other, true)
+	if err != nil {
+		t.Fatalf("open with reembed failed: %v", err)
+	}
+	defer g.Close()
+	if _, err := g.ForgetMemory(store.MemoryFilter{Name: "three"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+
+	q := queue.New(g.Store(), g, nil)
+	jobID, err := q.EnqueueReembed()
+	if err != nil {
+		t.Fatalf("EnqueueReembed failed: %v", err)
+	}
+	vector := goldie.RecallOptions{Mode: goldie.RecallModeVector}
+	if results, err := g.Recall("body one", vector); err != nil || len(results) != 0 {
+		t.Errorf("expected no old-model vectors left to recall, got %d (%v)", len(results), err)
+	}
+
+	q.Start()
+	job := waitForJob(t, g.Store(), jobID)
+	q.Stop()
+	// The walk covers trashed memories too, so the total counts them.
+	if n, err := g.Store().CountAllMemories(); err != nil || n != 3 {
+		t.Errorf("expected a total of 3 memories, got %d (%v)", n, err)
+	}
+	if job.Progress != 3 || job.Total != 3 {
+		t.Errorf("expected progress 3/3, got %d/%d", job.Progress, job.Total)
+	}
+	if results, err := g.Recall("body one", vector); err != nil || len(results) != 2 {
+		t.Errorf("expected both live memories recallable after reembed, got %d (%v)", len(results), err)
+	}
+}
+
+func TestReembedResumesInterruptedJob(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "resume.db")
+	seedReembedDB(t, dbPath, "one", "two", "three")
+
+	g, err := openGoldie(t, dbPath, NewMockEmbedder(8, 0), true)
+	if err != nil {
+		t.Fatalf("open with reembed failed: %v", err)
+	}
+	defer g.Close()
+	st := g.Store()
+
+	q := queue.New(st, g, nil)
+	jobID, err := q.EnqueueReembed()
+	if err != nil {
+		t.Fatalf("EnqueueReembed failed: %v", err)
+	}
+
+	// Simulate a process killed after re-embedding the first memory.
+	ids, err := st.ListMemoryIDsAfter("", 1)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("listing memories: %v (%v)", ids, err)
+	}
+	if err := g.ReembedMemory(ids[0]); err != nil {
+		t.Fatalf("ReembedMemory failed: %v", err)
+	}
+	if err := st.UpdateJobStatus(jobID, store.JobStatusProcessing); err != nil {
+		t.Fatalf("marking job processing: %v", err)
+	}
+	if err := st.UpdateJobCheckpoint(jobID, ids[0], 1, 3); err != nil {
+		t.Fatalf("checkpointing job: %v", err)
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("opening raw db: %v", err)
+	}
+	_, err = db.Exec("UPDATE jobs SET updated_at = datetime('now', '-1 hour') WHERE id = ?", jobID)
+	db.Close()
+	if err != nil {
+		t.Fatalf("backdating job: %v", err)
+	}
+
+	q.Start()
+	job := waitForJob(t, st, jobID)
+	q.Stop()
+
+	// Resuming from the checkpoint re-embeds only the remaining two.
+	var result struct {
+		Reembedded int `json:"reembedded"`
+	}
+	if err := json.Unmarshal([]byte(job.Result), &result); err != nil {
+		t.Fatalf("parsing result: %v", err)
+	}
+	if result.Reembedded != 3 || job.Progress != 3 {
+		t.Errorf("expected 3 memories after resume, got result=%d progress=%d", result.Reembedded, job.Progress)
+	}
+	results, err := g.RecallMemory("body one", 5, store.MemoryFilter{})
+	if err != nil || len(results) != 3 {
+		t.Errorf("expected all memories recallable after resume, got %d (%v)", len(results), err)
+	}
+}
+
+func TestRequeueStaleJobsLeavesOtherTypes(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	for _, id := range []string{"slow-index", "dead-reembed"} {
+		jobType := store.JobTypeIndexFile
+		if id == "dead-reembed" {
+			jobType = store.JobTypeReembed
+		}
+		if err := ts.Store.CreateJob(id, jobType, "", "{}"); err != nil {
+			t.Fatalf("CreateJob failed: %v", err)
+		}
+		if err := ts.Store.UpdateJobStatus(id, store.JobStatusProcessing); err != nil {
+			t.Fatalf("marking job processing: %v", err)
+		}
+	}
+	db, err := sql.Open("sqlite3", ts.DBPath)
+	if err != nil {
+		t.Fatalf("opening raw db: %v", err)
+	}
+	_, err = db.Exec("UPDATE jobs SET updated_at = datetime('now', '-1 hour')")
+	db.Close()
+	if err != nil {
+		t.Fatalf("backdating jobs: %v", err)
+	}
+
+	// Only the checkpointing reembed job can be told apart from a slow one.
+	n, err := ts.Store.RequeueStaleJobs(store.JobTypeReembed, 5*time.Minute)
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 requeued job, got %d (%v)", n, err)
+	}
+	for id, want := range map[string]string{
+		"slow-index":   store.JobStatusProcessing,
+		"dead-reembed": store.JobStatusQueued,
+	} {
+		job, err := ts.Store.GetJob(id)
+		if err != nil || job.Status != want {
+			t.Errorf("job %s: expected %s, got %+v (%v)", id, want, job, err)
+		}
+	}
+}
+
+// ============================================================================
+// Export/import tests
+// ============================================================================
+
+func TestMCP_ExportImportRoundTrip(t *testing.T) {
+	src := NewTestSetup(t)
+	defer src.Cleanup()
+	src.SetupGlobals()
+
+	for _, in := range []goldie.RememberInput{
+		{Name: "pr_size", Type: "feedback", Body: "Keep PRs under 400 lines.", Description: "small PRs", Agent: "codex"},
+		{Name: "deploy_day", Type: "project", Body: "Deploys go out on Tuesdays.", Source: "standup"},
+	} {
+		if _, err := src.Goldie.Remember(in); err != nil {
+			t.Fatalf("seed %s failed: %v", in.Name, err)
+		}
+	}
+	original, err := src.Store.GetMemoryByName(store.DefaultNamespace, "pr_size")
+	if err != nil || original == nil {
+		t.Fatalf("GetMemoryByName failed: %v", err)
+	}
+
+	path := filepath.Join(src.TempDir, "export.jsonl")
+	resp := src.CallTool(t, "export_memories", map[string]any{"path": path, "include_embeddings": true})
+	if resp["count"] != float64(2) {
+		t.Fatalf("expected 2 exported, got %v", resp)
+	}
+
+	dst := NewTestSetup(t)
+	defer dst.Cleanup()
+	dst.SetupGlobals()
+
+	resp = dst.CallTool(t, "import_memories", map[string]any{"path": path})
+	if resp["success"] != true {
+		t.Fatalf("import failed: %v", resp)
+	}
+	result := resp["result"].(map[string]any)
+	if result["imported"] != float64(2) || result["reembedded"] != float64(0) {
+		t.Errorf("expected 2 imported with stored vectors, got %v", result)
+	}
+
+	m, err := dst.Store.GetMemoryByName(store.DefaultNamespace, "pr_size")
+	if err != nil || m == nil {
+		t.Fatalf("imported memory missing: %v", err)
+	}
+	if m.ID != original.ID || m.Agent != "codex" || m.Description != "small PRs" ||
+		!m.CreatedAt.Equal(original.CreatedAt) || !m.UpdatedAt.Equal(original.UpdatedAt) {
+		t.Errorf("imported memory differs: got %+v, want %+v", m, original)
+	}
+	chunks, err := src.Store.GetMemoryChunks(original.ID, true)
+	if err != nil || len(chunks) == 0 {
+		t.Fatalf("source chunks missing: %v", err)
+	}
+	results, err := dst.Store.SearchMemories(chunks[0].Embedding, 1, store.MemoryFilter{})
+	if err != nil || len(results) != 1 || results[0].Memory.Name != "pr_size" {
+		t.Errorf("expected imported vectors to be searchable, got %+v (%v)", results, err)
+	}
+
+	resp = dst.CallTool(t, "export_memories", map[string]any{"type": "project"})
+	jsonl, _ := resp["jsonl"].(string)
+	if resp["count"] != float64(1) || !strings.Contains(jsonl, `"name":"deploy_day"`) {
+		t.Errorf("expected filtered inline export of deploy_day, got %v", resp)
+	}
+
+	defer func(limit int) { maxInlineExport = limit }(maxInlineExport)
+	maxInlineExport = len(jsonl) - 1
+	resp = dst.CallTool(t, "export_memories", map[string]any{"type": "project"})
+	if msg, _ := resp["message"].(string); resp["jsonl"] != nil || !strings.Contains(msg, "pass path") {
+		t.Errorf("expected an oversized inline export to be refused, got %v", resp)
+	}
+}
+
+func TestImportConflictPolicies(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	if _, err := ts.Goldie.Remember(goldie.RememberInput{Name: "alpha", Type: "idea", Body: "original body"}); err != nil {
+		t.Fatalf("seed failed: %v", err)
+	}
+	record := func(body, updated string) string {
+		return `{"name":"alpha","type":"idea","body":"` + body + `","created_at":"2000-01-01T00:00:00Z","updated_at":"` + updated + `"}`
+	}
+	run := func(policy, line string) *goldie.ImportResult {
+		t.Helper()
+		res, err := ts.Goldie.Import(strings.NewReader(line), goldie.ImportOptions{OnConflict: policy})
+		if err != nil {
+			t.Fatalf("import (%s) failed: %v", policy, err)
+		}
+		if len(res.Errors) > 0 {
+			t.Fatalf("import (%s) errors: %v", policy, res.Errors)
+		}
+		return res
+	}
+	body := func(name string) string {
+		t.Helper()
+		m, err := ts.Store.GetMemoryByName(store.DefaultNamespace, name)
+		if err != nil || m == nil {
+			t.Fatalf("memory %s missing: %v", name, err)
+		}
+		return m.Body
+	}
+
+	if res := run(goldie.ConflictSkip, record("skipped", "2100-01-01T00:00:00Z")); res.Skipped != 1 || body("alpha") != "original body" {
+		t.Errorf("skip: got %+v, body %q", res, body("alpha"))
+	}
+	if res := run(goldie.ConflictRename, record("renamed", "2100-01-01T00:00:00Z")); res.Renamed != 1 || body("alpha-2") != "renamed" {
+		t.Errorf("rename: got %+v", res)
+	}
+	if res := run(goldie.ConflictNewer, record("older", "2000-01-01T00:00:00Z")); res.Skipped != 1 || body("alpha") != "original body" {
+		t.Errorf("newer with older record: got %+v, body %q", res, body("alpha"))
+	}
+	if res := run(goldie.ConflictNewer, record("newer", "2100-01-01T00:00:00Z")); res.Overwritten != 1 || body("alpha") != "newer" {
+		t.Errorf("newer with newer record: got %+v, body %q", res, body("alpha"))
+	}
+	if res := run(goldie.ConflictOverwrite, record("overwritten", "2000-01-01T00:00:00Z")); res.Overwritten != 1 || body("alpha") != "overwritten" {
+		t.Errorf("overwrite: got %+v, body %q", res, body("alpha"))
+	}
+	if _, err := ts.Goldie.Import(strings.NewReader(""), goldie.ImportOptions{OnConflict: "merge"}); err == nil {
+		t.Error("expected unknown policy to be rejected")
+	}
+}
+
+func TestImportReembedsForeignOrMissingVectors(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	foreign := `{"name":"foreign","type":"idea","body":"from another model",` +
+		`"embedding":{"backend":"ollama","model":"nomic-embed-text","dimensions":3},` +
+		`"chunks":[{"index":0,"content":"from another model","embedding":[0.1,0.2,0.3]}]}`
+	bare := `{"name":"bare","type":"idea","body":"no vectors at all"}`
+	broken := `{"name":`
+
+	res, err := ts.Goldie.Import(strings.NewReader(foreign+"\n"+bare+"\n"+broken+"\n"), goldie.ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if res.Imported != 2 || res.Reembedded != 2 {
+		t.Errorf("expected 2 imported and re-embedded, got %+v", res)
+	}
+	if len(res.Errors) != 1 || !strings.HasPrefix(res.Errors[0], "line 3") {
+		t.Errorf("expected one error for line 3, got %v", res.Errors)
+	}
+	results, err := ts.Goldie.Recall("from another model", goldie.RecallOptions{Limit: 1, Mode: goldie.RecallModeVector})
+	if err != nil || len(results) != 1 || results[0].Memory.Name != "foreign" {
+		t.Errorf("expected re-embedded memory to be searchable, got %+v (%v)", results, err)
+	}
+}
+
+func TestMCP_MarkdownExportImportRoundTrip(t *testing.T) {
+	src := NewTestSetup(t)
+	defer src.Cleanup()
+	src.SetupGlobals()
+
+	for _, in := range []goldie.RememberInput{
+		{Name: "pr_size", Type: "feedback", Body: "Keep PRs under 400 lines.\n\n- split refactors out", Description: "rule: small PRs", Agent: "codex"},
+		{Name: "/tmp/notes/todo list.txt", Type: "reference", Body: "Buy milk.", Source: "/tmp/notes/todo list.txt"},
+	} {
+		if _, err := src.Goldie.Remember(in); err != nil {
+			t.Fatalf("seed %s failed: %v", in.Name, err)
+		}
+	}
+
+	dir := filepath.Join(src.TempDir, "export")
+	resp := src.CallTool(t, "export_memories", map[string]any{"path": dir, "format": "markdown"})
+	if resp["count"] != float64(2) {
+		t.Fatalf("expected 2 exported, got %v", resp)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "feedback", "pr_size.md"))
+	if err != nil {
+		t.Fatalf("expected feedback/pr_size.md: %v", err)
+	}
+	if !strings.Contains(string(data), `description: "rule: small PRs"`) || !strings.HasSuffix(string(data), "- split refactors out\n") {
+		t.Errorf("unexpected markdown file:\n%s", data)
+	}
+	index, err := os.ReadFile(filepath.Join(dir, "MEMORY.md"))
+	if err != nil || !strings.Contains(string(index), "- [pr_size](feedback/pr_size.md) — rule: small PRs") {
+		t.Errorf("unexpected MEMORY.md (%v):\n%s", err, index)
+	}
+
+	dst := NewTestSetup(t)
+	defer dst.Cleanup()
+	dst.SetupGlobals()
+
+	resp = dst.CallTool(t, "import_memories", map[string]any{"path": dir})
+	result := resp["result"].(map[string]any)
+	if resp["success"] != true || result["imported"] != float64(2) {
+		t.Fatalf("expected 2 imported, got %v", resp)
+	}
+	for _, name := range []string{"pr_size", "/tmp/notes/todo list.txt"} {
+		want, _ := src.Store.GetMemoryByName(store.DefaultNamespace, name)
+		got, err := dst.Store.GetMemoryByName(store.DefaultNamespace, name)
+		if err != nil || got == nil {
+			t.Fatalf("memory %s missing after import: %v", name, err)
+		}
+		if got.Type != want.Type || got.Body != want.Body || got.Description != want.Description ||
+			got.Agent != want.Agent || got.Source != want.Source {
+			t.Errorf("round trip changed %s: got %+v, want %+v", name, got, want)
+		}
+	}
+
+	resp = dst.CallTool(t, "import_memories", map[string]any{"path": dir, "on_conflict": "overwrite"})
+	result = resp["result"].(map[string]any)
+	if result["skipped"] != float64(2) || result["overwritten"] != float64(0) {
+		t.Errorf("expected unchanged files to be skipped, got %v", result)
+	}
+}
+
+func TestImportMarkdownClaudeStyleDirectory(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	dir := filepath.Join(ts.TempDir, "memory")
+	files := map[string]string{
+		"MEMORY.md": "# Memory index\n\n" +
+			"- [User role](user_role.md) — senior Go developer\n" +
+			"- [Testing](feedback_testing.md) — prefers table-driven tests\n",
+		"user_role.md":        "---\nname: user_role\ndescription: 'The user''s role'\nmetadata:\n  type: user\n---\n\nSenior Go developer on the payments team.\n",
+		"feedback_testing.md": "---\nname: feedback_testing\nmetadata:\n  type: feedback\n---\nUse table-driven tests.\n",
+		"project/release.md":  "Releases are cut on Tuesdays.\n",
+		"notes.md":            "No frontmatter and no type directory.\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := ts.Goldie.ImportMarkdown(dir, goldie.ImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportMarkdown failed: %v", err)
+	}
+	if res.Imported != 3 || len(res.Errors) != 1 || !strings.HasPrefix(res.Errors[0], "notes.md") {
+		t.Fatalf("expected 3 imported and notes.md rejected, got %+v", res)
+	}
+
+	for name, want := range map[string]store.Memory{
+		"user_role":        {Type: "user", Description: "The user's role", Body: "Senior Go developer on the payments team."},
+		"feedback_testing": {Type: "feedback", Description: "prefers table-driven tests", Body: "Use table-driven tests."},
+		"release":          {Type: "project", Body: "Releases are cut on Tuesdays."},
+	} {
+		m, err := ts.Store.GetMemoryByName(store.DefaultNamespace, name)
+		if err != nil || m == nil {
+			t.Fatalf("memory %s missing: %v", name, err)
+		}
+		if m.Type != want.Type || m.Description != want.Description || m.Body != want.Body {
+			t.Errorf("%s: got type=%q description=%q body=%q", name, m.Type, m.Description, m.Body)
+		}
+	}
+
+	edited := "---\nname: feedback_testing\nmetadata:\n  type: feedback\n---\nUse table-driven tests with t.Run.\n"
+	if err := os.WriteFile(filepath.Join(dir, "feedback_testing.md"), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = ts.Goldie.ImportMarkdown(dir, goldie.ImportOptions{OnConflict: goldie.ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("second ImportMarkdown failed: %v", err)
+	}
+	if res.Overwritten != 1 || res.Skipped != 2 {
+		t.Errorf("expected only the edited file to be overwritten, got %+v", res)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "feedback_testing"); m == nil || m.Body != "Use table-driven tests with t.Run." {
+		t.Errorf("edited body not imported: %+v", m)
+	}
+}
+
+// ============================================================================
+// Tag tests
+// ============================================================================
+
+func TestMCP_TagFilters(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, args := range []map[string]any{
+		{"name": "goldie_schema", "type": "project", "body": "Migrations are append-only.", "tags": []any{"Goldie", "sqlite"}},
+		{"name": "goldie_release", "type": "project", "body": "Release builds use the fts5 tag.", "tags": []any{"goldie", "release"}},
+		{"name": "other_repo", "type": "project", "body": "Migrations in other repos use goose.", "tags": "other, sqlite"},
+	} {
+		if resp := ts.CallTool(t, "remember", args); resp["success"] != true {
+			t.Fatalf("remember %v failed: %v", args["name"], resp)
+		}
+	}
+
+	m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "goldie_schema")
+	if m == nil || strings.Join(m.Tags, ",") != "goldie,sqlite" {
+		t.Fatalf("expected normalized tags [goldie sqlite], got %+v", m)
+	}
+
+	resp := ts.CallTool(t, "count_memories", map[string]any{"tags_any": []any{"goldie"}})
+	if resp["count"] != float64(2) {
+		t.Errorf("tags_any=goldie: expected 2, got %v", resp["count"])
+	}
+	resp = ts.CallTool(t, "list_memories", map[string]any{"tags_all": []any{"goldie", "sqlite"}})
+	if resp["count"] != float64(1) {
+		t.Errorf("tags_all=goldie,sqlite: expected 1, got %v", resp)
+	}
+	resp = ts.CallTool(t, "recall", map[string]any{"query": "migrations", "mode": "vector", "tags_any": []any{"goldie"}})
+	for _, r := range resp["results"].([]any) {
+		if name := r.(map[string]any)["name"]; name == "other_repo" {
+			t.Errorf("recall with tags_any=goldie returned %v", name)
+		}
+	}
+
+	resp = ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "goldie_release", "tags": []any{"archived"}})
+	if resp["success"] != true {
+		t.Fatalf("update_memory failed: %v", resp)
+	}
+	resp = ts.CallTool(t, "count_memories", map[string]any{"tags_any": []any{"goldie"}})
+	if resp["count"] != float64(1) {
+		t.Errorf("expected retagged memory to drop out of tags_any=goldie, got %v", resp["count"])
+	}
+
+	resp = ts.CallTool(t, "forget", map[string]any{"tags_all": []any{"sqlite", "other"}})
+	if resp["count"] != float64(1) {
+		t.Errorf("forget by tags: expected 1 deleted, got %v", resp)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "other_repo"); m == nil || m.DeletedAt == nil {
+		t.Error("other_repo should have been forgotten")
+	}
+}
+
+func TestTagsSurviveExportImport(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	if _, err := ts.Goldie.Remember(goldie.RememberInput{Name: "tagged", Type: "idea", Body: "body", Tags: []string{"a", "b"}}); err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	var buf strings.Builder
+	if _, err := ts.Goldie.Export(&buf, goldie.ExportOptions{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	dir := filepath.Join(ts.TempDir, "md")
+	if _, err := ts.Goldie.ExportMarkdown(dir, goldie.ExportOptions{}); err != nil {
+		t.Fatalf("ExportMarkdown failed: %v", err)
+	}
+	if _, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Name: "tagged"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+
+	if _, err := ts.Goldie.Import(strings.NewReader(buf.String()), goldie.ImportOptions{}); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "tagged"); m == nil || strings.Join(m.Tags, ",") != "a,b" {
+		t.Errorf("JSONL import lost tags: %+v", m)
+	}
+
+	if _, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Name: "tagged"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+	if _, err := ts.Goldie.ImportMarkdown(dir, goldie.ImportOptions{}); err != nil {
+		t.Fatalf("ImportMarkdown failed: %v", err)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "tagged"); m == nil || strings.Join(m.Tags, ",") != "a,b" {
+		t.Errorf("Markdown import lost tags: %+v", m)
+	}
+}
+
+// ============================================================================
+// Namespace tests
+// ============================================================================
+
+func TestMCP_NamespacesIsolateMemories(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, args := range []map[string]any{
+		{"name": "build", "type": "project", "body": "Alpha builds with make."},
+		{"name": "build", "type": "project", "body": "Beta builds with bazel.", "namespace": "beta"},
+	} {
+		if resp := ts.CallTool(t, "remember", args); resp["success"] != true {
+			t.Fatalf("remember in %v failed: %v", args["namespace"], resp)
+		}
+	}
+
+	resp := ts.CallTool(t, "count_memories", map[string]any{})
+	if resp["count"] != float64(1) {
+		t.Errorf("default namespace: expected 1 memory, got %v", resp["count"])
+	}
+	resp = ts.CallTool(t, "recall", map[string]any{"query": "how do we build", "namespace": "beta"})
+	results, _ := resp["results"].([]any)
+	if len(results) != 1 || results[0].(map[string]any)["namespace"] != "beta" {
+		t.Errorf("recall in beta: expected only the beta memory, got %v", resp)
+	}
+	resp = ts.CallTool(t, "recall", map[string]any{"query": "how do we build", "namespace": "*"})
+	if resp["count"] != float64(2) {
+		t.Errorf("recall across namespaces: expected 2 results, got %v", resp)
+	}
+
+	resp = ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "build", "body": "Beta builds with bazel 7.", "namespace": "beta"})
+	if resp["success"] != true {
+		t.Fatalf("update_memory in beta failed: %v", resp)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "build"); m == nil || m.Body != "Alpha builds with make." {
+		t.Errorf("update in beta leaked into default: %+v", m)
+	}
+
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "x", "type": "idea", "body": "x", "namespace": "*"}); resp["success"] == true {
+		t.Error("remember across all namespaces should fail")
+	}
+
+	resp = ts.CallTool(t, "forget", map[string]any{"name": "build", "namespace": "beta"})
+	if resp["count"] != float64(1) {
+		t.Errorf("forget in beta: expected 1 deleted, got %v", resp)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "build"); m == nil {
+		t.Error("forget in beta deleted the default namespace's memory")
+	}
+}
+
+func TestIndexFileIsUniquePerNamespace(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+	ts.Queue.Start()
+
+	path := filepath.Join(ts.TempDir, "notes.txt")
+	if err := os.WriteFile(path, []byte("shared notes"), 0o644); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+
+	var jobIDs []string
+	for _, ns := range []string{"", "beta"} {
+		resp := ts.CallTool(t, "index_file", map[string]any{"path": path, "namespace": ns})
+		id, _ := resp["job_id"].(string)
+		job, err := ts.Store.WaitForJob(id, 5*time.Second)
+		if err != nil || job.Status != store.JobStatusCompleted {
+			t.Fatalf("index_file in %q did not complete: %+v (%v)", ns, job, err)
+		}
+		jobIDs = append(jobIDs, id)
+	}
+
+	a, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, path)
+	b, _ := ts.Store.GetMemoryByName("beta", path)
+	if a == nil || b == nil || a.ID == b.ID {
+		t.Fatalf("expected one file memory per namespace, got %+v and %+v", a, b)
+	}
+
+	resp := ts.CallTool(t, "list_jobs", map[string]any{"namespace": "beta"})
+	if resp["count"] != float64(1) {
+		t.Errorf("list_jobs in beta: expected 1 job, got %v", resp)
+	}
+	if resp := ts.CallTool(t, "job_status", map[string]any{"id": jobIDs[1]}); resp["status"] != nil {
+		t.Errorf("job_status in default namespace should not see beta's job, got %v", resp)
+	}
+	resp = ts.CallTool(t, "list_jobs", map[string]any{"namespace": "*"})
+	if resp["count"] != float64(2) {
+		t.Errorf("list_jobs across namespaces: expected 2 jobs, got %v", resp)
+	}
+
+	// Pool-wide jobs are listed in every namespace but only cleared with "*".
+	if err := ts.Store.CreateJob("pool", store.JobTypeReembed, store.AllNamespaces, "{}"); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if err := ts.Store.UpdateJobStatus("pool", store.JobStatusCompleted); err != nil {
+		t.Fatalf("UpdateJobStatus failed: %v", err)
+	}
+	if resp := ts.CallTool(t, "list_jobs", map[string]any{"namespace": "beta"}); resp["count"] != float64(2) {
+		t.Errorf("list_jobs in beta: expected its job and the pool-wide one, got %v", resp)
+	}
+	resp = ts.CallTool(t, "clear_queue", map[string]any{"status": "all", "namespace": "beta"})
+	if resp["deleted"] != float64(1) {
+		t.Errorf("clear_queue in beta: expected only beta's job deleted, got %v", resp)
+	}
+	if job, _ := ts.Store.GetJob("pool"); job == nil {
+		t.Error("clear_queue in beta deleted the pool-wide job")
+	}
+}
+
+func TestVectorRecallSkipsOtherNamespacesAndTrash(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	const query = "Deploys go out on Tuesdays."
+	vector := goldie.RecallOptions{Mode: goldie.RecallModeVector}
+
+	big, err := ts.Goldie.WithNamespace("big")
+	if err != nil {
+		t.Fatalf("WithNamespace failed: %v", err)
+	}
+	small, err := ts.Goldie.WithNamespace("small")
+	if err != nil {
+		t.Fatalf("WithNamespace failed: %v", err)
+	}
+	for i := range 40 {
+		if _, err := big.Remember(goldie.RememberInput{Name: fmt.Sprintf("deploy_%d", i), Type: "project", Body: query}); err != nil {
+			t.Fatalf("Remember failed: %v", err)
+		}
+	}
+	if _, err := small.Remember(goldie.RememberInput{Name: "coffee", Type: "project", Body: "Coffee machine: third floor."}); err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	if results, err := small.Recall(query, vector); err != nil || len(results) != 1 {
+		t.Errorf("expected the small namespace's memory despite the big one, got %d (%v)", len(results), err)
+	}
+
+	if _, err := big.ForgetMemory(store.MemoryFilter{Type: "project"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+	if _, err := big.Remember(goldie.RememberInput{Name: "standup", Type: "idea", Body: "Standups start at ten."}); err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	results, err := big.Recall(query, vector)
+	if err != nil || len(results) != 1 || results[0].Memory.Name != "standup" {
+		t.Errorf("expected the live memory despite the trashed ones, got %d (%v)", len(results), err)
+	}
+	if _, err := big.RestoreFromTrash("deploy_0", 0); err != nil {
+		t.Fatalf("RestoreFromTrash failed: %v", err)
+	}
+	if results, _ := big.Recall(query, vector); len(results) != 2 || results[0].Memory.Name != "deploy_0" {
+		t.Errorf("expected the restored memory to be recallable again, got %+v", results)
+	}
+}
+
+func TestImportAcrossNamespacesKeepsRecordNamespace(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	beta, err := ts.Goldie.WithNamespace("beta")
+	if err != nil {
+		t.Fatalf("WithNamespace failed: %v", err)
+	}
+	for _, g := range []*goldie.Goldie{ts.Goldie, beta} {
+		if _, err := g.Remember(goldie.RememberInput{Name: "shared", Type: "idea", Body: "in " + g.Namespace()}); err != nil {
+			t.Fatalf("Remember in %s failed: %v", g.Namespace(), err)
+		}
+	}
+
+	all, err := ts.Goldie.WithNamespace(store.AllNamespaces)
+	if err != nil {
+		t.Fatalf("WithNamespace(*) failed: %v", err)
+	}
+	var buf strings.Builder
+	if n, err := all.Export(&buf, goldie.ExportOptions{}); err != nil || n != 2 {
+		t.Fatalf("Export across namespaces: n=%d err=%v", n, err)
+	}
+	if _, err := all.ForgetMemory(store.MemoryFilter{Name: "shared"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+
+	// The forgotten memories are still in the trash, so the import overwrites them.
+	res, err := all.Import(strings.NewReader(buf.String()), goldie.ImportOptions{})
+	if err != nil || res.Overwritten != 2 {
+		t.Fatalf("Import across namespaces: %+v (%v)", res, err)
+	}
+	for _, ns := range []string{store.DefaultNamespace, "beta"} {
+		if m, _ := ts.Store.GetMemoryByName(ns, "shared"); m == nil || m.Body != "in "+ns || m.DeletedAt != nil {
+			t.Errorf("expected shared memory back in %s, got %+v", ns, m)
+		}
+	}
+
+	if _, err := ts.Goldie.WithNamespace("two words"); err == nil {
+		t.Error("expected an invalid namespace to be rejected")
+	}
+}
+
+// ============================================================================
+// Revision history tests
+// ============================================================================
+
+func TestMCP_RevisionHistoryDiffAndRestore(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	original := "Run tests with -race.\nKeep PRs small.\nNo mocks for the database.\n"
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "workflow", "type": "feedback", "body": original}); resp["success"] != true {
+		t.Fatalf("remember failed: %v", resp)
+	}
+	resp := ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "workflow", "body": "Keep PRs small.\n"})
+	if resp["success"] != true {
+		t.Fatalf("update_memory failed: %v", resp)
+	}
+	if resp := ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "workflow", "tags": []any{"ci"}}); resp["success"] != true {
+		t.Fatalf("tag update failed: %v", resp)
+	}
+
+	resp = ts.CallTool(t, "memory_history", map[string]any{"id_or_name": "workflow"})
+	if resp["count"] != float64(1) {
+		t.Fatalf("expected 1 revision (tag-only updates aren't saved), got %v", resp)
+	}
+
+	resp = ts.CallTool(t, "memory_diff", map[string]any{"id_or_name": "workflow"})
+	want := "--- workflow (revision 1)\n+++ workflow (current)\n@@ -1,3 +1 @@\n-Run tests with -race.\n Keep PRs small.\n-No mocks for the database.\n"
+	if resp["diff"] != want {
+		t.Errorf("unexpected diff:\n%v\nwant:\n%s", resp["diff"], want)
+	}
+
+	resp = ts.CallTool(t, "restore_memory", map[string]any{"id_or_name": "workflow", "revision": float64(1)})
+	if resp["success"] != true {
+		t.Fatalf("restore_memory failed: %v", resp)
+	}
+	m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "workflow")
+	if m == nil || m.Body != original || strings.Join(m.Tags, ",") != "ci" {
+		t.Fatalf("restore did not roll back the body: %+v", m)
+	}
+	results, err := ts.Goldie.Recall("race", goldie.RecallOptions{Mode: goldie.RecallModeVector, Limit: 1})
+	if err != nil || len(results) != 1 || !strings.Contains(results[0].Excerpt, "-race") {
+		t.Errorf("restored body was not re-embedded: %+v (%v)", results, err)
+	}
+
+	resp = ts.CallTool(t, "memory_history", map[string]any{"id_or_name": "workflow"})
+	if resp["count"] != float64(2) {
+		t.Errorf("expected the restore to save the replaced state, got %v", resp)
+	}
+	if resp := ts.CallTool(t, "memory_diff", map[string]any{"id_or_name": "workflow", "from": float64(1)}); resp["diff"] != "" {
+		t.Errorf("expected revision 1 to equal the restored memory, got %v", resp["diff"])
+	}
+}
+
+func TestIndexFileSavesReplacedRevision(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	path := filepath.Join(ts.TempDir, "notes.txt")
+	for _, body := range []string{"first draft", "second draft"} {
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("writing file: %v", err)
+		}
+		if _, err := ts.Goldie.IndexFile(path, ""); err != nil {
+			t.Fatalf("IndexFile failed: %v", err)
+		}
+	}
+	_, revisions, err := ts.Goldie.MemoryHistory(path)
+	if err != nil || len(revisions) != 1 || revisions[0].Body != "first draft" {
+		t.Fatalf("expected the first draft saved as revision 1, got %+v (%v)", revisions, err)
+	}
+
+	if _, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Name: path}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+	if _, err := ts.Store.PurgeTrash(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if revisions, _ := ts.Store.ListMemoryRevisions(revisions[0].MemoryID); len(revisions) != 0 {
+		t.Errorf("expected revisions deleted with the memory, got %d", len(revisions))
+	}
+}
+
+func TestImportOverwriteSavesReplacedRevision(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	if _, err := ts.Goldie.Remember(goldie.RememberInput{Name: "alpha", Type: "idea", Body: "original body"}); err != nil {
+		t.Fatalf("seed failed: %v", err)
+	}
+	line := `{"name":"alpha","type":"idea","body":"imported body","updated_at":"2100-01-01T00:00:00Z"}`
+	res, err := ts.Goldie.Import(strings.NewReader(line), goldie.ImportOptions{OnConflict: goldie.ConflictOverwrite})
+	if err != nil || res.Overwritten != 1 {
+		t.Fatalf("import failed: %+v (%v)", res, err)
+	}
+
+	resp := ts.CallTool(t, "memory_history", map[string]any{"id_or_name": "alpha"})
+	if resp["count"] != float64(1) {
+		t.Fatalf("expected the overwritten state saved as a revision, got %v", resp)
+	}
+	resp = ts.CallTool(t, "memory_diff", map[string]any{"id_or_name": "alpha"})
+	if diff, _ := resp["diff"].(string); !strings.Contains(diff, "-original body") || !strings.Contains(diff, "+imported body") {
+		t.Errorf("expected the revision to hold the original body, got %v", resp)
+	}
+}
+
+// ============================================================================
+// Trash tests
+// ============================================================================
+
+func TestMCP_ForgetMovesToTrashAndRestores(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, name := range []string{"keep_prs_small", "run_race_tests"} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "feedback", "body": "Guidance: " + name}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+
+	resp := ts.CallTool(t, "forget", map[string]any{"query": "guidance", "limit": float64(1), "dry_run": true})
+	if resp["dry_run"] != true || resp["count"] != float64(1) {
+		t.Fatalf("dry run: expected 1 candidate, got %v", resp)
+	}
+	if resp := ts.CallTool(t, "count_memories", map[string]any{}); resp["count"] != float64(2) {
+		t.Fatalf("dry run forgot something: %v", resp)
+	}
+
+	resp = ts.CallTool(t, "forget", map[string]any{"name": "keep_prs_small"})
+	if resp["count"] != float64(1) {
+		t.Fatalf("forget: expected 1, got %v", resp)
+	}
+	if resp := ts.CallTool(t, "count_memories", map[string]any{}); resp["count"] != float64(1) {
+		t.Errorf("forgotten memory still counted: %v", resp)
+	}
+	results, err := ts.Goldie.Recall("keep_prs_small", goldie.RecallOptions{})
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	for _, r := range results {
+		if r.Memory.Name == "keep_prs_small" {
+			t.Error("recall returned a forgotten memory")
+		}
+	}
+
+	resp = ts.CallTool(t, "list_trash", map[string]any{})
+	memories, _ := resp["memories"].([]any)
+	if len(memories) != 1 || memories[0].(map[string]any)["name"] != "keep_prs_small" || memories[0].(map[string]any)["purge_at"] == nil {
+		t.Fatalf("list_trash: expected keep_prs_small with a purge time, got %v", resp)
+	}
+
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "keep_prs_small", "type": "feedback", "body": "x"}); resp["success"] == true {
+		t.Error("remember should refuse a name held by the trash")
+	}
+	if resp := ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "keep_prs_small", "body": "x"}); resp["success"] == true {
+		t.Error("update_memory should not find a forgotten memory")
+	}
+
+	if resp := ts.CallTool(t, "restore_memory", map[string]any{"id_or_name": "keep_prs_small", "revision": float64(7)}); resp["success"] == true {
+		t.Error("restoring a missing revision should fail")
+	}
+	if resp := ts.CallTool(t, "count_memories", map[string]any{}); resp["count"] != float64(1) {
+		t.Errorf("a failed revision restore took the memory out of the trash: %v", resp)
+	}
+
+	resp = ts.CallTool(t, "restore_memory", map[string]any{"id_or_name": "keep_prs_small"})
+	if resp["success"] != true {
+		t.Fatalf("restore_memory failed: %v", resp)
+	}
+	if resp := ts.CallTool(t, "count_memories", map[string]any{}); resp["count"] != float64(2) {
+		t.Errorf("restored memory not counted: %v", resp)
+	}
+	if resp := ts.CallTool(t, "restore_memory", map[string]any{"id_or_name": "keep_prs_small"}); resp["success"] == true {
+		t.Error("restoring a live memory without a revision should fail")
+	}
+}
+
+func TestPurgeTrashHonorsRetention(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	if _, err := ts.Goldie.Remember(goldie.RememberInput{Name: "old", Type: "idea", Body: "old idea"}); err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+	if _, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Name: "old"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+	if n, err := ts.Goldie.PurgeTrash(); err != nil || n != 0 {
+		t.Fatalf("expected nothing purged within the retention, got %d (%v)", n, err)
+	}
+
+	short, err := goldie.New(goldie.Config{
+		DBPath:         filepath.Join(ts.TempDir, "test.db"),
+		Dimensions:     ts.Goldie.EmbeddingInfo().Dimensions,
+		Embedder:       NewMockEmbedder(ts.Goldie.EmbeddingInfo().Dimensions, 0),
+		TrashRetention: -time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("opening with a negative retention: %v", err)
+	}
+	defer short.Close()
+	if n, _ := short.PurgeTrash(); n != 0 {
+		t.Errorf("negative retention should keep the trash, purged %d", n)
+	}
+
+	if n, err := ts.Store.PurgeTrash(time.Now().Add(time.Minute)); err != nil || n != 1 {
+		t.Fatalf("expected 1 purged, got %d (%v)", n, err)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "old"); m != nil {
+		t.Errorf("purged memory still stored: %+v", m)
+	}
+}
+
+func TestIndexFileRestoresForgottenFile(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	path := filepath.Join(ts.TempDir, "notes.txt")
+	if err := os.WriteFile(path, []byte("notes"), 0o644); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+	if _, err := ts.Goldie.IndexFile(path, ""); err != nil {
+		t.Fatalf("IndexFile failed: %v", err)
+	}
+	if _, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Name: path}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+	if _, err := ts.Goldie.IndexFile(path, ""); err != nil {
+		t.Fatalf("re-indexing a forgotten file failed: %v", err)
+	}
+	if m, _ := ts.Goldie.GetMemory(path); m == nil {
+		t.Error("re-indexing should take the file out of the trash")
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"0":   0,
+	} {
+		if got, err := goldie.ParseDuration(in); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := goldie.ParseDuration("soon"); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+}
+
+func TestMCP_ExpiredMemoriesAreHidden(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for name, expires := range map[string]string{
+		"renew_certs":   "2020-01-01T00:00:00Z",
+		"review_budget": "7d",
+	} {
+		resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "todo", "body": "Todo: " + name, "expires_at": expires})
+		if resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "bad", "type": "todo", "body": "x", "expires_at": "whenever"}); resp["success"] == true {
+		t.Error("remember should reject an unparseable expires_at")
+	}
+
+	if resp := ts.CallTool(t, "count_memories", map[string]any{}); resp["count"] != float64(1) {
+		t.Errorf("expired memory still counted: %v", resp)
+	}
+	if resp := ts.CallTool(t, "count_memories", map[string]any{"include_expired": true}); resp["count"] != float64(2) {
+		t.Errorf("include_expired: expected 2, got %v", resp)
+	}
+	results, err := ts.Goldie.Recall("renew_certs", goldie.RecallOptions{})
+	if err != nil {
+		t.Fatalf("Recall failed: %v", err)
+	}
+	for _, r := range results {
+		if r.Memory.Name == "renew_certs" {
+			t.Error("recall returned an expired memory")
+		}
+	}
+
+	resp := ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "renew_certs", "expires_at": ""})
+	if resp["success"] != true {
+		t.Fatalf("clearing expires_at failed: %v", resp)
+	}
+	if resp := ts.CallTool(t, "count_memories", map[string]any{}); resp["count"] != float64(2) {
+		t.Errorf("memory with cleared expiry not counted: %v", resp)
+	}
+}
+
+func TestSweepExpiredAppliesTypePolicy(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+
+	g, err := goldie.New(goldie.Config{
+		DBPath:       filepath.Join(ts.TempDir, "test.db"),
+		Dimensions:   ts.Goldie.EmbeddingInfo().Dimensions,
+		Embedder:     NewMockEmbedder(ts.Goldie.EmbeddingInfo().Dimensions, 0),
+		ExpiryPolicy: map[string]string{"todo": goldie.ExpiryDelete, "idea": goldie.ExpiryKeep},
+	})
+	if err != nil {
+		t.Fatalf("opening with an expiry policy: %v", err)
+	}
+	defer g.Close()
+
+	past := time.Now().Add(-time.Hour)
+	for name, typ := range map[string]string{"old_todo": "todo", "old_reminder": "reminder", "old_idea": "idea"} {
+		if _, err := g.Remember(goldie.RememberInput{Name: name, Type: typ, Body: "expired " + typ, ExpiresAt: past}); err != nil {
+			t.Fatalf("Remember %s failed: %v", name, err)
+		}
+	}
+	if _, err := g.Remember(goldie.RememberInput{Name: "live_todo", Type: "todo", Body: "live", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Remember failed: %v", err)
+	}
+
+	res, err := g.SweepExpired()
+	if err != nil {
+		t.Fatalf("SweepExpired failed: %v", err)
+	}
+	if res.Archived != 1 || res.Deleted != 1 {
+		t.Fatalf("expected 1 archived and 1 deleted, got %+v", res)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "old_todo"); m != nil {
+		t.Error("expired todo should be deleted")
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "old_reminder"); m == nil || m.DeletedAt == nil {
+		t.Errorf("expired reminder should be in the trash, got %+v", m)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "old_idea"); m == nil || m.DeletedAt != nil {
+		t.Errorf("expired idea should be kept, got %+v", m)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "live_todo"); m == nil || m.DeletedAt != nil {
+		t.Errorf("unexpired todo should be untouched, got %+v", m)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"2026-04-01T08:00:00Z": time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC),
+		"2026-04-01":           time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local),
+		"7d":                   now.Add(7 * 24 * time.Hour),
+		"36h":                  now.Add(36 * time.Hour),
+	} {
+		if got, err := goldie.ParseTime(in, now); err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"soon", "-1h", "0"} {
+		if _, err := goldie.ParseTime(in, now); err == nil {
+			t.Errorf("ParseTime(%q): expected an error", in)
+		}
+	}
+
+	policy, err := goldie.ParseExpiryPolicy("todo=delete, reminder=archive,*=keep")
+	if err != nil {
+		t.Fatalf("ParseExpiryPolicy failed: %v", err)
+	}
+	if policy["todo"] != goldie.ExpiryDelete || policy["reminder"] != goldie.ExpiryArchive || policy["*"] != goldie.ExpiryKeep {
+		t.Errorf("unexpected policy: %v", policy)
+	}
+	for _, in := range []string{"todo", "todo=shred", "chore=delete"} {
+		if _, err := goldie.ParseExpiryPolicy(in); err == nil {
+			t.Errorf("ParseExpiryPolicy(%q): expected an error", in)
+		}
+	}
+}
+
+func TestMCP_DueRemindersCompleteAndSnooze(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for name, due := range map[string]string{
+		"renew_certs":   "2020-01-01T00:00:00Z",
+		"review_budget": "2h",
+		"plan_offsite":  "30d",
+	} {
+		resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "todo", "body": "Todo: " + name, "due_at": due})
+		if resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+		if resp["memory"].(map[string]any)["status"] != store.MemoryStatusOpen {
+			t.Errorf("%s: expected a new todo to be open, got %v", name, resp["memory"])
+		}
+	}
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "idea_due", "type": "idea", "body": "x", "due_at": "1d"}); resp["success"] == true {
+		t.Error("remember should refuse a due date on an idea")
+	}
+
+	resp := ts.CallTool(t, "due_reminders", map[string]any{"within": "1d"})
+	memories, _ := resp["memories"].([]any)
+	if len(memories) != 2 || resp["overdue"] != float64(1) {
+		t.Fatalf("due_reminders: expected 2 items, 1 overdue, got %v", resp)
+	}
+	if first := memories[0].(map[string]any); first["name"] != "renew_certs" || first["overdue"] != true {
+		t.Errorf("expected the overdue item first, got %v", first)
+	}
+
+	if resp := ts.CallTool(t, "complete_memory", map[string]any{"id_or_name": "renew_certs"}); resp["success"] != true {
+		t.Fatalf("complete_memory failed: %v", resp)
+	}
+	if resp := ts.CallTool(t, "snooze_memory", map[string]any{"id_or_name": "review_budget", "until": "3d"}); resp["success"] != true {
+		t.Fatalf("snooze_memory failed: %v", resp)
+	}
+	resp = ts.CallTool(t, "due_reminders", map[string]any{"within": "1d"})
+	if resp["count"] != nil {
+		t.Errorf("expected nothing due after complete and snooze, got %v", resp)
+	}
+	resp = ts.CallTool(t, "due_reminders", map[string]any{"within": "7d"})
+	if memories, _ := resp["memories"].([]any); len(memories) != 1 || memories[0].(map[string]any)["status"] != store.MemoryStatusSnoozed {
+		t.Errorf("expected the snoozed item to come due again, got %v", resp)
+	}
+
+	if resp := ts.CallTool(t, "count_memories", map[string]any{"status": "done"}); resp["count"] != float64(1) {
+		t.Errorf("status filter: expected 1 done, got %v", resp)
+	}
+	if resp := ts.CallTool(t, "list_memories", map[string]any{"due_after": "7d"}); resp["count"] != float64(1) {
+		t.Errorf("due_after filter: expected plan_offsite only, got %v", resp)
+	}
+	if resp := ts.CallTool(t, "list_memories", map[string]any{"status": "later"}); resp["count"] != nil {
+		t.Errorf("expected an invalid status to be rejected, got %v", resp)
+	}
+
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "note", "type": "idea", "body": "an idea"}); resp["success"] != true {
+		t.Fatalf("remember failed: %v", resp)
+	}
+	if resp := ts.CallTool(t, "complete_memory", map[string]any{"id_or_name": "note"}); resp["success"] == true {
+		t.Error("complete_memory should refuse an idea")
+	}
+	resp = ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "plan_offsite", "type": "project"})
+	if m := resp["memory"].(map[string]any); m["status"] != nil || m["due_at"] != nil {
+		t.Errorf("turning a todo into a project should drop due date and status, got %v", m)
+	}
+	_, revisions, err := ts.Goldie.MemoryHistory("renew_certs")
+	if err != nil {
+		t.Fatalf("MemoryHistory failed: %v", err)
+	}
+	if len(revisions) != 0 {
+		t.Errorf("completing a todo should not save a revision, got %d", len(revisions))
+	}
+}
+
+func TestMCP_LinksAndRelated(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, name := range []string{"project_cache", "feedback_cache_ttl", "idea_cache_v2", "summary_cache"} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "idea", "body": "Cache notes: " + name}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+	for _, l := range [][3]string{
+		{"feedback_cache_ttl", "project_cache", ""},
+		{"idea_cache_v2", "feedback_cache_ttl", "supersedes"},
+		{"summary_cache", "idea_cache_v2", "derived_from"},
+	} {
+		resp := ts.CallTool(t, "link_memories", map[string]any{"from": l[0], "to": l[1], "type": l[2]})
+		if resp["success"] != true || resp["created"] != true {
+			t.Fatalf("link %v failed: %v", l, resp)
+		}
+	}
+	if resp := ts.CallTool(t, "link_memories", map[string]any{"from": "feedback_cache_ttl", "to": "project_cache"}); resp["created"] != false {
+		t.Errorf("relinking should report an existing link, got %v", resp)
+	}
+	if resp := ts.CallTool(t, "link_memories", map[string]any{"from": "project_cache", "to": "project_cache"}); resp["success"] == true {
+		t.Error("linking a memory to itself should fail")
+	}
+	if resp := ts.CallTool(t, "link_memories", map[string]any{"from": "project_cache", "to": "idea_cache_v2", "type": "likes"}); resp["success"] == true {
+		t.Error("an unknown link type should be rejected")
+	}
+
+	resp := ts.CallTool(t, "related", map[string]any{"id_or_name": "project_cache"})
+	related, _ := resp["related"].([]any)
+	if len(related) != 1 {
+		t.Fatalf("depth 1: expected 1 related memory, got %v", resp)
+	}
+	if r := related[0].(map[string]any); r["name"] != "feedback_cache_ttl" || r["direction"] != "in" || r["link_type"] != "relates_to" {
+		t.Errorf("unexpected hop: %v", r)
+	}
+	resp = ts.CallTool(t, "related", map[string]any{"id_or_name": "project_cache", "depth": float64(3)})
+	if resp["count"] != float64(3) {
+		t.Errorf("depth 3: expected 3 related memories, got %v", resp)
+	}
+	resp = ts.CallTool(t, "related", map[string]any{"id_or_name": "idea_cache_v2", "types": []any{"derived_from"}})
+	if related, _ := resp["related"].([]any); len(related) != 1 || related[0].(map[string]any)["name"] != "summary_cache" {
+		t.Errorf("types filter: expected summary_cache only, got %v", resp)
+	}
+
+	resp = ts.CallTool(t, "recall", map[string]any{"query": "cache notes", "limit": float64(4), "expand_links": true})
+	results, _ := resp["results"].([]any)
+	if len(results) != 4 {
+		t.Fatalf("recall: expected 4 results, got %v", resp)
+	}
+	for _, r := range results {
+		r := r.(map[string]any)
+		want := 2
+		if r["name"] == "project_cache" || r["name"] == "summary_cache" {
+			want = 1
+		}
+		if links, _ := r["links"].([]any); len(links) != want {
+			t.Errorf("expand_links: expected %d direct link(s) for %v, got %v", want, r["name"], r["links"])
+		}
+	}
+
+	if _, err := ts.Goldie.ForgetMemory(store.MemoryFilter{Name: "feedback_cache_ttl"}, goldie.ForgetQuery{}); err != nil {
+		t.Fatalf("ForgetMemory failed: %v", err)
+	}
+	if resp := ts.CallTool(t, "related", map[string]any{"id_or_name": "project_cache", "depth": float64(3)}); resp["count"] != float64(0) {
+		t.Errorf("related should not walk through forgotten memories, got %v", resp)
+	}
+
+	if resp := ts.CallTool(t, "unlink_memories", map[string]any{"from": "summary_cache", "to": "idea_cache_v2"}); resp["count"] != float64(1) {
+		t.Errorf("unlink: expected 1 removed, got %v", resp)
+	}
+	if _, err := ts.Store.PurgeTrash(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	idea, _ := ts.Goldie.GetMemory("idea_cache_v2")
+	if links, _ := ts.Store.ListMemoryLinks([]string{idea.ID}); len(links) != 0 {
+		t.Errorf("purging a memory should drop its links, got %+v", links)
+	}
+}
+
+func TestMCP_GetMemory(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	body := strings.Repeat("Long design note about the cache layer. ", 60)
+	for _, name := range []string{"design_cache", "design_queue"} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "project", "body": body}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+	if resp := ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "design_cache", "body": body + "Revised."}); resp["success"] != true {
+		t.Fatalf("update_memory failed: %v", resp)
+	}
+	if resp := ts.CallTool(t, "link_memories", map[string]any{"from": "design_queue", "to": "design_cache"}); resp["success"] != true {
+		t.Fatalf("link_memories failed: %v", resp)
+	}
+
+	resp := ts.CallTool(t, "get_memory", map[string]any{"id_or_name": "design_cache"})
+	memories, _ := resp["memories"].([]any)
+	if len(memories) != 1 {
+		t.Fatalf("get_memory: expected 1 memory, got %v", resp)
+	}
+	m := memories[0].(map[string]any)
+	if m["body"] != body+"Revised." {
+		t.Errorf("expected the full body, got %v", m["body"])
+	}
+	chunks, _ := m["chunks"].([]any)
+	if len(chunks) < 2 || chunks[1].(map[string]any)["index"] != float64(1) {
+		t.Errorf("expected several indexed chunks, got %v", m["chunks"])
+	}
+	if revs, _ := m["revisions"].(map[string]any); revs["count"] != float64(1) {
+		t.Errorf("expected 1 saved revision, got %v", m["revisions"])
+	}
+	if links, _ := m["links"].([]any); len(links) != 1 || links[0].(map[string]any)["direction"] != "in" {
+		t.Errorf("expected 1 incoming link, got %v", m["links"])
+	}
+
+	resp = ts.CallTool(t, "get_memory", map[string]any{"ids_or_names": []any{"design_queue", "missing", "design_cache"}})
+	if resp["count"] != float64(2) {
+		t.Errorf("expected 2 memories, got %v", resp)
+	}
+	if notFound, _ := resp["not_found"].([]any); len(notFound) != 1 || notFound[0] != "missing" {
+		t.Errorf("expected missing to be reported, got %v", resp["not_found"])
+	}
+	if resp := ts.CallTool(t, "get_memory", map[string]any{"id_or_name": "missing"}); resp["count"] != nil {
+		t.Errorf("expected an error for a missing memory, got %v", resp)
+	}
+}
+
+func TestMCP_RenameMemory(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, name := range []string{"feedbak_tests", "feedback_style"} {
+		resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "feedback", "body": "Run the race detector.", "agent": "agent-a"})
+		if resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+	before, _ := ts.Goldie.GetMemory("feedbak_tests")
+
+	resp := ts.CallTool(t, "rename_memory", map[string]any{"id_or_name": "feedbak_tests", "new_name": "feedback_tests"})
+	if resp["success"] != true {
+		t.Fatalf("rename_memory failed: %v", resp)
+	}
+	after, _ := ts.Goldie.GetMemory("feedback_tests")
+	if after == nil || after.ID != before.ID || after.Agent != "agent-a" || !after.CreatedAt.Equal(before.CreatedAt) {
+		t.Errorf("rename should keep id, provenance and created_at: before %+v, after %+v", before, after)
+	}
+	if m, _ := ts.Goldie.GetMemory("feedbak_tests"); m != nil {
+		t.Error("old name still resolves")
+	}
+	if resp := ts.CallTool(t, "rename_memory", map[string]any{"id_or_name": "feedback_tests", "new_name": "feedback_style"}); resp["success"] == true {
+		t.Error("renaming onto a taken name should fail")
+	}
+}
+
+func TestMCP_MergeMemories(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for name, tag := range map[string]string{"cache_notes": "cache", "cache_ttl": "ttl", "cache_keys": "keys", "cache_misc": "misc"} {
+		resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "project", "body": "Notes on " + name + ".", "tags": []any{tag}})
+		if resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+
+	resp := ts.CallTool(t, "merge_memories", map[string]any{"target": "cache_notes", "sources": []any{"cache_ttl", "cache_keys"}})
+	if resp["success"] != true {
+		t.Fatalf("merge_memories failed: %v", resp)
+	}
+	target, _ := ts.Goldie.GetMemory("cache_notes")
+	if target.Body != "Notes on cache_notes.\n\nNotes on cache_ttl.\n\nNotes on cache_keys." {
+		t.Errorf("unexpected merged body: %q", target.Body)
+	}
+	if !slices.Equal(target.Tags, []string{"cache", "keys", "ttl"}) {
+		t.Errorf("expected the union of tags, got %v", target.Tags)
+	}
+	for _, name := range []string{"cache_ttl", "cache_keys"} {
+		if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, name); m == nil || m.DeletedAt == nil {
+			t.Errorf("%s should be archived, got %+v", name, m)
+		}
+	}
+	if links, _ := ts.Store.ListMemoryLinks([]string{target.ID}); len(links) != 2 || links[0].Type != goldie.LinkDerivedFrom {
+		t.Errorf("expected 2 derived_from links, got %+v", links)
+	}
+	if _, revisions, _ := ts.Goldie.MemoryHistory("cache_notes"); len(revisions) != 1 || revisions[0].Body != "Notes on cache_notes." {
+		t.Errorf("expected the pre-merge body saved as a revision, got %+v", revisions)
+	}
+
+	// Purging the archived sources keeps the target's merge provenance.
+	if n, err := ts.Store.PurgeTrash(time.Now().Add(time.Minute)); err != nil || n != 2 {
+		t.Fatalf("expected both sources purged, got %d (%v)", n, err)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "cache_ttl"); m != nil {
+		t.Fatalf("purged source still stored: %+v", m)
+	}
+	if links, _ := ts.Store.ListMemoryLinks([]string{target.ID}); len(links) != 2 || links[0].Type != goldie.LinkDerivedFrom || links[0].FromID != target.ID {
+		t.Errorf("expected the derived_from links to survive the purge, got %+v", links)
+	}
+	missing := func() []string {
+		t.Helper()
+		var ids []string
+		resp := ts.CallTool(t, "related", map[string]any{"id_or_name": "cache_notes"})
+		related, _ := resp["related"].([]any)
+		for _, r := range related {
+			if r := r.(map[string]any); r["missing"] == true && r["link_type"] == goldie.LinkDerivedFrom {
+				ids = append(ids, r["id"].(string))
+			}
+		}
+		return ids
+	}
+	if ids := missing(); len(ids) != 2 {
+		t.Errorf("expected related to list both purged sources by id, got %v", ids)
+	}
+
+	resp = ts.CallTool(t, "merge_memories", map[string]any{"target": "cache_notes", "sources": []any{"cache_misc"}, "mode": "replace", "originals": "delete"})
+	if resp["success"] != true {
+		t.Fatalf("merge_memories replace failed: %v", resp)
+	}
+	if target, _ := ts.Goldie.GetMemory("cache_notes"); target.Body != "Notes on cache_misc." {
+		t.Errorf("replace: unexpected body %q", target.Body)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "cache_misc"); m != nil {
+		t.Error("deleted source still stored")
+	}
+	if ids := missing(); len(ids) != 3 {
+		t.Errorf("expected the deleted source linked and listed too, got %v", ids)
+	}
+
+	if resp := ts.CallTool(t, "merge_memories", map[string]any{"target": "cache_notes", "sources": []any{"cache_notes"}}); resp["success"] == true {
+		t.Error("merging a memory into itself should fail")
+	}
+	if resp := ts.CallTool(t, "merge_memories", map[string]any{"target": "cache_notes", "sources": []any{"missing"}}); resp["success"] == true {
+		t.Error("merging a missing memory should fail")
+	}
+}
+
+func TestMCP_RememberNearDuplicates(t *testing.T) {
+	// Unrelated texts from the mock embedder sit around 0.75 cosine
+	// similarity, so a lower threshold makes every memory a near-duplicate.
+	ts := newTestSetupWithConfig(t, goldie.Config{
+		Embedder:           NewMockEmbedder(384, 0),
+		DuplicateThreshold: 0.5,
+	})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	resp := ts.CallTool(t, "remember", map[string]any{"name": "retry_policy", "type": "project", "body": "Retry failed uploads three times.", "tags": []any{"uploads"}})
+	if resp["success"] != true {
+		t.Fatalf("remember failed: %v", resp)
+	}
+	if _, ok := resp["duplicates"]; ok {
+		t.Errorf("first memory should have no duplicates: %v", resp)
+	}
+
+	resp = ts.CallTool(t, "remember", map[string]any{"name": "upload_retries", "type": "project", "body": "Uploads are retried three times."})
+	if resp["success"] != true {
+		t.Fatalf("remember with warn failed: %v", resp)
+	}
+	dups, _ := resp["duplicates"].([]any)
+	if len(dups) != 1 || dups[0].(map[string]any)["name"] != "retry_policy" {
+		t.Errorf("expected retry_policy as a duplicate, got %v", resp["duplicates"])
+	}
+
+	resp = ts.CallTool(t, "remember", map[string]any{"name": "retry_again", "type": "project", "body": "Three retries for uploads.", "on_duplicate": "error"})
+	if msg, _ := resp["message"].(string); resp["success"] == true || !strings.Contains(msg, "nearly the same content") {
+		t.Errorf("on_duplicate=error should refuse, got %v", resp)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "retry_again"); m != nil {
+		t.Error("refused memory was stored")
+	}
+
+	resp = ts.CallTool(t, "remember", map[string]any{"name": "retry_merge", "type": "project", "body": "Back off between retries.", "tags": []any{"backoff"}, "on_duplicate": "merge"})
+	if resp["success"] != true {
+		t.Fatalf("remember with merge failed: %v", resp)
+	}
+	into, _ := resp["merged_into"].(string)
+	if into == "" {
+		t.Fatalf("expected merged_into, got %v", resp)
+	}
+	merged, _ := ts.Goldie.GetMemory(into)
+	if !strings.HasSuffix(merged.Body, "\n\nBack off between retries.") || !slices.Contains(merged.Tags, "backoff") {
+		t.Errorf("merge target missing the new content: %+v", merged)
+	}
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "retry_merge"); m != nil {
+		t.Error("merged memory should not be created")
+	}
+
+	resp = ts.CallTool(t, "remember", map[string]any{"name": "retry_ignored", "type": "project", "body": "Retries.", "on_duplicate": "ignore"})
+	if resp["success"] != true {
+		t.Fatalf("remember with ignore failed: %v", resp)
+	}
+	if _, ok := resp["duplicates"]; ok {
+		t.Errorf("on_duplicate=ignore should skip the probe: %v", resp)
+	}
+
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "bad", "type": "project", "body": "x", "on_duplicate": "skip"}); resp["success"] == true {
+		t.Error("invalid on_duplicate should fail")
+	}
+
+	if _, err := goldie.New(goldie.Config{DBPath: filepath.Join(t.TempDir(), "bad.db"), Embedder: NewMockEmbedder(384, 0), DuplicateThreshold: 1.5}); err == nil {
+		t.Error("threshold above 1 should be rejected")
+	}
+}
+
+func TestMCP_RememberDefaultThresholdAllowsDistinctMemories(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, name := range []string{"alpha_notes", "beta_notes"} {
+		resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "project", "body": "Notes on " + name + ".", "on_duplicate": "error"})
+		if resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+}
+
+// bodyEmbedder embeds only the chunk text, not the name and description
+// prepended to it, so memories with the same body get the same vectors.
+type bodyEmbedder struct{ *MockEmbedder }
+
+func (b bodyEmbedder) Embed(text string) ([]float32, error) {
+	if i := strings.LastIndex(text, "\n\n"); i >= 0 {
+		text = text[i+2:]
+	}
+	return b.MockEmbedder.Embed(text)
+}
+
+func TestMCP_FindAndResolveDuplicates(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+	ts.Queue.Start()
+
+	for name, body := range map[string]string{
+		"deploy_day":      "Deploys go out on Tuesdays.",
+		"deploy_schedule": "Deploys go out on Tuesdays.",
+		"go_indent":       "Go code is indented with tabs.",
+		"go_tabs":         "Go code is indented with tabs.",
+		"coffee":          "The office coffee machine is on the third floor.",
+	} {
+		resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "project", "body": body, "on_duplicate": "ignore"})
+		if resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+
+	resp := ts.CallTool(t, "find_duplicates", map[string]any{})
+	jobID, _ := resp["job_id"].(string)
+	if jobID == "" {
+		t.Fatalf("find_duplicates did not queue a job: %v", resp)
+	}
+	job, err := ts.Store.WaitForJob(jobID, 5*time.Second)
+	if err != nil || job.Status != store.JobStatusCompleted {
+		t.Fatalf("find_duplicates job did not complete: %+v, %v", job, err)
+	}
+	var result queue.FindDuplicatesResult
+	if err := json.Unmarshal([]byte(job.Result), &result); err != nil {
+		t.Fatalf("bad job result %q: %v", job.Result, err)
+	}
+	if result.Scanned != 5 || len(result.Clusters) != 2 {
+		t.Fatalf("expected 2 clusters among 5 memories, got %+v", result)
+	}
+	clusterOf := make(map[string]int)
+	for _, c := range result.Clusters {
+		if len(c.Memories) != 2 || c.Score < 0.99 || len(c.Pairs) != 1 {
+			t.Errorf("unexpected cluster %+v", c)
+		}
+		for _, m := range c.Memories {
+			clusterOf[m.Name] = c.Number
+		}
+	}
+	if clusterOf["deploy_day"] != clusterOf["deploy_schedule"] || clusterOf["go_indent"] != clusterOf["go_tabs"] || clusterOf["coffee"] != 0 {
+		t.Errorf("memories clustered wrongly: %v", clusterOf)
+	}
+
+	resp = ts.CallTool(t, "resolve_duplicates", map[string]any{"job_id": jobID, "clusters": []any{float64(clusterOf["deploy_day"])}, "action": "merge"})
+	if resp["success"] != true {
+		t.Fatalf("resolve_duplicates merge failed: %v", resp)
+	}
+	kept := resp["clusters"].([]any)[0].(map[string]any)["kept"].(string)
+	if m, _ := ts.Goldie.GetMemory(kept); m == nil || m.Body != "Deploys go out on Tuesdays.\n\nDeploys go out on Tuesdays." {
+		t.Errorf("kept memory not merged: %+v", m)
+	}
+	if n, _ := ts.Goldie.CountMemories(store.MemoryFilter{}); n != 4 {
+		t.Errorf("expected 4 memories after the merge, got %d", n)
+	}
+
+	resp = ts.CallTool(t, "resolve_duplicates", map[string]any{"job_id": jobID, "clusters": []any{float64(clusterOf["go_tabs"]), float64(clusterOf["deploy_day"]), float64(9)}, "action": "forget"})
+	if resp["success"] != true {
+		t.Fatalf("resolve_duplicates forget failed: %v", resp)
+	}
+	entries := resp["clusters"].([]any)
+	if forgotten, _ := entries[0].(map[string]any)["forgotten"].([]any); len(forgotten) != 1 {
+		t.Errorf("expected one memory forgotten, got %v", entries[0])
+	}
+	for _, e := range entries[1:] {
+		if _, ok := e.(map[string]any)["error"]; !ok {
+			t.Errorf("expected an error for a resolved or unknown cluster, got %v", e)
+		}
+	}
+	if trash, _ := ts.Goldie.ListTrash(store.MemoryFilter{}, 0); len(trash) != 2 {
+		t.Errorf("expected the merged and forgotten memories in the trash, got %d", len(trash))
+	}
+
+	if resp := ts.CallTool(t, "resolve_duplicates", map[string]any{"job_id": jobID, "clusters": []any{float64(1)}, "action": "keep"}); resp["success"] == true {
+		t.Error("invalid action should fail")
+	}
+}
+
+func TestMCP_RecallMinScore(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	g := ts.Goldie
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	if g.MinScore() != -1 {
+		t.Errorf("expected no cutoff for an uncalibrated backend, got %v", g.MinScore())
+	}
+	for name, body := range map[string]string{"deploy_day": "Deploys go out on Tuesdays.", "coffee": "Coffee machine: third floor."} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "project", "body": body}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+
+	resp := ts.CallTool(t, "recall", map[string]any{"query": "Deploys go out on Tuesdays.", "mode": "vector"})
+	if resp["count"] != float64(2) || resp["min_score"] != float64(-1) {
+		t.Errorf("expected both memories without a cutoff, got %v", resp)
+	}
+
+	resp = ts.CallTool(t, "recall", map[string]any{"query": "Deploys go out on Tuesdays.", "mode": "vector", "min_score": 0.95})
+	results, _ := resp["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("expected only the exact match above min_score, got %v", resp)
+	}
+	top := results[0].(map[string]any)
+	if top["name"] != "deploy_day" || top["metric"] != store.MetricCosine || top["distance"].(float64) > 0.001 {
+		t.Errorf("unexpected top result %v", top)
+	}
+
+	resp = ts.CallTool(t, "recall", map[string]any{"query": "Deploys go out on Tuesdays.", "min_score": 0.95})
+	results, _ = resp["results"].([]any)
+	if len(results) != 1 {
+		t.Fatalf("expected hybrid recall to apply min_score to vector matches, got %v", resp)
+	}
+	top = results[0].(map[string]any)
+	wantMetric := store.MetricRRF
+	if !g.Store().FullTextEnabled() {
+		wantMetric = store.MetricCosine // hybrid degrades to vector-only
+	}
+	if top["name"] != "deploy_day" || top["metric"] != wantMetric {
+		t.Errorf("expected deploy_day measured in %s, got %v", wantMetric, top)
+	}
+	if _, ok := top["distance"]; ok != (wantMetric == store.MetricCosine) {
+		t.Errorf("expected a cosine distance only on cosine results, got %v", top)
+	}
+
+	resp = ts.CallTool(t, "forget", map[string]any{"query": "Coffee machine: third floor.", "min_score": 0.95, "dry_run": true})
+	if deleted, _ := resp["deleted"].([]any); len(deleted) != 1 || deleted[0].(map[string]any)["name"] != "coffee" {
+		t.Errorf("expected forget to select only the close match, got %v", resp)
+	}
+	// "out" also appears in deploy_day, which a keyword match would select.
+	resp = ts.CallTool(t, "forget", map[string]any{"query": "Coffee machine: third floor. out", "min_score": 0.95, "dry_run": true})
+	if deleted, _ := resp["deleted"].([]any); len(deleted) > 1 {
+		t.Errorf("expected forget to ignore a shared keyword, got %v", resp)
+	}
+
+	if resp := ts.CallTool(t, "recall", map[string]any{"query": "anything", "min_score": 2.0}); resp["count"] != nil {
+		t.Errorf("min_score above 1 should fail, got %v", resp)
+	}
+
+	if goldie.DefaultMinScore("minilm") <= 0 || goldie.DefaultMinScore("ollama") <= goldie.DefaultMinScore("minilm") {
+		t.Error("expected calibrated defaults for minilm and ollama")
+	}
+	strict, err := goldie.New(goldie.Config{DBPath: filepath.Join(t.TempDir(), "strict.db"), Embedder: NewMockEmbedder(384, 0), MinScore: 0.9})
+	if err != nil {
+		t.Fatalf("failed to create goldie: %v", err)
+	}
+	defer strict.Close()
+	if strict.MinScore() != 0.9 {
+		t.Errorf("expected the configured minimum score, got %v", strict.MinScore())
+	}
+}
+
+func TestMCP_RecallDiversity(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for name, body := range map[string]string{
+		"deploy_a": "Deploys go out on Tuesdays.",
+		"deploy_b": "Deploys go out on Tuesdays.",
+		"deploy_c": "Deploys go out on Tuesdays.",
+		"coffee":   "Coffee machine: third floor.",
+		"standup":  "Standups start at ten.",
+	} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "project", "body": body, "on_duplicate": "ignore"}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+
+	names := func(resp map[string]any) []string {
+		var out []string
+		results, _ := resp["results"].([]any)
+		for _, r := range results {
+			out = append(out, r.(map[string]any)["name"].(string))
+		}
+		return out
+	}
+	copies := func(got []string) int {
+		n := 0
+		for _, name := range got {
+			if strings.HasPrefix(name, "deploy_") {
+				n++
+			}
+		}
+		return n
+	}
+
+	plain := names(ts.CallTool(t, "recall", map[string]any{"query": "Deploys go out on Tuesdays.", "mode": "vector", "limit": 3.0}))
+	if len(plain) != 3 || copies(plain) != 3 {
+		t.Errorf("expected the three copies by relevance alone, got %v", plain)
+	}
+
+	diverse := names(ts.CallTool(t, "recall", map[string]any{"query": "Deploys go out on Tuesdays.", "mode": "vector", "limit": 3.0, "diversity": 0.9}))
+	if len(diverse) != 3 || copies(diverse) != 1 || !strings.HasPrefix(diverse[0], "deploy_") {
+		t.Errorf("expected one copy first and two other memories, got %v", diverse)
+	}
+
+	if resp := ts.CallTool(t, "recall", map[string]any{"query": "Deploys", "diversity": 1.5}); resp["count"] != nil {
+		t.Errorf("diversity above 1 should fail, got %v", resp)
+	}
+}
+
+func TestMCP_RecallStatsAndBoosts(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, args := range []map[string]any{
+		{"name": "parking_a", "type": "reference", "body": "Parking is in lot B.", "importance": 1.0},
+		{"name": "parking_b", "type": "reference", "body": "Parking is in lot B."},
+		{"name": "standup", "type": "project", "body": "Standups start at ten."},
+	} {
+		args["on_duplicate"] = "ignore"
+		if resp := ts.CallTool(t, "remember", args); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", args["name"], resp)
+		}
+	}
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "too_important", "type": "idea", "body": "x", "importance": 1.5}); resp["success"] == true {
+		t.Errorf("importance above 1 should fail, got %v", resp)
+	}
+	before, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "parking_a")
+
+	first := func(resp map[string]any) map[string]any {
+		results, _ := resp["results"].([]any)
+		if len(results) == 0 {
+			t.Fatalf("expected results, got %v", resp)
+		}
+		return results[0].(map[string]any)
+	}
+
+	top := first(ts.CallTool(t, "recall", map[string]any{"query": "Parking is in lot B.", "mode": "vector", "limit": 1.0, "boost": true}))
+	if top["name"] != "parking_a" || top["importance"] != 1.0 {
+		t.Errorf("expected the important copy first, got %v", top)
+	}
+	if b, _ := top["boost"].(float64); b < 0.09 {
+		t.Errorf("expected an importance boost, got %v", top["boost"])
+	}
+
+	m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "parking_a")
+	if m.RecallCount != 1 || m.LastRecalledAt == nil {
+		t.Errorf("expected recall recorded, got count %d at %v", m.RecallCount, m.LastRecalledAt)
+	}
+	if !m.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("recall should not touch updated_at: %v -> %v", before.UpdatedAt, m.UpdatedAt)
+	}
+	if other, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "parking_b"); other.RecallCount != 0 {
+		t.Errorf("memory cut by the limit should not count as recalled, got %d", other.RecallCount)
+	}
+
+	// Without importance, the copy recalled before wins on usage.
+	top = first(ts.CallTool(t, "recall", map[string]any{"query": "Parking is in lot B.", "mode": "vector", "limit": 1.0, "importance_boost": 0.0, "usage_boost": 1.0}))
+	if top["name"] != "parking_a" || top["recall_count"] != 2.0 {
+		t.Errorf("expected the recalled copy first with two recalls, got %v", top)
+	}
+
+	for name, importance := range map[string]float64{"parking_a": 0, "parking_b": 0.5} {
+		if resp := ts.CallTool(t, "update_memory", map[string]any{"id_or_name": name, "importance": importance}); resp["success"] != true {
+			t.Fatalf("update_memory failed: %v", resp)
+		}
+	}
+	top = first(ts.CallTool(t, "recall", map[string]any{"query": "Parking is in lot B.", "mode": "vector", "limit": 1.0, "usage_boost": 0.0, "importance_boost": 1.0, "recency_boost": 0.0}))
+	if top["name"] != "parking_b" || top["importance"] != 0.5 {
+		t.Errorf("expected the copy that is now important, got %v", top)
+	}
+
+	ts.CallTool(t, "forget", map[string]any{"query": "Parking is in lot B.", "dry_run": true})
+	if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "parking_a"); m.RecallCount != 2 {
+		t.Errorf("forget previews should not count as recalls, got %d", m.RecallCount)
+	}
+
+	for _, args := range []map[string]any{
+		{"query": "Parking", "recency_boost": 2.0},
+		{"query": "Parking", "recency_half_life": "soon"},
+	} {
+		if resp := ts.CallTool(t, "recall", args); resp["count"] != nil {
+			t.Errorf("recall with %v should fail, got %v", args, resp)
+		}
+	}
+}
+
+func TestMCP_Bootstrap(t *testing.T) {
+	ts := NewTestSetup(t)
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	for _, args := range []map[string]any{
+		{"name": "user_prefs", "type": "user", "body": "Prefers terse answers.", "agent": "claude", "pinned": true},
+		{"name": "feedback_tests", "type": "feedback", "body": "Always run the race detector.", "pinned": true},
+		{"name": "feedback_other", "type": "feedback", "body": "Use tabs.", "agent": "other", "pinned": true},
+		{"name": "project_x", "type": "project", "body": "Project X ships in March.", "agent": "claude"},
+	} {
+		if resp := ts.CallTool(t, "remember", args); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", args["name"], resp)
+		}
+	}
+
+	names := func(v any) []string {
+		var out []string
+		list, _ := v.([]any)
+		for _, e := range list {
+			out = append(out, e.(map[string]any)["name"].(string))
+		}
+		return out
+	}
+
+	resp := ts.CallTool(t, "bootstrap", map[string]any{"agent": "claude"})
+	if got := names(resp["pinned"]); !slices.Equal(got, []string{"user_prefs", "feedback_tests"}) {
+		t.Errorf("expected pinned memories of the agent and of no agent, user first, got %v", got)
+	}
+	if got := names(resp["recent"]); !slices.Equal(got, []string{"project_x"}) {
+		t.Errorf("expected the unpinned memory as recent, got %v", got)
+	}
+	text, _ := resp["text"].(string)
+	if !strings.HasPrefix(text, "# Pinned memories") || !strings.Contains(text, "# Recent memories") || !strings.Contains(text, "Prefers terse answers.") {
+		t.Errorf("unexpected bootstrap text: %q", text)
+	}
+	if pinned, _ := resp["pinned"].([]any); len(pinned) > 0 && pinned[0].(map[string]any)["body"] != nil {
+		t.Errorf("bodies should only be returned in text, got %v", pinned[0])
+	}
+
+	resp = ts.CallTool(t, "bootstrap", map[string]any{"recent": 0.0})
+	if got := names(resp["pinned"]); len(got) != 3 || resp["recent"] == nil || len(names(resp["recent"])) != 0 {
+		t.Errorf("expected every pinned memory and no recent ones, got %v / %v", got, resp["recent"])
+	}
+
+	resp = ts.CallTool(t, "bootstrap", map[string]any{"agent": "claude", "max_chars": 80.0})
+	if got := names(resp["pinned"]); !slices.Equal(got, []string{"user_prefs"}) {
+		t.Errorf("expected only the first pinned memory to fit, got %v", got)
+	}
+	if omitted, _ := resp["omitted"].([]any); len(omitted) != 2 {
+		t.Errorf("expected two memories over budget, got %v", resp["omitted"])
+	}
+	if size, _ := resp["size"].(float64); size > 80 || resp["unit"] != "chars" {
+		t.Errorf("bootstrap text exceeds the budget: %v %v", size, resp["unit"])
+	}
+
+	resp = ts.CallTool(t, "bootstrap", map[string]any{"agent": "claude", "max_tokens": 20.0})
+	if size, _ := resp["size"].(float64); size == 0 || size > 20 || resp["unit"] != "tokens" || len(names(resp["pinned"])) != 1 {
+		t.Errorf("expected one pinned memory within 20 tokens, got %v %v: %v", size, resp["unit"], names(resp["pinned"]))
+	}
+
+	if resp := ts.CallTool(t, "update_memory", map[string]any{"id_or_name": "feedback_tests", "pinned": false}); resp["success"] != true {
+		t.Fatalf("update_memory failed: %v", resp)
+	}
+	resp = ts.CallTool(t, "list_memories", map[string]any{"pinned": true})
+	if got := names(resp["memories"]); len(got) != 2 || slices.Contains(got, "feedback_tests") {
+		t.Errorf("expected two pinned memories after unpinning, got %v", got)
+	}
+
+	req := mcp.GetPromptRequest{}
+	req.Params.Name = "bootstrap"
+	req.Params.Arguments = map[string]string{"agent": "claude", "recent": "0"}
+	prompt, err := handleBootstrapPrompt(context.Background(), req)
+	if err != nil {
+		t.Fatalf("bootstrap prompt failed: %v", err)
+	}
+	if len(prompt.Messages) != 1 {
+		t.Fatalf("expected one prompt message, got %d", len(prompt.Messages))
+	}
+	content, _ := prompt.Messages[0].Content.(mcp.TextContent)
+	if !strings.Contains(content.Text, "Prefers terse answers.") || strings.Contains(content.Text, "Project X") || strings.Contains(content.Text, "race detector") {
+		t.Errorf("unexpected prompt text: %q", content.Text)
+	}
+	req.Params.Arguments = map[string]string{"max_chars": "lots"}
+	if _, err := handleBootstrapPrompt(context.Background(), req); err == nil {
+		t.Error("expected a non-numeric budget to fail")
+	}
+}
+
+func TestMCP_RecallBudget(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	words := strings.Fields("alpha bravo charlie delta echo foxtrot golf hotel india juliet kilo lima mike november oscar papa quebec romeo sierra tango uniform victor whiskey xray yankee zulu")
+	var long strings.Builder
+	for i := range 400 {
+		long.WriteString(words[i%26] + words[(i/26)%26] + " ")
+	}
+	for name, body := range map[string]string{
+		"manual":     strings.TrimSpace(long.String()),
+		"short_fact": "Deploys go out on Tuesdays.",
+	} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "reference", "body": body}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+	manual, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "manual")
+	chunks, _ := ts.Store.GetMemoryChunks(manual.ID, false)
+	if len(chunks) < 5 {
+		t.Fatalf("expected a long memory, got %d chunks", len(chunks))
+	}
+	matched := chunks[2].Content
+
+	byName := func(resp map[string]any) map[string]map[string]any {
+		out := make(map[string]map[string]any)
+		results, _ := resp["results"].([]any)
+		for _, r := range results {
+			entry := r.(map[string]any)
+			out[entry["name"].(string)] = entry
+		}
+		return out
+	}
+
+	resp := ts.CallTool(t, "recall", map[string]any{"query": matched, "mode": "vector", "max_tokens": 1000.0})
+	results := byName(resp)
+	body, _ := results["manual"]["body"].(string)
+	if !strings.Contains(body, matched) || body == manual.Body || results["manual"]["truncated"] != true {
+		t.Errorf("expected the matched chunk and its neighbours from the long memory, got %d chars", len(body))
+	}
+	if !strings.HasPrefix(body, goldie.TruncationMarker+"\n") || !strings.HasSuffix(body, "\n"+goldie.TruncationMarker) {
+		t.Errorf("expected truncation markers on both sides, got %q...%q", body[:20], body[len(body)-20:])
+	}
+	if !strings.Contains(body, chunks[1].Content) || strings.Contains(body, chunks[4].Content) {
+		t.Error("expected exactly the neighbouring chunks around the match")
+	}
+	if results["short_fact"]["body"] != "Deploys go out on Tuesdays." || results["short_fact"]["truncated"] != nil {
+		t.Errorf("expected the full body of the short memory, got %v", results["short_fact"])
+	}
+	if _, ok := results["manual"]["excerpt"]; ok {
+		t.Error("packed results should not repeat the excerpt")
+	}
+	budget, _ := resp["budget"].(map[string]any)
+	if used, _ := budget["used"].(float64); budget["unit"] != "tokens" || used == 0 || used > 1000 {
+		t.Errorf("unexpected budget report: %v", budget)
+	}
+
+	resp = ts.CallTool(t, "recall", map[string]any{"query": matched, "mode": "vector", "max_chars": 120.0})
+	results = byName(resp)
+	body, _ = results["manual"]["body"].(string)
+	if len([]rune(body)) > 120 || !strings.HasSuffix(body, goldie.TruncationMarker) || len(results) != 1 {
+		t.Errorf("expected only a truncated excerpt within 120 chars, got %q (%d results)", body, len(results))
+	}
+	if omitted, _ := resp["omitted"].([]any); len(omitted) != 1 || omitted[0] != "short_fact" {
+		t.Errorf("expected the short memory omitted, got %v", resp["omitted"])
+	}
+	for name, want := range map[string]int{"manual": 2, "short_fact": 1} {
+		if m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, name); m == nil || m.RecallCount != want {
+			t.Errorf("expected %s recalled %d times, omitted results not counted, got %+v", name, want, m)
+		}
+	}
+
+	plain := byName(ts.CallTool(t, "recall", map[string]any{"query": matched, "mode": "vector"}))
+	if plain["manual"]["body"] != manual.Body || plain["manual"]["excerpt"] != matched {
+		t.Error("without a budget recall should return full bodies and excerpts")
+	}
+}
+
+func TestMCP_RecallContextChunks(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	words := strings.Fields("alpha bravo charlie delta echo foxtrot golf hotel india juliet kilo lima mike november oscar papa quebec romeo sierra tango uniform victor whiskey xray yankee zulu")
+	var long strings.Builder
+	for i := range 400 {
+		long.WriteString(words[i%26] + words[(i/26)%26] + " ")
+	}
+	if resp := ts.CallTool(t, "remember", map[string]any{"name": "manual", "type": "reference", "body": strings.TrimSpace(long.String())}); resp["success"] != true {
+		t.Fatalf("remember failed: %v", resp)
+	}
+	manual, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "manual")
+	chunks, _ := ts.Store.GetMemoryChunks(manual.ID, false)
+	if len(chunks) < 5 {
+		t.Fatalf("expected a long memory, got %d chunks", len(chunks))
+	}
+
+	first := func(resp map[string]any) map[string]any {
+		results, _ := resp["results"].([]any)
+		if len(results) != 1 {
+			t.Fatalf("expected one result, got %v", resp)
+		}
+		return results[0].(map[string]any)
+	}
+	ints := func(v any) []int {
+		var out []int
+		list, _ := v.([]any)
+		for _, n := range list {
+			out = append(out, int(n.(float64)))
+		}
+		return out
+	}
+
+	plain := first(ts.CallTool(t, "recall", map[string]any{"query": chunks[2].Content, "mode": "vector", "limit": 1.0}))
+	if plain["excerpt"] != chunks[2].Content || plain["chunk_index"] != 2.0 || !slices.Contains(ints(plain["matched_chunks"]), 2) {
+		t.Errorf("expected chunk 2 as the excerpt and match, got index %v matched %v", plain["chunk_index"], plain["matched_chunks"])
+	}
+	if plain["excerpt_chunks"] != nil {
+		t.Errorf("excerpt should not be widened by default, got %v", plain["excerpt_chunks"])
+	}
+
+	wide := first(ts.CallTool(t, "recall", map[string]any{"query": chunks[2].Content, "mode": "vector", "limit": 1.0, "context_chunks": 1.0}))
+	excerpt, _ := wide["excerpt"].(string)
+	for i := 1; i <= 3; i++ {
+		if !strings.Contains(excerpt, chunks[i].Content) {
+			t.Errorf("expected chunk %d in the widened excerpt", i)
+		}
+	}
+	if strings.Contains(excerpt, chunks[4].Content) || !strings.Contains(manual.Body, excerpt) {
+		t.Error("expected the widened excerpt to be one overlap-free span of the body")
+	}
+	if got := ints(wide["excerpt_chunks"]); !slices.Equal(got, []int{1, 2, 3}) || wide["chunk_index"] != 2.0 {
+		t.Errorf("expected chunks 1-3 around chunk 2, got %v (best %v)", got, wide["chunk_index"])
+	}
+
+	edge := first(ts.CallTool(t, "recall", map[string]any{"query": chunks[0].Content, "mode": "vector", "limit": 1.0, "context_chunks": 2.0}))
+	if got := ints(edge["excerpt_chunks"]); !slices.Equal(got, []int{0, 1, 2}) || !strings.HasPrefix(manual.Body, edge["excerpt"].(string)) {
+		t.Errorf("expected the window clipped at the first chunk, got %v", got)
+	}
+
+	if resp := ts.CallTool(t, "recall", map[string]any{"query": "alpha", "context_chunks": 9.0}); resp["count"] != nil {
+		t.Errorf("context_chunks above the maximum should fail, got %v", resp)
+	}
+
+	if ts.Store.FullTextEnabled() {
+		kw := first(ts.CallTool(t, "recall", map[string]any{"query": "mikelima", "mode": "keyword", "limit": 1.0}))
+		idx := int(kw["chunk_index"].(float64))
+		if kw["excerpt"] != chunks[idx].Content || !strings.Contains(chunks[idx].Content, "mikelima") || !slices.Contains(ints(kw["matched_chunks"]), idx) {
+			t.Errorf("expected the keyword excerpt to be its matching chunk %d, got %v", idx, kw["matched_chunks"])
+		}
+	}
+}
+
+func TestMCP_RecallChunkAggregates(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	words := strings.Fields("alpha bravo charlie delta echo foxtrot golf hotel india juliet kilo lima mike november oscar papa quebec romeo sierra tango uniform victor whiskey xray yankee zulu")
+	var long strings.Builder
+	for i := range 400 {
+		long.WriteString(words[i%26] + words[(i/26)%26] + " ")
+	}
+	const query = "where the deploy runbook lives"
+	for name, body := range map[string]string{"manual": strings.TrimSpace(long.String()), "note": query} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "reference", "body": body}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+
+	names := func(resp map[string]any) []string {
+		var out []string
+		results, _ := resp["results"].([]any)
+		for _, r := range results {
+			out = append(out, r.(map[string]any)["name"].(string))
+		}
+		return out
+	}
+	recall := func(args map[string]any) map[string]any {
+		args["query"] = query
+		args["mode"] = "vector"
+		return ts.CallTool(t, "recall", args)
+	}
+
+	// The note matches exactly with its only chunk; every chunk of the
+	// manual matches loosely.
+	if got := names(recall(map[string]any{})); !slices.Equal(got, []string{"note", "manual"}) {
+		t.Errorf("expected the exact match first by its best chunk, got %v", got)
+	}
+	if got := names(recall(map[string]any{"aggregate": "mean"})); !slices.Equal(got, []string{"note", "manual"}) {
+		t.Errorf("expected the exact match first by mean, got %v", got)
+	}
+	resp := recall(map[string]any{"aggregate": "sum"})
+	if got := names(resp); !slices.Equal(got, []string{"manual", "note"}) {
+		t.Fatalf("expected several loose matches to outweigh one exact match by sum, got %v", got)
+	}
+	if resp["aggregate"] != "sum" {
+		t.Errorf("expected the aggregate in the response, got %v", resp["aggregate"])
+	}
+	manual := resp["results"].([]any)[0].(map[string]any)
+	chunks, _ := manual["chunks"].([]any)
+	if len(chunks) != goldie.DefaultChunksPerMemory {
+		t.Fatalf("expected %d chunks by default when aggregating, got %d", goldie.DefaultChunksPerMemory, len(chunks))
+	}
+	var sum float64
+	prev := 2.0
+	for _, c := range chunks {
+		c := c.(map[string]any)
+		score := c["score"].(float64)
+		if score > prev || c["excerpt"] == "" {
+			t.Errorf("expected chunks best first with excerpts, got %v", chunks)
+		}
+		prev = score
+		sum += score
+	}
+	if score := manual["score"].(float64); score-sum > 1e-4 || sum-score > 1e-4 {
+		t.Errorf("expected the score to be the sum of its chunks %v, got %v", sum, score)
+	}
+	if first := chunks[0].(map[string]any); first["index"] != manual["chunk_index"] {
+		t.Errorf("expected the best chunk to be the excerpt's, got %v and %v", first["index"], manual["chunk_index"])
+	}
+
+	two := recall(map[string]any{"chunks_per_memory": 2.0, "limit": 1.0})
+	if got, _ := two["results"].([]any)[0].(map[string]any)["chunks"].([]any); len(got) != 1 {
+		t.Errorf("expected the note's only chunk, got %v", got)
+	}
+	two = recall(map[string]any{"chunks_per_memory": 2.0})
+	if got, _ := two["results"].([]any)[1].(map[string]any)["chunks"].([]any); len(got) != 2 {
+		t.Errorf("expected two of the manual's chunks, got %v", got)
+	}
+
+	// In hybrid recall the manual's chunks come from the vector search that
+	// found it; its keyword match on a chunk below min_score isn't pooled in.
+	resp = ts.CallTool(t, "recall", map[string]any{"query": "alphaalpha", "mode": "hybrid", "chunks_per_memory": 10.0, "min_score": 0.75})
+	for _, r := range resp["results"].([]any) {
+		r := r.(map[string]any)
+		chunks, _ := r["chunks"].([]any)
+		prev := math.Inf(1)
+		for _, c := range chunks {
+			c := c.(map[string]any)
+			score := c["score"].(float64)
+			if c["metric"] != store.MetricCosine || score > prev {
+				t.Errorf("expected %s's hybrid chunks all cosine and best first, got %v", r["name"], chunks)
+				break
+			}
+			prev = score
+		}
+	}
+
+	if resp := recall(map[string]any{"aggregate": "median"}); resp["count"] != nil {
+		t.Errorf("an unknown aggregate should fail, got %v", resp)
+	}
+	if resp := recall(map[string]any{"chunks_per_memory": 11.0}); resp["count"] != nil {
+		t.Errorf("chunks_per_memory above the maximum should fail, got %v", resp)
+	}
+}
+
+func TestMCP_MarkdownChunking(t *testing.T) {
+	ts := newTestSetupWithConfig(t, goldie.Config{Embedder: bodyEmbedder{NewMockEmbedder(384, 0)}})
+	defer ts.Cleanup()
+	ts.SetupGlobals()
+
+	code := "```sh\n" + strings.Repeat("make build TARGET=linux\n", 30) + "\n# not a heading\nmake install\n```"
+	table := "| flag | meaning |\n|------|---------|\n" + strings.Repeat("| -v | verbose |\n", 10)
+	doc := "Written for the build team.\n\n# Guide\n\nHow to build.\n\n## Install\n\n" +
+		strings.Repeat("Install the toolchain first. ", 18) + "\n\n" + code + "\n\n## Usage\n\n" + table + "\n### Flags\n\nFlags combine."
+	path := filepath.Join(ts.TempDir, "guide.md")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.Goldie.IndexFile(path, ""); err != nil {
+		t.Fatalf("IndexFile failed: %v", err)
+	}
+	absPath, _ := filepath.Abs(path)
+	m, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, absPath)
+	chunks, _ := ts.Store.GetMemoryChunks(m.ID, false)
+
+	want := []struct{ path, prefix string }{
+		{"", "Written for"},
+		{"Guide", "# Guide"},
+		{"Guide > Install", "## Install"},
+		{"Guide > Install", "```sh"},
+		{"Guide > Usage", "## Usage"},
+	}
+	if len(chunks) != len(want) {
+		for _, c := range chunks {
+			t.Logf("chunk %d [%s]: %.40q", c.Index, c.HeadingPath, c.Content)
+		}
+		t.Fatalf("expected %d chunks, got %d", len(want), len(chunks))
+	}
+	for i, w := range want {
+		if chunks[i].HeadingPath != w.path || !strings.HasPrefix(chunks[i].Content, w.prefix) {
+			t.Errorf("chunk %d: expected %q under %q, got %.40q under %q", i, w.prefix, w.path, chunks[i].Content, chunks[i].HeadingPath)
+		}
+	}
+	if chunks[3].Content != code {
+		t.Error("expected the oversized code block whole in one chunk")
+	}
+	if !strings.Contains(chunks[4].Content, table) || !strings.HasSuffix(chunks[4].Content, "Flags combine.") {
+		t.Error("expected the table and its subsection in the section's chunk")
+	}
+
+	resp := ts.CallTool(t, "get_memory", map[string]any{"id_or_name": absPath})
+	memories, _ := resp["memories"].([]any)
+	if len(memories) != 1 {
+		t.Fatalf("get_memory: expected 1 memory, got %v", resp)
+	}
+	if got, _ := memories[0].(map[string]any)["chunks"].([]any); len(got) != len(want) || got[1].(map[string]any)["heading_path"] != "Guide" {
+		t.Errorf("expected heading paths in get_memory, got %v", got)
+	}
+	recalled := ts.CallTool(t, "recall", map[string]any{"query": chunks[4].Content, "mode": "vector", "limit": 1.0})
+	if results, _ := recalled["results"].([]any); len(results) != 1 || results[0].(map[string]any)["heading_path"] != "Guide > Usage" {
+		t.Errorf("expected the matched chunk's heading path in recall, got %v", recalled["results"])
+	}
+
+	// Memory bodies are chunked as Markdown when they look like it.
+	for name, body := range map[string]string{"notes": "## Notes\n\nKeep it short.", "plain": "Just # a sentence."} {
+		if resp := ts.CallTool(t, "remember", map[string]any{"name": name, "type": "reference", "body": body}); resp["success"] != true {
+			t.Fatalf("remember %s failed: %v", name, resp)
+		}
+	}
+	notes, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "notes")
+	if c, _ := ts.Store.GetMemoryChunks(notes.ID, false); len(c) != 1 || c[0].HeadingPath != "Notes" {
+		t.Errorf("expected a Markdown chunk under Notes, got %+v", c)
+	}
+	plain, _ := ts.Store.GetMemoryByName(store.DefaultNamespace, "plain")
+	if c, _ := ts.Store.GetMemoryChunks(plain.ID, false); len(c) != 1 || c[0].HeadingPath != "" {
+		t.Errorf("expected a plain chunk, got %+v", c)
+	}
+}
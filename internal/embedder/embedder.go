@@ -15,6 +15,8 @@ type Interface interface {
 	Embed(text string) ([]float32, error)
 	EmbedBatch(texts []string) ([][]float32, error)
 	GetDimensions() int
+	Backend() string // backend name, e.g. "minilm" or "ollama"
+	Model() string   // model identifier within the backend
 	Warmup() error
 	Close() error
 }
@@ -75,6 +77,16 @@ func (e *Embedder) GetDimensions() int {
 	return minilm.Dimensions
 }
 
+// Backend returns the backend name
+func (e *Embedder) Backend() string {
+	return "minilm"
+}
+
+// Model returns the embedded model identifier
+func (e *Embedder) Model() string {
+	return minilm.ModelName
+}
+
 // Warmup pre-loads the model by running a test embedding
 func (e *Embedder) Warmup() error {
 	_, err := e.Embed("warmup")
@@ -89,6 +101,12 @@ func (e *Embedder) Close() error {
 	return nil
 }
 
+// CountTokens returns the number of MiniLM tokens in text, for sizing text
+// handed to agents.
+func CountTokens(text string) (int, error) {
+	return minilm.CountTokens(text)
+}
+
 // CosineSimilarity computes cosine similarity between two vectors
 func CosineSimilarity(a, b []float32) float32 {
 	if len(a) != len(b) {
@@ -14,6 +14,8 @@ import (
 const (
 	// Dimensions is the output embedding dimension for all-MiniLM-L6-v2
 	Dimensions = 384
+	// ModelName identifies the embedded model
+	ModelName = "all-MiniLM-L6-v2"
 )
 
 // MiniLM provides text embeddings using the all-MiniLM-L6-v2 ONNX model.
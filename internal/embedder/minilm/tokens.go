@@ -0,0 +1,46 @@
+package minilm
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/sugarme/tokenizer"
+	"github.com/sugarme/tokenizer/pretrained"
+)
+
+// counter is a tokenizer without truncation or padding, loaded on first use
+// by CountTokens.
+var (
+	counterOnce sync.Once
+	counterMu   sync.Mutex
+	counter     *tokenizer.Tokenizer
+	counterErr  error
+)
+
+// CountTokens returns how many tokens the MiniLM tokenizer splits text into,
+// without special tokens. It only needs the tokenizer, not the ONNX runtime,
+// so it works whatever the embedding backend.
+func CountTokens(text string) (int, error) {
+	counterOnce.Do(func() {
+		counter, counterErr = pretrained.FromReader(bytes.NewBuffer(tokenizerData))
+		if counterErr == nil {
+			counter.WithTruncation(nil)
+			counter.WithPadding(nil)
+		}
+	})
+	if counterErr != nil {
+		return 0, fmt.Errorf("loading tokenizer: %w", counterErr)
+	}
+	if text == "" {
+		return 0, nil
+	}
+
+	counterMu.Lock()
+	defer counterMu.Unlock()
+	enc, err := counter.EncodeSingle(text, false)
+	if err != nil {
+		return 0, fmt.Errorf("tokenizing: %w", err)
+	}
+	return enc.Len(), nil
+}
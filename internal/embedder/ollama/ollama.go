@@ -116,6 +116,16 @@ func (o *Ollama) GetDimensions() int {
 	return o.dimensions
 }
 
+// Backend returns the backend name.
+func (o *Ollama) Backend() string {
+	return "ollama"
+}
+
+// Model returns the Ollama model name.
+func (o *Ollama) Model() string {
+	return o.model
+}
+
 // Warmup pre-loads the model by running a test embedding.
 func (o *Ollama) Warmup() error {
 	_, err := o.Embed("warmup")
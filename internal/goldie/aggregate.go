@@ -0,0 +1,73 @@
+package goldie
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// Aggregates select how recall scores a memory from its matching chunks.
+const (
+	AggregateMax  = "max"  // the best chunk alone (default)
+	AggregateMean = "mean" // the mean of the best ChunksPerMemory chunks
+	AggregateSum  = "sum"  // the sum of the best ChunksPerMemory chunks
+)
+
+const (
+	// DefaultChunksPerMemory is how many chunks mean and sum aggregate when
+	// RecallOptions.ChunksPerMemory is unset.
+	DefaultChunksPerMemory = 3
+	// MaxChunksPerMemory caps RecallOptions.ChunksPerMemory.
+	MaxChunksPerMemory = 10
+)
+
+// ValidateAggregate returns an error if how is not a recognized aggregate.
+// The empty string is accepted and means the default (max).
+func ValidateAggregate(how string) error {
+	switch how {
+	case "", AggregateMax, AggregateMean, AggregateSum:
+		return nil
+	}
+	return fmt.Errorf("invalid aggregate %q (allowed: %s, %s, %s)", how, AggregateMax, AggregateMean, AggregateSum)
+}
+
+func validateChunksPerMemory(n int) error {
+	if n < 0 || n > MaxChunksPerMemory {
+		return fmt.Errorf("chunks per memory must be between 0 and %d, got %d", MaxChunksPerMemory, n)
+	}
+	return nil
+}
+
+// aggregateScores rescores vector results from up to k of their matching
+// chunks, best first, and re-ranks them. Distance stays that of the best
+// chunk.
+func aggregateScores(results []store.MemorySearchResult, k int, how string) []store.MemorySearchResult {
+	if how == "" || how == AggregateMax {
+		return results
+	}
+	for i := range results {
+		chunks := results[i].Chunks[:min(k, len(results[i].Chunks))]
+		var total float32
+		for _, c := range chunks {
+			total += c.Score
+		}
+		if how == AggregateMean {
+			total /= float32(len(chunks))
+		}
+		results[i].Score = total
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// limitChunks trims each result's matching chunks to the best k.
+func limitChunks(results []store.MemorySearchResult, k int) {
+	for i := range results {
+		if len(results[i].Chunks) > k {
+			results[i].Chunks = results[i].Chunks[:k]
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package goldie
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+const (
+	// DefaultBootstrapMaxChars is the default Bootstrap budget, about 2,000
+	// tokens.
+	DefaultBootstrapMaxChars = 8000
+	// DefaultBootstrapRecent is how many recent memories Bootstrap adds
+	// after the pinned ones.
+	DefaultBootstrapRecent = 10
+)
+
+// BootstrapOptions tunes a Bootstrap call. Zero values pick the defaults.
+type BootstrapOptions struct {
+	// Agent limits the memories to those written by this agent or by no
+	// agent in particular. Empty means every agent.
+	Agent  string
+	Budget Budget // for the rendered text (default: DefaultBootstrapMaxChars characters)
+	Recent int    // recent memories to add after the pinned ones; negative for none
+}
+
+// BootstrapResult is what an agent should know at the start of a session.
+type BootstrapResult struct {
+	Pinned  []store.Memory
+	Recent  []store.Memory
+	Omitted []store.Memory // memories that didn't fit the budget
+	Text    string         // Pinned and Recent rendered as Markdown
+	Size    int            // of Text, in the budget's unit
+}
+
+// Bootstrap collects the memories to load at the start of a session: every
+// pinned memory in the instance namespace, by type (user first) and name,
+// then up to Recent of the most recently updated others, as many as fit the
+// budget. A memory that doesn't fit is skipped, so smaller ones after it can
+// still be included; pinned memories are never displaced by recent ones.
+func (g *Goldie) Bootstrap(opts BootstrapOptions) (*BootstrapResult, error) {
+	if err := opts.Budget.validate(); err != nil {
+		return nil, err
+	}
+	budget := opts.Budget
+	if budget.IsZero() {
+		budget.MaxChars = DefaultBootstrapMaxChars
+	}
+	recent := opts.Recent
+	if recent == 0 {
+		recent = DefaultBootstrapRecent
+	}
+
+	ofAgent := func(m store.Memory) bool {
+		return opts.Agent == "" || m.Agent == "" || m.Agent == opts.Agent
+	}
+	pinned, err := g.store.ListMemories(g.scope(store.MemoryFilter{Pinned: true}), 0)
+	if err != nil {
+		return nil, err
+	}
+	pinned = slices.DeleteFunc(pinned, func(m store.Memory) bool { return !ofAgent(m) })
+	sort.SliceStable(pinned, func(i, j int) bool {
+		ti, tj := slices.Index(MemoryTypes, pinned[i].Type), slices.Index(MemoryTypes, pinned[j].Type)
+		if ti != tj {
+			return ti < tj
+		}
+		return pinned[i].Name < pinned[j].Name
+	})
+
+	res := &BootstrapResult{}
+	var pinnedText, recentText strings.Builder
+	fit := func(b *strings.Builder, heading string, m store.Memory) (bool, error) {
+		section := formatBootstrapMemory(m)
+		if b.Len() == 0 {
+			section = heading + section
+		}
+		size, err := budget.size(section)
+		if err != nil {
+			return false, err
+		}
+		if res.Size+size > budget.Limit() {
+			res.Omitted = append(res.Omitted, m)
+			return false, nil
+		}
+		b.WriteString(section)
+		res.Size += size
+		return true, nil
+	}
+	for _, m := range pinned {
+		ok, err := fit(&pinnedText, "# Pinned memories\n\n", m)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res.Pinned = append(res.Pinned, m)
+		}
+	}
+
+	if recent > 0 {
+		others, err := g.store.ListMemories(g.scope(store.MemoryFilter{}), 0)
+		if err != nil {
+			return nil, err
+		}
+		considered := 0
+		for _, m := range others {
+			if considered == recent {
+				break
+			}
+			if m.Pinned || !ofAgent(m) {
+				continue
+			}
+			considered++
+			ok, err := fit(&recentText, "# Recent memories\n\n", m)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				res.Recent = append(res.Recent, m)
+			}
+		}
+	}
+	res.Text = strings.TrimRight(pinnedText.String()+recentText.String(), "\n")
+	return res, nil
+}
+
+// formatBootstrapMemory renders one memory as a Markdown section.
+func formatBootstrapMemory(m store.Memory) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s)\n\n", m.Name, m.Type)
+	if m.Description != "" {
+		b.WriteString(m.Description + "\n\n")
+	}
+	b.WriteString(strings.TrimSpace(m.Body) + "\n\n")
+	return b.String()
+}
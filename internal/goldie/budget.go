@@ -0,0 +1,93 @@
+package goldie
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/srfrog/goldie-mcp/internal/embedder"
+)
+
+// Budget caps the size of text handed to an agent, in tokens or characters.
+// Tokens are counted with the MiniLM tokenizer whatever the embedding
+// backend; MaxTokens takes precedence when both are set.
+type Budget struct {
+	MaxChars  int
+	MaxTokens int
+}
+
+// IsZero reports whether the budget sets no cap.
+func (b Budget) IsZero() bool {
+	return b.MaxChars == 0 && b.MaxTokens == 0
+}
+
+// Unit returns what the budget counts, "tokens" or "chars".
+func (b Budget) Unit() string {
+	if b.MaxTokens > 0 {
+		return "tokens"
+	}
+	return "chars"
+}
+
+// Limit returns the cap in the budget's unit.
+func (b Budget) Limit() int {
+	if b.MaxTokens > 0 {
+		return b.MaxTokens
+	}
+	return b.MaxChars
+}
+
+func (b Budget) validate() error {
+	if b.MaxChars < 0 || b.MaxTokens < 0 {
+		return fmt.Errorf("budget must not be negative")
+	}
+	return nil
+}
+
+// size measures text in the budget's unit.
+func (b Budget) size(text string) (int, error) {
+	if b.MaxTokens > 0 {
+		return embedder.CountTokens(text)
+	}
+	return utf8.RuneCountInString(text), nil
+}
+
+// truncate returns the longest prefix of text, cut after a whole word, that
+// measures at most n.
+func (b Budget) truncate(text string, n int) (string, error) {
+	var cuts []int // byte offsets just past each word
+	inWord := false
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if inWord {
+				cuts = append(cuts, i)
+			}
+			inWord = false
+		} else {
+			inWord = true
+		}
+	}
+	if inWord {
+		cuts = append(cuts, len(text))
+	}
+
+	// Binary search for the most words that fit.
+	lo, hi := 0, len(cuts)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		size, err := b.size(text[:cuts[mid-1]])
+		if err != nil {
+			return "", err
+		}
+		if size <= n {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		return "", nil
+	}
+	return strings.TrimSpace(text[:cuts[lo-1]]), nil
+}
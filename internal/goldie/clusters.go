@@ -0,0 +1,199 @@
+package goldie
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// DuplicateCluster is a group of memories joined by near-duplicate pairs.
+// Memories are most recently updated first; the first is the suggested one
+// to keep.
+type DuplicateCluster struct {
+	Memories []store.Memory
+	Pairs    []DuplicatePair
+	Score    float32 // mean similarity of the pairs
+}
+
+// DuplicatePair is two memories, by id, at least the threshold similar.
+type DuplicatePair struct {
+	A, B       string
+	Similarity float32
+}
+
+// DuplicateScan tunes a FindDuplicateClusters call. Zero values pick the
+// defaults.
+type DuplicateScan struct {
+	Threshold float32            // pair similarity to cluster at (default: the duplicate threshold)
+	Filter    store.MemoryFilter // which memories to scan
+	// Progress, if set, is called after each memory is compared.
+	Progress func(done, total int)
+}
+
+// FindDuplicateClusters compares every memory matching the filter in the
+// instance namespace with its nearest neighbours in the vector index and
+// groups near-duplicates into clusters, highest scoring first. The pair
+// similarity is the mean of contentSimilarity both ways, so a memory
+// contained in a longer one scores below an exact copy. Also returns how
+// many memories were scanned.
+func (g *Goldie) FindDuplicateClusters(opts DuplicateScan) ([]DuplicateCluster, int, error) {
+	if err := g.writableNamespace(); err != nil {
+		return nil, 0, err
+	}
+	threshold := opts.Threshold
+	if threshold == 0 {
+		threshold = g.duplicateThreshold
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, 0, fmt.Errorf("threshold must be between 0 and 1, got %v", threshold)
+	}
+	filter := g.scope(opts.Filter)
+	filter.Trashed = false
+	memories, err := g.store.ListMemories(filter, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[string]store.Memory, len(memories))
+	embeddings := make(map[string][][]float32, len(memories))
+	for _, m := range memories {
+		byID[m.ID] = m
+		if embeddings[m.ID], err = g.chunkEmbeddings(m.ID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	parent := make(map[string]string, len(memories))
+	var find func(id string) string
+	find = func(id string) string {
+		if p, ok := parent[id]; ok && p != id {
+			parent[id] = find(p)
+			return parent[id]
+		}
+		return id
+	}
+
+	var pairs []DuplicatePair
+	compared := make(map[[2]string]bool)
+	for i, m := range memories {
+		for _, emb := range embeddings[m.ID] {
+			results, err := g.store.SearchMemories(emb, duplicateProbe, filter)
+			if err != nil {
+				return nil, 0, fmt.Errorf("probing for duplicates: %w", err)
+			}
+			for _, r := range results {
+				other := r.Memory.ID
+				key := [2]string{min(m.ID, other), max(m.ID, other)}
+				if other == m.ID || compared[key] {
+					continue
+				}
+				compared[key] = true
+				ea, eb := embeddings[key[0]], embeddings[key[1]]
+				sim := (contentSimilarity(ea, eb) + contentSimilarity(eb, ea)) / 2
+				if sim < threshold {
+					continue
+				}
+				pairs = append(pairs, DuplicatePair{A: key[0], B: key[1], Similarity: sim})
+				if ra, rb := find(key[0]), find(key[1]); ra != rb {
+					parent[ra] = rb
+				}
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(memories))
+		}
+	}
+
+	groups := make(map[string]*DuplicateCluster)
+	for _, p := range pairs {
+		root := find(p.A)
+		c := groups[root]
+		if c == nil {
+			c = &DuplicateCluster{}
+			groups[root] = c
+		}
+		c.Pairs = append(c.Pairs, p)
+	}
+	clusters := make([]DuplicateCluster, 0, len(groups))
+	for _, c := range groups {
+		seen := make(map[string]bool)
+		var total float32
+		for _, p := range c.Pairs {
+			total += p.Similarity
+			for _, id := range []string{p.A, p.B} {
+				if !seen[id] {
+					seen[id] = true
+					c.Memories = append(c.Memories, byID[id])
+				}
+			}
+		}
+		c.Score = total / float32(len(c.Pairs))
+		sort.Slice(c.Memories, func(i, j int) bool {
+			return c.Memories[i].UpdatedAt.After(c.Memories[j].UpdatedAt)
+		})
+		sort.Slice(c.Pairs, func(i, j int) bool { return c.Pairs[i].Similarity > c.Pairs[j].Similarity })
+		clusters = append(clusters, *c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Score != clusters[j].Score {
+			return clusters[i].Score > clusters[j].Score
+		}
+		return clusters[i].Memories[0].Name < clusters[j].Memories[0].Name
+	})
+	return clusters, len(memories), nil
+}
+
+// Resolutions for a duplicate cluster.
+const (
+	ResolveMerge  = "merge"  // merge the others into the kept memory
+	ResolveForget = "forget" // move the others to the trash
+)
+
+// ResolveDuplicates resolves one cluster, given as memory ids with the one
+// to keep first. Memories forgotten or merged since the cluster was found
+// are skipped; it is an error if fewer than two remain. Returns the kept
+// memory and the others that were merged into it or forgotten.
+func (g *Goldie) ResolveDuplicates(ids []string, action string, deleteOriginals bool) (*store.Memory, []store.Memory, error) {
+	if action != ResolveMerge && action != ResolveForget {
+		return nil, nil, fmt.Errorf("invalid action %q (allowed: %s, %s)", action, ResolveMerge, ResolveForget)
+	}
+	var live []store.Memory
+	for _, id := range ids {
+		m, err := g.findMemory(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if m != nil {
+			live = append(live, *m)
+		}
+	}
+	if len(live) < 2 {
+		return nil, nil, fmt.Errorf("cluster already resolved: fewer than two of its memories remain")
+	}
+	keep, others := live[0], live[1:]
+
+	if action == ResolveMerge {
+		sources := make([]string, len(others))
+		for i, m := range others {
+			sources[i] = m.ID
+		}
+		merged, err := g.MergeMemories(MergeInput{Target: keep.ID, Sources: sources, DeleteSources: deleteOriginals})
+		if err != nil {
+			return nil, nil, err
+		}
+		return merged, others, nil
+	}
+
+	var forgotten []store.Memory
+	for _, m := range others {
+		ok, err := g.store.TrashMemory(m.ID)
+		if err != nil {
+			return &keep, forgotten, fmt.Errorf("forgetting %s: %w", m.ID, err)
+		}
+		if ok {
+			forgotten = append(forgotten, m)
+		}
+	}
+	return &keep, forgotten, nil
+}
@@ -0,0 +1,74 @@
+package goldie
+
+import (
+	"github.com/srfrog/goldie-mcp/internal/embedder"
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// diversify picks up to limit of the ranked results by maximal marginal
+// relevance: each pick maximizes
+//
+//	(1 - diversity) * relevance - diversity * max similarity to earlier picks
+//
+// where relevance is the result's score scaled to [0, 1] across the
+// candidates and similarity is the cosine similarity of the matched chunks.
+// Scores are left as ranked; only the order and the cut change.
+func (g *Goldie) diversify(results []store.MemorySearchResult, limit int, diversity float32) ([]store.MemorySearchResult, error) {
+	if len(results) <= 1 {
+		return results, nil
+	}
+	embeddings := make([][]float32, len(results))
+	for i, r := range results {
+		emb, err := g.excerptEmbedding(r)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = emb
+	}
+
+	relevance := normalizedScores(results)
+
+	// redundancy[i] is candidate i's highest similarity to a picked result.
+	redundancy := make([]float32, len(results))
+	picked := make([]bool, len(results))
+	out := make([]store.MemorySearchResult, 0, min(limit, len(results)))
+	for len(out) < cap(out) {
+		best, bestScore := -1, float32(0)
+		for i := range results {
+			if picked[i] {
+				continue
+			}
+			score := (1-diversity)*relevance[i] - diversity*redundancy[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		picked[best] = true
+		out = append(out, results[best])
+		for i := range results {
+			if !picked[i] {
+				redundancy[i] = max(redundancy[i], embedder.CosineSimilarity(embeddings[i], embeddings[best]))
+			}
+		}
+	}
+	return out, nil
+}
+
+// excerptEmbedding returns the vector of a result's matched chunk. Keyword
+// matches don't carry one, so it is looked up by the excerpt, falling back
+// to the memory's first chunk.
+func (g *Goldie) excerptEmbedding(r store.MemorySearchResult) ([]float32, error) {
+	if r.Embedding != nil {
+		return r.Embedding, nil
+	}
+	chunks, err := g.store.GetMemoryChunks(r.Memory.ID, true)
+	if err != nil || len(chunks) == 0 {
+		return nil, err
+	}
+	for _, c := range chunks {
+		if c.Content == r.Excerpt {
+			return c.Embedding, nil
+		}
+	}
+	return chunks[0].Embedding, nil
+}
@@ -0,0 +1,133 @@
+package goldie
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/embedder"
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// DefaultDuplicateThreshold is the content similarity, from 0 to 1, at
+// which RememberChecked treats an existing memory as a near-duplicate.
+const DefaultDuplicateThreshold = 0.92
+
+// Duplicate policies tell RememberChecked what to do when the new memory
+// is a near-duplicate of an existing one.
+const (
+	DuplicateError  = "error"  // refuse with ErrDuplicateMemory
+	DuplicateWarn   = "warn"   // create it and report the candidates
+	DuplicateMerge  = "merge"  // append it to the closest match instead
+	DuplicateIgnore = "ignore" // create it without probing
+)
+
+// ValidateDuplicatePolicy returns an error if policy is not recognized.
+func ValidateDuplicatePolicy(policy string) error {
+	switch policy {
+	case DuplicateError, DuplicateWarn, DuplicateMerge, DuplicateIgnore:
+		return nil
+	}
+	return fmt.Errorf("invalid duplicate policy %q (allowed: %s, %s, %s, %s)",
+		policy, DuplicateError, DuplicateWarn, DuplicateMerge, DuplicateIgnore)
+}
+
+// ErrDuplicateMemory is returned by RememberChecked under DuplicateError
+// when a near-duplicate exists.
+var ErrDuplicateMemory = errors.New("a similar memory already exists")
+
+// Duplicate is an existing memory similar to a new one.
+type Duplicate struct {
+	Memory     store.Memory
+	Similarity float32
+}
+
+// RememberResult is the outcome of RememberChecked. Memory is the created
+// memory, or with Merged set the existing one the content went into.
+// Duplicates lists near-duplicates, most similar first.
+type RememberResult struct {
+	Memory     *store.Memory
+	Duplicates []Duplicate
+	Merged     bool
+}
+
+// duplicateProbe is how many nearest memories each new chunk is compared
+// against.
+const duplicateProbe = 5
+
+// findDuplicates returns the live memories in the namespace whose content is
+// at least the duplicate threshold similar to the given chunk embeddings.
+// Similarity is the mean, over the new chunks, of each chunk's best cosine
+// similarity with the memory's chunks, so a long memory sharing one
+// paragraph with a short one isn't a duplicate of it.
+func (g *Goldie) findDuplicates(embeddings [][]float32) ([]Duplicate, error) {
+	if len(embeddings) == 0 {
+		return nil, nil
+	}
+	filter := store.MemoryFilter{Namespace: g.namespace}
+	candidates := make(map[string]store.Memory)
+	for _, emb := range embeddings {
+		results, err := g.store.SearchMemories(emb, duplicateProbe, filter)
+		if err != nil {
+			return nil, fmt.Errorf("probing for duplicates: %w", err)
+		}
+		for _, r := range results {
+			candidates[r.Memory.ID] = r.Memory
+		}
+	}
+
+	var dups []Duplicate
+	for id, m := range candidates {
+		stored, err := g.chunkEmbeddings(id)
+		if err != nil {
+			return nil, err
+		}
+		if sim := contentSimilarity(embeddings, stored); sim >= g.duplicateThreshold {
+			dups = append(dups, Duplicate{Memory: m, Similarity: sim})
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].Similarity != dups[j].Similarity {
+			return dups[i].Similarity > dups[j].Similarity
+		}
+		return dups[i].Memory.Name < dups[j].Memory.Name
+	})
+	return dups, nil
+}
+
+// contentSimilarity is how much of a's content b covers: the mean, over a's
+// chunk embeddings, of each one's best cosine similarity with b's.
+func contentSimilarity(a, b [][]float32) float32 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var total float32
+	for _, ea := range a {
+		var best float32
+		for _, eb := range b {
+			best = max(best, embedder.CosineSimilarity(ea, eb))
+		}
+		total += best
+	}
+	return total / float32(len(a))
+}
+
+func (g *Goldie) chunkEmbeddings(id string) ([][]float32, error) {
+	chunks, err := g.store.GetMemoryChunks(id, true)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float32, len(chunks))
+	for i, c := range chunks {
+		out[i] = c.Embedding
+	}
+	return out, nil
+}
+
+// mergeInto appends body to an existing memory and adds tags to it.
+func (g *Goldie) mergeInto(m *store.Memory, body string, tags []string) (*store.Memory, error) {
+	merged := strings.TrimSpace(m.Body) + "\n\n" + strings.TrimSpace(body)
+	tags = append(append([]string{}, m.Tags...), tags...)
+	return g.UpdateMemory(m.ID, UpdateMemoryInput{Body: &merged, Tags: &tags})
+}
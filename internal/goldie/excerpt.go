@@ -0,0 +1,111 @@
+package goldie
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// MaxContextChunks caps RecallOptions.ContextChunks.
+const MaxContextChunks = 5
+
+// expandExcerpts widens each result's excerpt to its best chunk plus n
+// chunks either side, merged so overlapping text appears once, and records
+// the chunk indices it spans.
+func (g *Goldie) expandExcerpts(results []store.MemorySearchResult, n int) error {
+	for i := range results {
+		r := &results[i]
+		chunks, err := g.store.GetMemoryChunks(r.Memory.ID, false)
+		if err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+		best := min(max(r.ChunkIndex, 0), len(chunks)-1)
+		lo, hi := max(best-n, 0), min(best+n, len(chunks)-1)
+		if spans := chunkSpans(r.Memory.Body, chunks); spans != nil {
+			r.Excerpt = r.Memory.Body[spans[lo][0]:spans[hi][1]]
+		} else {
+			contents := make([]string, 0, hi-lo+1)
+			for _, c := range chunks[lo : hi+1] {
+				contents = append(contents, c.Content)
+			}
+			r.Excerpt = mergeOverlapping(contents)
+		}
+		r.ExcerptChunks = make([]int, 0, hi-lo+1)
+		for idx := lo; idx <= hi; idx++ {
+			r.ExcerptChunks = append(r.ExcerptChunks, idx)
+		}
+	}
+	return nil
+}
+
+func validateContextChunks(n int) error {
+	if n < 0 || n > MaxContextChunks {
+		return fmt.Errorf("context chunks must be between 0 and %d, got %d", MaxContextChunks, n)
+	}
+	return nil
+}
+
+// chunkSpans locates each chunk in the body as [start, end) byte offsets.
+// Chunks are trimmed, overlapping slices of the body in order; nil means
+// they don't line up with it.
+func chunkSpans(body string, chunks []store.MemoryChunk) [][2]int {
+	if len(chunks) == 0 {
+		return nil
+	}
+	spans := make([][2]int, len(chunks))
+	from := 0
+	for i, c := range chunks {
+		at := strings.Index(body[from:], c.Content)
+		if at < 0 {
+			return nil
+		}
+		start := from + at
+		spans[i] = [2]int{start, start + len(c.Content)}
+		from = start + 1
+	}
+	return spans
+}
+
+// mergeOverlapping joins consecutive chunks, dropping the longest prefix of
+// each that repeats the end of the one before.
+func mergeOverlapping(chunks []string) string {
+	var b strings.Builder
+	for i, c := range chunks {
+		if i > 0 {
+			prev := chunks[i-1]
+			overlap := 0
+			for k := min(len(prev), len(c)); k > 0; k-- {
+				if strings.HasSuffix(prev, c[:k]) {
+					overlap = k
+					break
+				}
+			}
+			if overlap == 0 {
+				b.WriteString(" ")
+			}
+			c = c[overlap:]
+		}
+		b.WriteString(c)
+	}
+	return b.String()
+}
+
+// excerptWithMarkers returns body[start:end] with a TruncationMarker line on
+// each side where body text was left out, and whether any was.
+func excerptWithMarkers(body string, start, end int) (string, bool) {
+	content := body[start:end]
+	truncated := false
+	if strings.TrimSpace(body[:start]) != "" {
+		content = TruncationMarker + "\n" + content
+		truncated = true
+	}
+	if strings.TrimSpace(body[end:]) != "" {
+		content += "\n" + TruncationMarker
+		truncated = true
+	}
+	return content, truncated
+}
@@ -0,0 +1,143 @@
+package goldie
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// Expiry policies decide what SweepExpired does with a memory past its
+// expires_at. Expired memories are hidden from queries either way.
+const (
+	ExpiryArchive = "archive" // move to the trash, restorable until purged
+	ExpiryDelete  = "delete"  // delete permanently
+	ExpiryKeep    = "keep"    // leave in place, reachable with include_expired
+)
+
+// DefaultExpiryPolicy archives expired memories of every type.
+const DefaultExpiryPolicy = ExpiryArchive
+
+// ValidateExpiryPolicy returns an error if p is not a recognized policy.
+func ValidateExpiryPolicy(p string) error {
+	switch p {
+	case ExpiryArchive, ExpiryDelete, ExpiryKeep:
+		return nil
+	}
+	return fmt.Errorf("invalid expiry policy %q (allowed: %s, %s, %s)", p, ExpiryArchive, ExpiryDelete, ExpiryKeep)
+}
+
+// ParseExpiryPolicy parses a per-type policy list such as
+// "todo=delete,reminder=archive". The type "*" sets the fallback for types
+// not listed.
+func ParseExpiryPolicy(s string) (map[string]string, error) {
+	policy := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		typ, p, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid expiry policy entry %q (want type=policy)", part)
+		}
+		policy[strings.TrimSpace(typ)] = strings.TrimSpace(p)
+	}
+	if err := validateExpiryPolicies(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func validateExpiryPolicies(policy map[string]string) error {
+	for typ, p := range policy {
+		if typ != "*" {
+			if err := ValidateMemoryType(typ); err != nil {
+				return err
+			}
+		}
+		if err := ValidateExpiryPolicy(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpiryPolicyFor returns the policy applied to expired memories of the
+// given type.
+func (g *Goldie) ExpiryPolicyFor(memType string) string {
+	if p, ok := g.expiryPolicy[memType]; ok {
+		return p
+	}
+	if p, ok := g.expiryPolicy["*"]; ok {
+		return p
+	}
+	return DefaultExpiryPolicy
+}
+
+// ParseTime parses a point in time given as an RFC 3339 timestamp, a local
+// date ("2026-01-31", meaning the start of that day), or a duration from now
+// as accepted by ParseDuration ("36h", "7d"). Used for expires_at and due_at.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	d, err := ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (want RFC 3339 time, YYYY-MM-DD date or duration like 7d)", s)
+	}
+	if d <= 0 {
+		return time.Time{}, fmt.Errorf("duration must be positive: %q", s)
+	}
+	return now.Add(d), nil
+}
+
+// SweepResult counts what SweepExpired did, by policy.
+type SweepResult struct {
+	Archived int `json:"archived"`
+	Deleted  int `json:"deleted"`
+}
+
+// SweepExpired applies the per-type expiry policy to every live memory, in
+// every namespace, whose expires_at has passed.
+func (g *Goldie) SweepExpired() (SweepResult, error) {
+	var res SweepResult
+	expired, err := g.store.ListExpiredMemories(time.Now())
+	if err != nil {
+		return res, err
+	}
+	for _, m := range expired {
+		switch g.ExpiryPolicyFor(m.Type) {
+		case ExpiryArchive:
+			ok, err := g.store.TrashMemory(m.ID)
+			if err != nil {
+				return res, fmt.Errorf("archiving %s: %w", m.ID, err)
+			}
+			if ok {
+				res.Archived++
+			}
+		case ExpiryDelete:
+			ok, err := g.store.DeleteMemoryByID(m.ID)
+			if err != nil {
+				return res, fmt.Errorf("deleting %s: %w", m.ID, err)
+			}
+			if ok {
+				res.Deleted++
+			}
+		}
+	}
+	return res, nil
+}
+
+// expiresAt returns the memory's expiry, or the zero time if it has none.
+func expiresAt(m store.Memory) time.Time {
+	if m.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return *m.ExpiresAt
+}
@@ -0,0 +1,147 @@
+package goldie
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// frontmatterDelim opens and closes a YAML frontmatter block.
+const frontmatterDelim = "---"
+
+// frontmatterField is one key/value pair in emission order. A field with a
+// list is written as a flow sequence instead of a scalar.
+type frontmatterField struct {
+	key, value string
+	list       []string
+}
+
+// formatFrontmatter renders fields as a YAML frontmatter block. Empty values
+// are omitted. Only the subset that parseFrontmatter reads back is produced.
+func formatFrontmatter(fields []frontmatterField) string {
+	var b strings.Builder
+	b.WriteString(frontmatterDelim + "\n")
+	for _, f := range fields {
+		if f.list != nil {
+			if len(f.list) == 0 {
+				continue
+			}
+			items := make([]string, len(f.list))
+			for i, item := range f.list {
+				items[i] = yamlScalar(item)
+			}
+			b.WriteString(f.key + ": [" + strings.Join(items, ", ") + "]\n")
+			continue
+		}
+		if f.value == "" {
+			continue
+		}
+		b.WriteString(f.key + ": " + yamlScalar(f.value) + "\n")
+	}
+	b.WriteString(frontmatterDelim + "\n")
+	return b.String()
+}
+
+// yamlScalar quotes s when writing it plain would change its meaning in YAML.
+func yamlScalar(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s, ":#\n\r\t\"'\\") ||
+		strings.ContainsAny(s[:1], "-?[]{}!&*|>%@`,") {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "null", "~":
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// parseFrontmatter splits a Markdown document into its frontmatter and body.
+// It understands the subset agents write in practice: `key: value` lines with
+// plain, single- or double-quoted scalars, one level of nested mappings,
+// whose keys are returned as `parent.child`, and flow (`[a, b]`) or block
+// (`- a`) sequences of scalars, returned comma-joined. A document without
+// frontmatter returns an empty map and the whole text as body.
+func parseFrontmatter(text string) (map[string]string, string, error) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	first, rest, ok := strings.Cut(text, "\n")
+	if !ok || strings.TrimRight(first, " \r") != frontmatterDelim {
+		return map[string]string{}, text, nil
+	}
+
+	fields := make(map[string]string)
+	var parent string
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		trimmed := strings.TrimRight(line, " \r")
+		if trimmed == frontmatterDelim {
+			return fields, rest, nil
+		}
+		if strings.TrimSpace(trimmed) == "" || strings.HasPrefix(strings.TrimSpace(trimmed), "#") {
+			continue
+		}
+
+		nested := strings.HasPrefix(trimmed, " ") || strings.HasPrefix(trimmed, "\t")
+		if item, isItem := strings.CutPrefix(strings.TrimSpace(trimmed), "- "); isItem && parent != "" {
+			value, err := yamlUnquote(strings.TrimSpace(item))
+			if err != nil {
+				return nil, "", fmt.Errorf("frontmatter %s: %w", parent, err)
+			}
+			if prev, ok := fields[parent]; ok && prev != "" {
+				value = prev + "," + value
+			}
+			fields[parent] = value
+			continue
+		}
+		key, raw, ok := strings.Cut(strings.TrimSpace(trimmed), ":")
+		if !ok {
+			return nil, "", fmt.Errorf("frontmatter: expected `key: value`, got %q", line)
+		}
+		key = strings.TrimSpace(key)
+		value, err := yamlUnquote(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, "", fmt.Errorf("frontmatter %s: %w", key, err)
+		}
+
+		switch {
+		case nested && parent != "":
+			fields[parent+"."+key] = value
+		case raw == "" || strings.TrimSpace(raw) == "":
+			parent = key
+		default:
+			parent = ""
+			fields[key] = value
+		}
+	}
+	return nil, "", fmt.Errorf("frontmatter: missing closing %s", frontmatterDelim)
+}
+
+func yamlUnquote(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '[' && s[len(s)-1] == ']' {
+		var items []string
+		for _, item := range strings.Split(s[1:len(s)-1], ",") {
+			item, err := yamlUnquote(strings.TrimSpace(item))
+			if err != nil {
+				return "", err
+			}
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+		return strings.Join(items, ","), nil
+	}
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	// Drop a trailing comment from plain scalars.
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
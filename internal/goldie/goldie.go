@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/srfrog/goldie-mcp/internal/embedder"
 	"github.com/srfrog/goldie-mcp/internal/store"
@@ -30,11 +31,17 @@ const (
 
 // Goldie is the memory-RAG facade.
 type Goldie struct {
-	embedder     embedder.Interface
-	store        *store.Store
-	chunkSize    int
-	chunkOverlap int
-	logger       *log.Logger
+	embedder           embedder.Interface
+	store              *store.Store
+	embedding          store.EmbeddingInfo
+	chunkSize          int
+	chunkOverlap       int
+	namespace          string
+	trashRetention     time.Duration
+	expiryPolicy       map[string]string
+	duplicateThreshold float32
+	minScore           float32
+	logger             *log.Logger
 }
 
 // Config holds Goldie configuration.
@@ -43,11 +50,38 @@ type Config struct {
 	Dimensions   int
 	ChunkSize    int
 	ChunkOverlap int
-	JournalMode  string             // SQLite journal_mode PRAGMA (default: WAL)
-	Embedder     embedder.Interface // optional injection point for tests
-	Logger       *log.Logger
+	JournalMode  string // SQLite journal_mode PRAGMA (default: WAL)
+	Namespace    string // memory namespace (default: store.DefaultNamespace)
+	// TrashRetention is how long forgotten memories stay in the trash before
+	// PurgeTrash deletes them (default: DefaultTrashRetention; negative keeps
+	// them until restored).
+	TrashRetention time.Duration
+	// ExpiryPolicy maps memory types to the policy SweepExpired applies once
+	// they expire; "*" sets the fallback (default: DefaultExpiryPolicy).
+	ExpiryPolicy map[string]string
+	// DuplicateThreshold is the content similarity, from 0 to 1, at which
+	// RememberChecked reports near-duplicates (default:
+	// DefaultDuplicateThreshold).
+	DuplicateThreshold float32
+	// MinScore is the lowest cosine similarity a vector match may have to be
+	// recalled (default: DefaultMinScore for the embedder's backend; negative
+	// turns the cutoff off).
+	MinScore float32
+	Embedder embedder.Interface // optional injection point for tests
+	Logger   *log.Logger
+	// Reembed opens a database whose recorded embedding model differs from
+	// Embedder instead of refusing; the caller must then enqueue a reembed job.
+	Reembed bool
 }
 
+// ErrEmbeddingMismatch is returned by New when the database was embedded with
+// a different backend, model or dimension than the configured embedder.
+var ErrEmbeddingMismatch = errors.New("embedding model mismatch")
+
+// ErrReembedIncomplete is returned by New when a re-embed dropped the vectors
+// and its job is gone before it finished, leaving nothing to recall.
+var ErrReembedIncomplete = errors.New("re-embed incomplete")
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() Config {
 	homeDir, err := os.UserHomeDir()
@@ -73,6 +107,24 @@ func New(cfg Config) (*Goldie, error) {
 	if cfg.ChunkOverlap == 0 {
 		cfg.ChunkOverlap = DefaultChunkOverlap
 	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = store.DefaultNamespace
+	}
+	if cfg.TrashRetention == 0 {
+		cfg.TrashRetention = DefaultTrashRetention
+	}
+	if err := ValidateNamespace(cfg.Namespace); err != nil {
+		return nil, err
+	}
+	if err := validateExpiryPolicies(cfg.ExpiryPolicy); err != nil {
+		return nil, err
+	}
+	if cfg.DuplicateThreshold == 0 {
+		cfg.DuplicateThreshold = DefaultDuplicateThreshold
+	}
+	if cfg.DuplicateThreshold < 0 || cfg.DuplicateThreshold > 1 {
+		return nil, fmt.Errorf("duplicate threshold must be between 0 and 1, got %v", cfg.DuplicateThreshold)
+	}
 
 	emb := cfg.Embedder
 	if emb == nil {
@@ -83,23 +135,106 @@ func New(cfg Config) (*Goldie, error) {
 		}
 	}
 
-	st, err := store.New(cfg.DBPath, cfg.Dimensions, cfg.JournalMode)
+	info := store.EmbeddingInfo{
+		Backend:    emb.Backend(),
+		Model:      emb.Model(),
+		Dimensions: cfg.Dimensions,
+	}
+	st, err := store.New(cfg.DBPath, info, cfg.JournalMode)
 	if err != nil {
 		return nil, fmt.Errorf("creating store: %w", err)
 	}
 
+	if cfg.MinScore == 0 {
+		cfg.MinScore = DefaultMinScore(emb.Backend())
+	}
+	if cfg.MinScore < 0 {
+		cfg.MinScore = -1
+	}
+	if cfg.MinScore > 1 {
+		st.Close()
+		return nil, fmt.Errorf("minimum score must be at most 1, got %v", cfg.MinScore)
+	}
+
 	logger := cfg.Logger
 	if logger == nil {
 		logger = log.New(io.Discard, "", 0)
 	}
+	if !st.FullTextEnabled() {
+		logger.Printf("Warning: %v; hybrid recall falls back to vector-only and keyword recall fails", store.ErrFullTextUnavailable)
+	}
 
-	return &Goldie{
-		embedder:     emb,
-		store:        st,
-		chunkSize:    cfg.ChunkSize,
-		chunkOverlap: cfg.ChunkOverlap,
-		logger:       logger,
-	}, nil
+	g := &Goldie{
+		embedder:           emb,
+		store:              st,
+		embedding:          info,
+		chunkSize:          cfg.ChunkSize,
+		chunkOverlap:       cfg.ChunkOverlap,
+		namespace:          cfg.Namespace,
+		trashRetention:     cfg.TrashRetention,
+		expiryPolicy:       cfg.ExpiryPolicy,
+		duplicateThreshold: cfg.DuplicateThreshold,
+		minScore:           cfg.MinScore,
+		logger:             logger,
+	}
+	if err := g.checkEmbedding(cfg.Reembed); err != nil {
+		st.Close()
+		return nil, err
+	}
+	return g, nil
+}
+
+// checkEmbedding compares the database's embedding fingerprint with the
+// configured embedder. Databases that predate fingerprints adopt the current
+// one when the vector dimension agrees, since that is all we can verify.
+func (g *Goldie) checkEmbedding(reembed bool) error {
+	stored, err := g.store.EmbeddingInfo()
+	if err != nil {
+		return fmt.Errorf("reading embedding fingerprint: %w", err)
+	}
+	if stored == nil {
+		dims, err := g.store.VectorDimensions()
+		if err != nil {
+			return err
+		}
+		if dims == g.embedding.Dimensions {
+			g.logger.Printf("No embedding fingerprint recorded; assuming %s", g.embedding)
+			return g.store.SetEmbeddingInfo(g.embedding)
+		}
+		stored = &store.EmbeddingInfo{Backend: "unknown", Model: "unknown", Dimensions: dims}
+	}
+	if *stored == g.embedding {
+		return g.checkReembedPending(reembed)
+	}
+	if reembed {
+		g.logger.Printf("Embedding model changing from %s to %s; re-embed required", stored, g.embedding)
+		return nil
+	}
+	return fmt.Errorf("%w: database was embedded with %s but the configured embedder is %s; "+
+		"switch back or restart with -reembed to re-embed every memory", ErrEmbeddingMismatch, stored, g.embedding)
+}
+
+// checkReembedPending refuses a database whose vectors were dropped by a
+// re-embed that neither finished nor has a job left to finish it, unless the
+// caller is about to enqueue one.
+func (g *Goldie) checkReembedPending(reembed bool) error {
+	pending, err := g.store.ReembedPending()
+	if err != nil {
+		return err
+	}
+	if !pending || reembed {
+		return nil
+	}
+	active, err := g.store.ActiveJob(store.JobTypeReembed)
+	if err != nil {
+		return err
+	}
+	if active != nil {
+		g.logger.Printf("Re-embed with %s still in progress (job_id: %s); recall is incomplete until it finishes", g.embedding, active.ID)
+		return nil
+	}
+	return fmt.Errorf("%w: the re-embed with %s did not finish and its job is gone, so most memories have no vectors; "+
+		"restart with -reembed to finish it", ErrReembedIncomplete, g.embedding)
 }
 
 // IndexFileResult reports the outcome of an IndexFile call.
@@ -110,11 +245,17 @@ type IndexFileResult struct {
 	Skipped    bool   `json:"skipped"`
 }
 
-// IndexFile imports a file as a memory of type=reference. Memory.name is the
-// absolute file path, so re-indexing the same file updates in place
-// (checksum-gated). This is the only place upsert-by-name is allowed.
-// agent is recorded on the memory for provenance; pass "" to leave unset.
+// IndexFile imports a file as a memory of type=reference in the instance
+// namespace. Memory.name is the absolute file path, so re-indexing the same
+// file into the same namespace updates in place (checksum-gated, and taking
+// a forgotten file out of the trash), while other namespaces keep their own
+// copy. This is the only place upsert-by-name is
+// allowed. agent is recorded on the memory for provenance; pass "" to leave
+// unset.
 func (g *Goldie) IndexFile(path, agent string) (*IndexFileResult, error) {
+	if err := g.writableNamespace(); err != nil {
+		return nil, err
+	}
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("resolving path: %w", err)
@@ -129,10 +270,16 @@ func (g *Goldie) IndexFile(path, agent string) (*IndexFileResult, error) {
 	checksum := hex.EncodeToString(hash[:])
 	body := string(content)
 
-	existing, err := g.store.GetMemoryByName(absPath)
+	existing, err := g.store.GetMemoryByName(g.namespace, absPath)
 	if err != nil {
 		return nil, fmt.Errorf("looking up existing memory: %w", err)
 	}
+	if existing != nil && existing.DeletedAt != nil {
+		g.logger.Printf("IndexFile: %s was forgotten, restoring it from the trash", absPath)
+		if _, err := g.store.UntrashMemory(existing.ID); err != nil {
+			return nil, err
+		}
+	}
 	if existing != nil && existing.Checksum == checksum {
 		g.logger.Printf("IndexFile: %s unchanged, skipping", absPath)
 		return &IndexFileResult{
@@ -142,7 +289,7 @@ func (g *Goldie) IndexFile(path, agent string) (*IndexFileResult, error) {
 		}, nil
 	}
 
-	chunks := g.chunkText(body)
+	chunks := g.chunkBody(body, absPath)
 	embeddings, err := g.embedChunks(absPath, "", chunks)
 	if err != nil {
 		return nil, err
@@ -150,12 +297,13 @@ func (g *Goldie) IndexFile(path, agent string) (*IndexFileResult, error) {
 
 	if existing == nil {
 		m := &store.Memory{
-			Name:     absPath,
-			Type:     FileMemoryType,
-			Body:     body,
-			Source:   absPath,
-			Agent:    agent,
-			Checksum: checksum,
+			Namespace: g.namespace,
+			Name:      absPath,
+			Type:      FileMemoryType,
+			Body:      body,
+			Source:    absPath,
+			Agent:     agent,
+			Checksum:  checksum,
 		}
 		err := g.store.AddMemory(m, chunks, embeddings)
 		if err == nil {
@@ -171,7 +319,7 @@ func (g *Goldie) IndexFile(path, agent string) (*IndexFileResult, error) {
 			return nil, fmt.Errorf("storing file memory: %w", err)
 		}
 		g.logger.Printf("IndexFile: %s lost create race, falling through to update", absPath)
-		existing, err = g.store.GetMemoryByName(absPath)
+		existing, err = g.store.GetMemoryByName(g.namespace, absPath)
 		if err != nil {
 			return nil, fmt.Errorf("re-fetching after create race: %w", err)
 		}
@@ -271,6 +419,63 @@ func (g *Goldie) ScanDirectory(dir string, pattern string, recursive bool) (*Sca
 	return &ScanDirResult{Files: files}, nil
 }
 
+// EmbeddingInfo returns the fingerprint of the configured embedder.
+func (g *Goldie) EmbeddingInfo() store.EmbeddingInfo {
+	return g.embedding
+}
+
+// BeginReembed prepares the database for re-embedding with the configured
+// embedder. When the fingerprint changes, every vector is dropped (and the
+// index recreated at the new dimension), so recall never ranks old and new
+// vectors against each other while the job runs; then the new fingerprint is
+// recorded, so restarts with the new model resume cleanly without dropping
+// the vectors again, while binaries still configured with the old model are
+// refused. Dropping the vectors marks the re-embed pending until
+// FinishReembed, so a job that fails or is deleted can't leave the database
+// silently recalling nothing.
+func (g *Goldie) BeginReembed() error {
+	stored, err := g.store.EmbeddingInfo()
+	if err != nil {
+		return fmt.Errorf("reading embedding fingerprint: %w", err)
+	}
+	if stored == nil || *stored != g.embedding {
+		if err := g.store.SetReembedPending(true); err != nil {
+			return err
+		}
+		g.logger.Printf("BeginReembed: clearing vectors and recreating the index at %d dims", g.embedding.Dimensions)
+		if err := g.store.RecreateVectorIndex(g.embedding.Dimensions); err != nil {
+			return err
+		}
+	}
+	return g.store.SetEmbeddingInfo(g.embedding)
+}
+
+// FinishReembed clears the pending marker once every memory is re-embedded.
+func (g *Goldie) FinishReembed() error {
+	return g.store.SetReembedPending(false)
+}
+
+// ReembedMemory re-chunks and re-embeds a single memory with the current
+// chunking settings and embedder. A memory deleted in the meantime is skipped.
+func (g *Goldie) ReembedMemory(id string) error {
+	m, err := g.store.GetMemory(id)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return nil
+	}
+	chunks := g.chunkBody(m.Body, m.Source)
+	embeddings, err := g.embedChunks(m.Name, m.Description, chunks)
+	if err != nil {
+		return fmt.Errorf("re-embedding %s: %w", m.Name, err)
+	}
+	if err := g.store.ReplaceMemoryChunks(m.ID, chunks, embeddings); err != nil {
+		return fmt.Errorf("replacing chunks for %s: %w", m.Name, err)
+	}
+	return nil
+}
+
 // Store returns the underlying store for direct access (used by the queue).
 func (g *Goldie) Store() *store.Store {
 	return g.store
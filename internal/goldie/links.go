@@ -0,0 +1,225 @@
+package goldie
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// Link types name how one memory relates to another, read as
+// "from <type> to".
+const (
+	LinkRelatesTo   = "relates_to"
+	LinkSupersedes  = "supersedes"
+	LinkContradicts = "contradicts"
+	LinkDerivedFrom = "derived_from"
+)
+
+// LinkTypes is the closed set of allowed link types.
+var LinkTypes = []string{LinkRelatesTo, LinkSupersedes, LinkContradicts, LinkDerivedFrom}
+
+// ValidateLinkType returns an error if t is not a recognized link type.
+func ValidateLinkType(t string) error {
+	if !slices.Contains(LinkTypes, t) {
+		return fmt.Errorf("invalid link type %q (allowed: %s)", t, strings.Join(LinkTypes, ", "))
+	}
+	return nil
+}
+
+// Link directions, relative to the memory a link was reached from.
+const (
+	LinkOut = "out" // the origin links to the memory
+	LinkIn  = "in"  // the memory links to the origin
+)
+
+// LinkedMemory is a memory reached by following a link.
+type LinkedMemory struct {
+	Memory    store.Memory
+	Type      string // the link type
+	Direction string // LinkOut or LinkIn, relative to Via
+	Via       string // id of the memory the link was followed from
+	Depth     int    // hops from the origin
+	// Missing marks a derived_from source that is in the trash or purged;
+	// only Memory.ID is set, and the walk doesn't go through it.
+	Missing bool
+}
+
+// MaxRelatedDepth caps how many hops Related walks.
+const MaxRelatedDepth = 5
+
+// RelatedOptions tunes a Related call. Zero values pick the defaults.
+type RelatedOptions struct {
+	Depth int      // hops to walk (default 1, max MaxRelatedDepth)
+	Types []string // only follow these link types (default: all)
+	Limit int      // maximum memories returned (default: unlimited)
+}
+
+// LinkMemories adds a typed link from one memory to another, both given by
+// id or name within the instance namespace. Reports false if the link
+// already existed.
+func (g *Goldie) LinkMemories(fromRef, toRef, linkType string) (*store.Memory, *store.Memory, bool, error) {
+	if err := ValidateLinkType(linkType); err != nil {
+		return nil, nil, false, err
+	}
+	from, to, err := g.linkEnds(fromRef, toRef)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if from.ID == to.ID {
+		return nil, nil, false, fmt.Errorf("cannot link a memory to itself")
+	}
+	if from.Namespace != to.Namespace {
+		return nil, nil, false, fmt.Errorf("cannot link memories in different namespaces")
+	}
+	created, err := g.store.AddMemoryLink(from.ID, to.ID, linkType)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return from, to, created, nil
+}
+
+// UnlinkMemories removes the links from one memory to another, only those
+// of linkType unless it is empty, and returns how many were removed.
+func (g *Goldie) UnlinkMemories(fromRef, toRef, linkType string) (int, error) {
+	if linkType != "" {
+		if err := ValidateLinkType(linkType); err != nil {
+			return 0, err
+		}
+	}
+	from, to, err := g.linkEnds(fromRef, toRef)
+	if err != nil {
+		return 0, err
+	}
+	return g.store.RemoveMemoryLinks(from.ID, to.ID, linkType)
+}
+
+func (g *Goldie) linkEnds(fromRef, toRef string) (*store.Memory, *store.Memory, error) {
+	var ends [2]*store.Memory
+	for i, ref := range []string{fromRef, toRef} {
+		m, err := g.findMemory(ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		if m == nil {
+			return nil, nil, fmt.Errorf("memory not found: %s", ref)
+		}
+		ends[i] = m
+	}
+	return ends[0], ends[1], nil
+}
+
+// Related walks links in both directions from a memory, given by id or name,
+// breadth first, and returns the memories reached with how they were
+// reached. Forgotten and expired memories are skipped and not walked
+// through, except that the sources a memory was merged from are still
+// listed by id, as Missing.
+func (g *Goldie) Related(idOrName string, opts RelatedOptions) (*store.Memory, []LinkedMemory, error) {
+	origin, err := g.findMemory(idOrName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if origin == nil {
+		return nil, nil, fmt.Errorf("memory not found: %s", idOrName)
+	}
+	for _, t := range opts.Types {
+		if err := ValidateLinkType(t); err != nil {
+			return nil, nil, err
+		}
+	}
+	depth := min(max(opts.Depth, 1), MaxRelatedDepth)
+
+	seen := map[string]bool{origin.ID: true}
+	frontier := []string{origin.ID}
+	var related []LinkedMemory
+	for d := 1; d <= depth && len(frontier) > 0; d++ {
+		hops, err := g.linkedFrom(frontier, opts.Types, seen)
+		if err != nil {
+			return nil, nil, err
+		}
+		frontier = frontier[:0]
+		for _, hop := range hops {
+			hop.Depth = d
+			related = append(related, hop)
+			if !hop.Missing {
+				frontier = append(frontier, hop.Memory.ID)
+			}
+			if opts.Limit > 0 && len(related) == opts.Limit {
+				return origin, related, nil
+			}
+		}
+	}
+	return origin, related, nil
+}
+
+// DirectLinks returns, for each of the given memory ids, the visible
+// memories it links to or is linked from.
+func (g *Goldie) DirectLinks(ids []string) (map[string][]LinkedMemory, error) {
+	out := make(map[string][]LinkedMemory, len(ids))
+	for _, id := range ids {
+		hops, err := g.linkedFrom([]string{id}, nil, map[string]bool{id: true})
+		if err != nil {
+			return nil, err
+		}
+		for i := range hops {
+			hops[i].Depth = 1
+		}
+		out[id] = hops
+	}
+	return out, nil
+}
+
+// linkedFrom follows every link of the given types touching the frontier to
+// a visible memory not yet seen, marking it seen. A derived_from link to a
+// memory that is in the trash or purged yields a Missing hop, so merge
+// provenance stays visible after the sources are gone.
+func (g *Goldie) linkedFrom(frontier, types []string, seen map[string]bool) ([]LinkedMemory, error) {
+	links, err := g.store.ListMemoryLinks(frontier)
+	if err != nil {
+		return nil, err
+	}
+	inFrontier := make(map[string]bool, len(frontier))
+	for _, id := range frontier {
+		inFrontier[id] = true
+	}
+
+	var hops []LinkedMemory
+	now := time.Now()
+	for _, l := range links {
+		if len(types) > 0 && !slices.Contains(types, l.Type) {
+			continue
+		}
+		for _, hop := range []LinkedMemory{
+			{Via: l.FromID, Type: l.Type, Direction: LinkOut, Memory: store.Memory{ID: l.ToID}},
+			{Via: l.ToID, Type: l.Type, Direction: LinkIn, Memory: store.Memory{ID: l.FromID}},
+		} {
+			if !inFrontier[hop.Via] || seen[hop.Memory.ID] {
+				continue
+			}
+			m, err := g.store.GetMemory(hop.Memory.ID)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil && !g.inNamespace(m) {
+				continue
+			}
+			if m == nil || m.DeletedAt != nil {
+				if hop.Type == LinkDerivedFrom && hop.Direction == LinkOut {
+					seen[hop.Memory.ID] = true
+					hop.Missing = true
+					hops = append(hops, hop)
+				}
+				continue
+			}
+			if m.ExpiresAt != nil && !m.ExpiresAt.After(now) {
+				continue
+			}
+			seen[m.ID] = true
+			hop.Memory = *m
+			hops = append(hops, hop)
+		}
+	}
+	return hops, nil
+}
@@ -0,0 +1,408 @@
+package goldie
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// Transfer formats accepted by export and import.
+const (
+	FormatJSONL    = "jsonl"    // one memory per line, see Export
+	FormatMarkdown = "markdown" // a <type>/<name>.md tree, see ExportMarkdown
+)
+
+// markdownIndexFile is the index written at the root of a Markdown export,
+// in the same shape as the MEMORY.md files agents keep per project.
+const markdownIndexFile = "MEMORY.md"
+
+// ValidateTransferFormat returns an error if format is not recognized. The
+// empty string is accepted and means the default (jsonl).
+func ValidateTransferFormat(format string) error {
+	switch format {
+	case "", FormatJSONL, FormatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("invalid format %q (allowed: %s, %s)", format, FormatJSONL, FormatMarkdown)
+}
+
+// ExportMarkdown writes every memory matching the filter in the instance
+// namespace to dir as <type>/<name>.md with YAML frontmatter, plus a
+// MEMORY.md index linking to each file. Existing files for the same memories
+// are overwritten; nothing else in dir is touched. It returns the number of
+// memories written.
+func (g *Goldie) ExportMarkdown(dir string, opts ExportOptions) (int, error) {
+	memories, err := g.ListMemories(opts.Filter, 0)
+	if err != nil {
+		return 0, err
+	}
+	if g.namespace == store.AllNamespaces {
+		return 0, fmt.Errorf("markdown exports hold one namespace; export each namespace to its own directory")
+	}
+	sort.Slice(memories, func(i, j int) bool {
+		if memories[i].Type != memories[j].Type {
+			return memories[i].Type < memories[j].Type
+		}
+		return memories[i].Name < memories[j].Name
+	})
+
+	var index strings.Builder
+	index.WriteString("# Memories\n\n")
+	for i, m := range memories {
+		rel := filepath.Join(m.Type, markdownFileName(m.Name))
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return i, err
+		}
+		if err := os.WriteFile(path, []byte(formatMarkdownMemory(m)), 0o644); err != nil {
+			return i, fmt.Errorf("writing %s: %w", rel, err)
+		}
+
+		fmt.Fprintf(&index, "- [%s](%s)", m.Name, filepath.ToSlash(rel))
+		if m.Description != "" {
+			fmt.Fprintf(&index, " — %s", strings.ReplaceAll(m.Description, "\n", " "))
+		}
+		index.WriteString("\n")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return len(memories), err
+	}
+	if err := os.WriteFile(filepath.Join(dir, markdownIndexFile), []byte(index.String()), 0o644); err != nil {
+		return len(memories), fmt.Errorf("writing %s: %w", markdownIndexFile, err)
+	}
+	return len(memories), nil
+}
+
+func formatMarkdownMemory(m store.Memory) string {
+	fm := formatFrontmatter([]frontmatterField{
+		{"name", m.Name, nil},
+		{"id", m.ID, nil},
+		{"namespace", m.Namespace, nil},
+		{"type", m.Type, nil},
+		{"description", m.Description, nil},
+		{"agent", m.Agent, nil},
+		{"source", m.Source, nil},
+		{key: "tags", list: m.Tags},
+		{"created_at", formatFrontmatterTime(m.CreatedAt), nil},
+		{"updated_at", formatFrontmatterTime(m.UpdatedAt), nil},
+		{"expires_at", formatFrontmatterTime(expiresAt(m)), nil},
+		{"due_at", formatFrontmatterTime(dueAt(m)), nil},
+		{"status", m.Status, nil},
+		{"importance", formatImportance(m.Importance), nil},
+		{"pinned", formatPinned(m.Pinned), nil},
+	})
+	return fm + "\n" + strings.TrimRight(m.Body, "\n") + "\n"
+}
+
+func formatImportance(v float32) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(v), 'g', -1, 32)
+}
+
+func formatPinned(pinned bool) string {
+	if !pinned {
+		return ""
+	}
+	return "true"
+}
+
+func formatFrontmatterTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// markdownFileName maps a memory name to a portable file name. Names that
+// aren't already safe (file memories are named by absolute path) get a hash
+// suffix so distinct names can never collide; the frontmatter keeps the
+// original.
+func markdownFileName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+	safe = strings.TrimLeft(safe, "._")
+	if safe == name && len(safe) <= 100 {
+		return safe + ".md"
+	}
+	if len(safe) > 80 {
+		safe = safe[len(safe)-80:]
+	}
+	sum := sha256.Sum256([]byte(name))
+	return safe + "-" + hex.EncodeToString(sum[:4]) + ".md"
+}
+
+// ImportMarkdown reads a Markdown tree back into the instance namespace (or,
+// when the instance spans all namespaces, each file's frontmatter namespace)
+// through Remember and UpdateMemory. Every *.md file except MEMORY.md is one
+// memory. Fields come from the frontmatter; the name falls back to the file
+// name, the type to `metadata.type` (Claude-style) or the parent directory,
+// and the description to the file's entry in MEMORY.md. Files identical to
+// the stored memory are skipped; other existing names are resolved with
+// opts.OnConflict, comparing `newer` against the frontmatter's updated_at (or
+// the file's modification time).
+func (g *Goldie) ImportMarkdown(dir string, opts ImportOptions) (*ImportResult, error) {
+	if err := ValidateConflictPolicy(opts.OnConflict); err != nil {
+		return nil, err
+	}
+	policy := opts.OnConflict
+	if policy == "" {
+		policy = ConflictSkip
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	hooks, err := readMarkdownIndex(filepath.Join(dir, markdownIndexFile))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ImportResult{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".md") || strings.EqualFold(d.Name(), markdownIndexFile) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if err := g.importMarkdownFile(path, hooks[rel], policy, res); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", rel, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return res, fmt.Errorf("walking %s: %w", dir, err)
+	}
+	return res, nil
+}
+
+func (g *Goldie) importMarkdownFile(path, hook, policy string, res *ImportResult) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fm, body, err := parseFrontmatter(string(data))
+	if err != nil {
+		return err
+	}
+
+	in := RememberInput{
+		Name:        fm["name"],
+		Type:        firstNonEmpty(fm["type"], fm["metadata.type"]),
+		Body:        strings.TrimSpace(body),
+		Description: firstNonEmpty(fm["description"], hook),
+		Agent:       fm["agent"],
+		Source:      fm["source"],
+		Status:      fm["status"],
+	}
+	if fm["tags"] != "" {
+		tags, err := store.NormalizeTags(strings.Split(fm["tags"], ","))
+		if err != nil {
+			return err
+		}
+		in.Tags = tags
+	}
+	if fm["expires_at"] != "" {
+		t, err := time.Parse(time.RFC3339, fm["expires_at"])
+		if err != nil {
+			return fmt.Errorf("parsing expires_at: %w", err)
+		}
+		in.ExpiresAt = t
+	}
+	if fm["due_at"] != "" {
+		t, err := time.Parse(time.RFC3339, fm["due_at"])
+		if err != nil {
+			return fmt.Errorf("parsing due_at: %w", err)
+		}
+		in.DueAt = t
+	}
+	if fm["importance"] != "" {
+		v, err := strconv.ParseFloat(fm["importance"], 32)
+		if err != nil {
+			return fmt.Errorf("parsing importance: %w", err)
+		}
+		in.Importance = float32(v)
+	}
+	if fm["pinned"] != "" {
+		pinned, err := strconv.ParseBool(fm["pinned"])
+		if err != nil {
+			return fmt.Errorf("parsing pinned: %w", err)
+		}
+		in.Pinned = pinned
+	}
+	if in.Name == "" {
+		in.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if in.Type == "" {
+		if dirType := filepath.Base(filepath.Dir(path)); ValidateMemoryType(dirType) == nil {
+			in.Type = dirType
+		}
+	}
+	if in.Type == "" {
+		return fmt.Errorf("no type in frontmatter or parent directory")
+	}
+	if err := ValidateMemoryType(in.Type); err != nil {
+		return err
+	}
+	g, err = g.importTarget(fm["namespace"])
+	if err != nil {
+		return err
+	}
+
+	existing, err := g.store.GetMemoryByName(g.namespace, in.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.DeletedAt != nil {
+		// A forgotten memory yields to the import.
+		if _, err := g.store.UntrashMemory(existing.ID); err != nil {
+			return err
+		}
+		policy = ConflictOverwrite
+	}
+	if existing == nil {
+		if _, err := g.Remember(in); err != nil {
+			return err
+		}
+		res.Imported++
+		res.Reembedded++
+		return nil
+	}
+
+	if existing.Type == in.Type && existing.Description == in.Description && existing.Body == in.Body &&
+		existing.Agent == in.Agent && existing.Source == in.Source && slices.Equal(existing.Tags, in.Tags) &&
+		expiresAt(*existing).Equal(in.ExpiresAt) && dueAt(*existing).Equal(in.DueAt) &&
+		(in.Status == "" || existing.Status == in.Status) && existing.Importance == in.Importance &&
+		existing.Pinned == in.Pinned {
+		res.Skipped++
+		return nil
+	}
+	switch policy {
+	case ConflictSkip:
+		res.Skipped++
+		return nil
+	case ConflictNewer:
+		updated, err := markdownUpdatedAt(path, fm["updated_at"])
+		if err != nil {
+			return err
+		}
+		if !updated.After(existing.UpdatedAt) {
+			res.Skipped++
+			return nil
+		}
+	case ConflictRename:
+		name, err := g.freeName(in.Name)
+		if err != nil {
+			return err
+		}
+		in.Name = name
+		if _, err := g.Remember(in); err != nil {
+			return err
+		}
+		res.Renamed++
+		res.Reembedded++
+		return nil
+	}
+
+	if _, err := g.UpdateMemory(existing.ID, UpdateMemoryInput{
+		Type:        in.Type,
+		Description: &in.Description,
+		Body:        &in.Body,
+		Source:      &in.Source,
+		Agent:       &in.Agent,
+		Tags:        &in.Tags,
+		ExpiresAt:   &in.ExpiresAt,
+		DueAt:       &in.DueAt,
+		Status:      optionalString(in.Status),
+		Importance:  &in.Importance,
+		Pinned:      &in.Pinned,
+	}); err != nil {
+		return err
+	}
+	res.Overwritten++
+	res.Reembedded++
+	return nil
+}
+
+// markdownUpdatedAt returns the frontmatter updated_at, falling back to the
+// file's modification time for hand-written files.
+func markdownUpdatedAt(path, value string) (time.Time, error) {
+	if value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parsing updated_at: %w", err)
+		}
+		return t, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// markdownIndexEntry matches MEMORY.md lines like `- [Title](file.md) — hook`.
+var markdownIndexEntry = regexp.MustCompile(`^\s*[-*+]\s*\[[^\]]*\]\(([^)\s]+)\)\s*(?:[—–:-]+\s*)?(.*)$`)
+
+// readMarkdownIndex maps each file linked from a MEMORY.md index, relative to
+// the index, to its one-line hook. A missing index is not an error.
+func readMarkdownIndex(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	hooks := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		m := markdownIndexEntry.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		hooks[filepath.ToSlash(filepath.Clean(m[1]))] = strings.TrimSpace(m[2])
+	}
+	return hooks, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,201 @@
+package goldie
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// headingRe matches an ATX heading line, capturing its marker and title.
+var headingRe = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+// fenceRe matches the opening line of a fenced code block.
+var fenceRe = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// chunkBody splits a memory body into chunks to embed: along its Markdown
+// structure for .md files and bodies that look like Markdown, and into
+// overlapping character windows otherwise.
+func (g *Goldie) chunkBody(body, source string) []store.ChunkText {
+	if isMarkdownPath(source) || looksLikeMarkdown(body) {
+		if chunks := g.chunkMarkdown(body); len(chunks) > 0 {
+			return chunks
+		}
+	}
+	texts := g.chunkText(body)
+	chunks := make([]store.ChunkText, len(texts))
+	for i, t := range texts {
+		chunks[i] = store.ChunkText{Content: t}
+	}
+	return chunks
+}
+
+func isMarkdownPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// looksLikeMarkdown reports whether text has a heading or a fenced code
+// block.
+func looksLikeMarkdown(text string) bool {
+	for _, b := range markdownBlocks(text) {
+		if b.kind == mdHeading || b.kind == mdFence {
+			return true
+		}
+	}
+	return false
+}
+
+// Markdown block kinds. Fences and tables are never split.
+const (
+	mdParagraph = iota
+	mdHeading
+	mdFence
+	mdTable
+)
+
+// mdBlock is a run of lines of a Markdown text, as [start, end) byte
+// offsets.
+type mdBlock struct {
+	kind       int
+	start, end int
+	level      int    // of a heading
+	title      string // of a heading
+}
+
+// markdownBlocks splits text into headings, fenced code blocks, tables and
+// paragraphs, skipping blank lines. An unclosed fence runs to the end.
+func markdownBlocks(text string) []mdBlock {
+	var blocks []mdBlock
+	var cur *mdBlock // open paragraph, table or fence
+	fence := ""
+	flush := func() {
+		if cur != nil {
+			blocks = append(blocks, *cur)
+			cur = nil
+		}
+	}
+	for start := 0; start < len(text); {
+		end := len(text)
+		next := end
+		if i := strings.IndexByte(text[start:], '\n'); i >= 0 {
+			end, next = start+i, start+i+1
+		}
+		line := strings.TrimRight(text[start:end], "\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case fence != "":
+			cur.end = end
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+				flush()
+			}
+		case fenceRe.MatchString(line):
+			flush()
+			fence = fenceRe.FindStringSubmatch(line)[1]
+			cur = &mdBlock{kind: mdFence, start: start, end: end}
+		case headingRe.MatchString(line):
+			flush()
+			m := headingRe.FindStringSubmatch(line)
+			blocks = append(blocks, mdBlock{kind: mdHeading, start: start, end: end, level: len(m[1]), title: strings.TrimSpace(m[2])})
+		case trimmed == "":
+			flush()
+		default:
+			kind := mdParagraph
+			if strings.HasPrefix(trimmed, "|") {
+				kind = mdTable
+			}
+			if cur != nil && cur.kind != kind {
+				flush()
+			}
+			if cur == nil {
+				cur = &mdBlock{kind: kind, start: start}
+			}
+			cur.end = end
+		}
+		start = next
+	}
+	flush()
+	return blocks
+}
+
+// mdSection is a heading and the blocks up to the next heading, or the
+// blocks before the first one.
+type mdSection struct {
+	path   string
+	blocks []mdBlock
+}
+
+// chunkMarkdown splits text along its headings. A section is chunked with
+// its subsections while they fit the chunk size; a longer one is split
+// between blocks, keeping fenced code blocks and tables whole even when
+// they exceed the chunk size, and cutting oversized paragraphs into
+// windows. Chunks don't overlap, and each records its heading path.
+func (g *Goldie) chunkMarkdown(text string) []store.ChunkText {
+	var sections []mdSection
+	var stack []mdBlock // enclosing headings, outermost first
+	for _, b := range markdownBlocks(text) {
+		if b.kind == mdHeading {
+			for len(stack) > 0 && stack[len(stack)-1].level >= b.level {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, b)
+			titles := make([]string, len(stack))
+			for i, h := range stack {
+				titles[i] = h.title
+			}
+			sections = append(sections, mdSection{path: strings.Join(titles, store.HeadingPathSeparator)})
+		} else if len(sections) == 0 {
+			sections = append(sections, mdSection{})
+		}
+		last := &sections[len(sections)-1]
+		last.blocks = append(last.blocks, b)
+	}
+
+	var chunks []store.ChunkText
+	var cur struct {
+		start, end int
+		path       string
+	}
+	open := false
+	flush := func() {
+		if open {
+			chunks = append(chunks, store.ChunkText{Content: strings.TrimSpace(text[cur.start:cur.end]), HeadingPath: cur.path})
+			open = false
+		}
+	}
+	within := func(path string) bool {
+		return open && cur.path != "" && (path == cur.path || strings.HasPrefix(path, cur.path+store.HeadingPathSeparator))
+	}
+
+	for _, sec := range sections {
+		end := sec.blocks[len(sec.blocks)-1].end
+		if within(sec.path) && end-cur.start <= g.chunkSize {
+			cur.end = end
+			continue
+		}
+		flush()
+		for _, b := range sec.blocks {
+			switch {
+			case open && b.end-cur.start <= g.chunkSize:
+				cur.end = b.end
+			case b.end-b.start <= g.chunkSize || b.kind == mdFence || b.kind == mdTable:
+				flush()
+				cur.start, cur.end, cur.path = b.start, b.end, sec.path
+				open = true
+			default:
+				flush()
+				for _, t := range g.chunkText(text[b.start:b.end]) {
+					chunks = append(chunks, store.ChunkText{Content: t, HeadingPath: sec.path})
+				}
+			}
+		}
+	}
+	flush()
+	return chunks
+}
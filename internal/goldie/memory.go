@@ -1,8 +1,12 @@
 package goldie
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/srfrog/goldie-mcp/internal/store"
 )
@@ -43,21 +47,57 @@ type RememberInput struct {
 	Description string
 	Agent       string
 	Source      string
+	Tags        []string
+	ExpiresAt   time.Time // zero means the memory never expires
+	DueAt       time.Time // todo and reminder only; zero means no due date
+	Status      string    // todo and reminder only (default: open)
+	Importance  float32   // 0 to 1, boosts recall ranking
+	Pinned      bool      // always loaded by Bootstrap
 }
 
 // UpdateMemoryInput patches an existing memory. Nil fields are left unchanged;
-// non-nil empty strings clear optional columns. Name is immutable.
+// non-nil empty strings clear optional columns. Names change through
+// RenameMemory.
 type UpdateMemoryInput struct {
 	Type        string
 	Description *string
 	Body        *string
 	Source      *string
 	Agent       *string
+	Tags        *[]string  // replaces the whole set; an empty slice clears it
+	ExpiresAt   *time.Time // a zero time clears the expiry
+	DueAt       *time.Time // todo and reminder only; a zero time clears it
+	Status      *string    // todo and reminder only
+	Importance  *float32   // 0 to 1
+	Pinned      *bool
 }
 
-// Remember creates a new memory. Returns store.ErrMemoryNameExists if the
-// name is already taken — callers should recall + UpdateMemory in that case.
+// Remember creates a new memory in the instance namespace. Returns
+// store.ErrMemoryNameExists if the name is already taken there — callers
+// should recall + UpdateMemory in that case — or ErrMemoryInTrash if it is
+// taken by a forgotten memory. It doesn't probe for near-duplicates; see
+// RememberChecked.
 func (g *Goldie) Remember(in RememberInput) (*store.Memory, error) {
+	res, err := g.RememberChecked(in, DuplicateIgnore)
+	if err != nil {
+		return nil, err
+	}
+	return res.Memory, nil
+}
+
+// RememberChecked is Remember with a near-duplicate probe: memories in the
+// namespace whose content is at least the duplicate threshold similar to the
+// new one are handled according to onDuplicate (default DuplicateWarn).
+func (g *Goldie) RememberChecked(in RememberInput, onDuplicate string) (*RememberResult, error) {
+	if onDuplicate == "" {
+		onDuplicate = DuplicateWarn
+	}
+	if err := ValidateDuplicatePolicy(onDuplicate); err != nil {
+		return nil, err
+	}
+	if err := g.writableNamespace(); err != nil {
+		return nil, err
+	}
 	if in.Name == "" {
 		return nil, fmt.Errorf("name is required")
 	}
@@ -67,25 +107,80 @@ func (g *Goldie) Remember(in RememberInput) (*store.Memory, error) {
 	if err := ValidateMemoryType(in.Type); err != nil {
 		return nil, err
 	}
+	tags, err := store.NormalizeTags(in.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateImportance(in.Importance); err != nil {
+		return nil, err
+	}
+	if IsTaskType(in.Type) {
+		if in.Status == "" {
+			in.Status = store.MemoryStatusOpen
+		}
+		if err := ValidateMemoryStatus(in.Status); err != nil {
+			return nil, err
+		}
+	} else if !in.DueAt.IsZero() || in.Status != "" {
+		return nil, errNotTask(in.Type)
+	}
 
-	chunks := g.chunkText(in.Body)
+	chunks := g.chunkBody(in.Body, in.Source)
 	embeddings, err := g.embedChunks(in.Name, in.Description, chunks)
 	if err != nil {
 		return nil, err
 	}
 
+	res := &RememberResult{}
+	if onDuplicate != DuplicateIgnore {
+		if res.Duplicates, err = g.findDuplicates(embeddings); err != nil {
+			return nil, err
+		}
+	}
+	if len(res.Duplicates) > 0 {
+		switch best := res.Duplicates[0]; onDuplicate {
+		case DuplicateError:
+			return res, fmt.Errorf("%w: %q (similarity %.2f)", ErrDuplicateMemory, best.Memory.Name, best.Similarity)
+		case DuplicateMerge:
+			if res.Memory, err = g.mergeInto(&best.Memory, in.Body, tags); err != nil {
+				return nil, err
+			}
+			res.Merged = true
+			return res, nil
+		}
+	}
+
 	m := &store.Memory{
+		Namespace:   g.namespace,
 		Name:        in.Name,
 		Type:        in.Type,
 		Description: in.Description,
 		Body:        in.Body,
 		Agent:       in.Agent,
 		Source:      in.Source,
+		Tags:        tags,
+		Status:      in.Status,
+		Importance:  in.Importance,
+		Pinned:      in.Pinned,
+	}
+	if !in.ExpiresAt.IsZero() {
+		m.ExpiresAt = &in.ExpiresAt
+	}
+	if !in.DueAt.IsZero() {
+		m.DueAt = &in.DueAt
 	}
 	if err := g.store.AddMemory(m, chunks, embeddings); err != nil {
+		if errors.Is(err, store.ErrMemoryNameExists) {
+			if taken, _ := g.store.GetMemoryByName(g.namespace, in.Name); taken != nil && taken.DeletedAt != nil {
+				return nil, ErrMemoryInTrash
+			}
+		}
 		return nil, err
 	}
-	return g.store.GetMemoryByName(in.Name)
+	if res.Memory, err = g.store.GetMemory(m.ID); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 // UpdateMemory patches an existing memory by id or name. When body or
@@ -104,6 +199,19 @@ func (g *Goldie) UpdateMemory(idOrName string, in UpdateMemoryInput) (*store.Mem
 			return nil, err
 		}
 	}
+	if in.Tags != nil {
+		if _, err := store.NormalizeTags(*in.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if in.Importance != nil {
+		if err := ValidateImportance(*in.Importance); err != nil {
+			return nil, err
+		}
+	}
+	if err := taskPatch(existing, &in); err != nil {
+		return nil, err
+	}
 
 	patch := store.MemoryUpdate{
 		Type:        in.Type,
@@ -111,6 +219,12 @@ func (g *Goldie) UpdateMemory(idOrName string, in UpdateMemoryInput) (*store.Mem
 		Body:        in.Body,
 		Source:      in.Source,
 		Agent:       in.Agent,
+		Tags:        in.Tags,
+		ExpiresAt:   in.ExpiresAt,
+		DueAt:       in.DueAt,
+		Status:      in.Status,
+		Importance:  in.Importance,
+		Pinned:      in.Pinned,
 	}
 	if err := g.store.UpdateMemoryFields(existing.ID, patch); err != nil {
 		return nil, fmt.Errorf("updating memory: %w", err)
@@ -121,7 +235,7 @@ func (g *Goldie) UpdateMemory(idOrName string, in UpdateMemoryInput) (*store.Mem
 		if err != nil {
 			return nil, err
 		}
-		chunks := g.chunkText(updated.Body)
+		chunks := g.chunkBody(updated.Body, updated.Source)
 		embeddings, err := g.embedChunks(updated.Name, updated.Description, chunks)
 		if err != nil {
 			return nil, err
@@ -134,78 +248,394 @@ func (g *Goldie) UpdateMemory(idOrName string, in UpdateMemoryInput) (*store.Mem
 	return g.store.GetMemory(existing.ID)
 }
 
-// RecallMemory runs semantic search over memories, optionally filtered.
+// Recall modes select how RecallMemory ranks candidates.
+const (
+	RecallModeVector  = "vector"  // KNN over chunk embeddings only
+	RecallModeKeyword = "keyword" // BM25 over name/description/body only
+	RecallModeHybrid  = "hybrid"  // reciprocal rank fusion of both
+)
+
+// rrfK is the reciprocal rank fusion damping constant. 60 is the value from
+// the original RRF paper and works well without tuning.
+const rrfK = 60
+
+// ValidateRecallMode returns an error if mode is not a recognized recall mode.
+// The empty string is accepted and means the default (hybrid).
+func ValidateRecallMode(mode string) error {
+	switch mode {
+	case "", RecallModeVector, RecallModeKeyword, RecallModeHybrid:
+		return nil
+	}
+	return fmt.Errorf("invalid recall mode %q (allowed: %s, %s, %s)", mode, RecallModeVector, RecallModeKeyword, RecallModeHybrid)
+}
+
+// defaultMinScores are the cosine similarities below which vector matches
+// are noise for each backend's default model. Models spread unrelated texts
+// differently: MiniLM puts them near 0, nomic-embed-text around 0.4.
+var defaultMinScores = map[string]float32{
+	"minilm": 0.2,
+	"ollama": 0.45,
+}
+
+// DefaultMinScore returns the minimum vector similarity recall uses for an
+// embedding backend, or -1 (no cutoff) for backends without a calibrated
+// default.
+func DefaultMinScore(backend string) float32 {
+	if v, ok := defaultMinScores[backend]; ok {
+		return v
+	}
+	return -1
+}
+
+// MinScore returns the instance's minimum vector similarity for recall; -1
+// means no cutoff.
+func (g *Goldie) MinScore() float32 {
+	return g.minScore
+}
+
+// RecallOptions tunes a Recall call. Zero values pick the defaults.
+type RecallOptions struct {
+	Limit  int
+	Filter store.MemoryFilter
+	Mode   string // vector, keyword, or hybrid (default)
+	// MinScore drops vector matches with a lower cosine similarity
+	// (default: the instance's MinScore). Keyword matches are kept.
+	MinScore *float32
+	// Diversity re-ranks results with maximal marginal relevance, from 0
+	// (off, by relevance alone) to 1 (by dissimilarity alone), so near-copies
+	// don't crowd each other out.
+	Diversity float32
+	// Boost, if set, re-ranks results by relevance plus boosts for recency,
+	// recall frequency and importance.
+	Boost *BoostOptions
+	// ContextChunks widens each excerpt to the best matching chunk plus this
+	// many chunks before and after it, up to MaxContextChunks.
+	ContextChunks int
+	// ChunksPerMemory keeps up to this many matching chunks per result, best
+	// first, up to MaxChunksPerMemory (default: every match, or
+	// DefaultChunksPerMemory when aggregating).
+	ChunksPerMemory int
+	// Aggregate scores each vector match from its best ChunksPerMemory
+	// chunks: max (default), mean or sum. Sum favours memories, such as
+	// indexed files, with several relevant sections.
+	Aggregate string
+}
+
+// RecallMemory runs hybrid search over memories, optionally filtered.
 func (g *Goldie) RecallMemory(query string, limit int, filter store.MemoryFilter) ([]store.MemorySearchResult, error) {
+	return g.Recall(query, RecallOptions{Limit: limit, Filter: filter})
+}
+
+// Recall searches memories using the configured mode, within the instance
+// namespace unless the filter names another (or store.AllNamespaces). Hybrid
+// mode fuses the vector and BM25 rankings with reciprocal rank fusion, so
+// exact identifiers that embed poorly still surface; it degrades to
+// vector-only when the store has no full-text index. In hybrid mode Score is
+// the fused RRF score. Vector matches below the minimum score are dropped
+// before fusion, so a memory found only by weak similarity isn't recalled.
+// With a mean or sum aggregate, a vector match's score pools its best
+// chunks. With boosts on, Score is the relevance scaled to [0, 1] plus the
+// boost.
+// Each recalled memory's recall count and time are recorded.
+func (g *Goldie) Recall(query string, opts RecallOptions) ([]store.MemorySearchResult, error) {
+	results, err := g.recall(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	memories := make([]*store.Memory, len(results))
+	for i := range results {
+		memories[i] = &results[i].Memory
+	}
+	g.markRecalled(memories)
+	return results, nil
+}
+
+// markRecalled records a recall of each memory, in the store and on the
+// given copies.
+func (g *Goldie) markRecalled(memories []*store.Memory) {
+	if len(memories) == 0 {
+		return
+	}
+	ids := make([]string, len(memories))
+	for i, m := range memories {
+		ids[i] = m.ID
+	}
+	if err := g.store.MarkRecalled(ids); err != nil {
+		// Stats are best effort; the recall itself succeeded.
+		g.logger.Printf("Recording recall: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, m := range memories {
+		m.RecallCount++
+		m.LastRecalledAt = &now
+	}
+}
+
+// recall is Recall without recording recall stats.
+func (g *Goldie) recall(query string, opts RecallOptions) ([]store.MemorySearchResult, error) {
 	if query == "" {
 		return nil, fmt.Errorf("empty query")
 	}
+	if err := ValidateRecallMode(opts.Mode); err != nil {
+		return nil, err
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 5
+	}
+	if opts.MinScore != nil && (*opts.MinScore < -1 || *opts.MinScore > 1) {
+		return nil, fmt.Errorf("min_score must be between -1 and 1, got %v", *opts.MinScore)
+	}
+	if opts.Diversity < 0 || opts.Diversity > 1 {
+		return nil, fmt.Errorf("diversity must be between 0 and 1, got %v", opts.Diversity)
+	}
+	if opts.Boost != nil {
+		if err := opts.Boost.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateContextChunks(opts.ContextChunks); err != nil {
+		return nil, err
+	}
+	if err := validateChunksPerMemory(opts.ChunksPerMemory); err != nil {
+		return nil, err
+	}
+	if err := ValidateAggregate(opts.Aggregate); err != nil {
+		return nil, err
+	}
+	aggregating := opts.Aggregate != "" && opts.Aggregate != AggregateMax
+	if aggregating && opts.ChunksPerMemory == 0 {
+		opts.ChunksPerMemory = DefaultChunksPerMemory
+	}
+	opts.Filter = g.scope(opts.Filter)
+	minScore := g.minScore
+	if opts.MinScore != nil {
+		minScore = *opts.MinScore
+	}
+	mode := opts.Mode
+	if mode == "" {
+		mode = RecallModeHybrid
+	}
+	if mode == RecallModeHybrid && !g.store.FullTextEnabled() {
+		mode = RecallModeVector
+	}
+
+	// Rank deeper candidate lists than requested so memories just outside
+	// the limit by one signal can still be lifted by the other, or by
+	// boosts, diversification and chunk aggregation.
+	depth := max(opts.Limit*4, 20)
+	candidates := opts.Limit
+	if opts.Diversity > 0 || opts.Boost != nil || aggregating {
+		candidates = depth
+	}
+
+	var results []store.MemorySearchResult
+	var err error
+	switch mode {
+	case RecallModeKeyword:
+		results, err = g.store.SearchMemoriesKeyword(query, candidates, opts.Filter)
+	case RecallModeVector:
+		if results, err = g.vectorSearch(query, candidates, opts.Filter, minScore); err == nil {
+			results = aggregateScores(results, opts.ChunksPerMemory, opts.Aggregate)
+		}
+	default:
+		var vec, kw []store.MemorySearchResult
+		if vec, err = g.vectorSearch(query, depth, opts.Filter, minScore); err != nil {
+			return nil, err
+		}
+		vec = aggregateScores(vec, opts.ChunksPerMemory, opts.Aggregate)
+		if kw, err = g.store.SearchMemoriesKeyword(query, depth, opts.Filter); err != nil {
+			return nil, err
+		}
+		results = fuseRankings(candidates, vec, kw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Boost != nil {
+		results = boost(results, *opts.Boost, time.Now())
+	}
+	if opts.Diversity > 0 {
+		if results, err = g.diversify(results, opts.Limit, opts.Diversity); err != nil {
+			return nil, err
+		}
+	}
+	if len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	if opts.ChunksPerMemory > 0 {
+		limitChunks(results, opts.ChunksPerMemory)
+	}
+	if opts.ContextChunks > 0 {
+		if err := g.expandExcerpts(results, opts.ContextChunks); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (g *Goldie) vectorSearch(query string, limit int, filter store.MemoryFilter, minScore float32) ([]store.MemorySearchResult, error) {
 	emb, err := g.embedder.Embed(query)
 	if err != nil {
 		return nil, fmt.Errorf("generating query embedding: %w", err)
 	}
-	return g.store.SearchMemories(emb, limit, filter)
+	results, err := g.store.SearchMemories(emb, limit, filter)
+	if err != nil {
+		return nil, err
+	}
+	// Results are best first, so cut at the first one below the minimum,
+	// and drop the weaker chunks of the others.
+	for i := range results {
+		if results[i].Score < minScore {
+			return results[:i], nil
+		}
+		results[i].Chunks = slices.DeleteFunc(results[i].Chunks, func(c store.ChunkMatch) bool {
+			return c.Score < minScore
+		})
+	}
+	return results, nil
 }
 
-// ForgetMemory deletes memories. If query is non-empty, semantic search
-// (constrained by filter) selects up to `limit` candidates and they are
-// deleted. Otherwise every memory matching the filter is deleted. Refuses to
-// run with both an empty filter and an empty query.
-func (g *Goldie) ForgetMemory(filter store.MemoryFilter, query string, limit int) ([]store.Memory, error) {
-	if filter.IsEmpty() && query == "" {
-		return nil, fmt.Errorf("forget requires at least one filter (name, type, agent, source) or a query")
+// fuseRankings merges ranked result lists with reciprocal rank fusion. The
+// first list to mention a memory supplies its excerpt and matched chunks;
+// chunks from the other lists are scored in another metric, so they aren't
+// pooled with them. Fused results are measured in MetricRRF.
+func fuseRankings(limit int, lists ...[]store.MemorySearchResult) []store.MemorySearchResult {
+	index := make(map[string]int)
+	var fused []store.MemorySearchResult
+	for _, list := range lists {
+		for rank, r := range list {
+			score := float32(1.0 / float64(rrfK+rank+1))
+			if i, ok := index[r.Memory.ID]; ok {
+				fused[i].Score += score
+				continue
+			}
+			index[r.Memory.ID] = len(fused)
+			r.Score = score
+			r.Metric = store.MetricRRF
+			fused = append(fused, r)
+		}
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	if len(fused) > limit {
+		fused = fused[:limit]
 	}
+	return fused
+}
+
+// ForgetQuery selects memories to forget by vector recall. Keyword matches
+// aren't used: a single shared word would be enough to forget a memory, and
+// MinScore couldn't hold them back.
+type ForgetQuery struct {
+	Text     string
+	Limit    int
+	MinScore *float32 // as in RecallOptions
+}
 
-	if query != "" {
-		results, err := g.RecallMemory(query, limit, filter)
+// ForgetMemory moves memories in the instance namespace to the trash, where
+// they stay restorable until purged. If the query text is non-empty, recall
+// (constrained by filter) selects up to Limit candidates. Otherwise every
+// memory matching the filter is forgotten. Refuses to run with both an empty
+// filter and an empty query; a namespace alone doesn't count as a filter.
+func (g *Goldie) ForgetMemory(filter store.MemoryFilter, query ForgetQuery) ([]store.Memory, error) {
+	candidates, err := g.PreviewForget(filter, query)
+	if err != nil {
+		return nil, err
+	}
+	var forgotten []store.Memory
+	for _, m := range candidates {
+		ok, err := g.store.TrashMemory(m.ID)
 		if err != nil {
-			return nil, err
+			return forgotten, fmt.Errorf("forgetting %s: %w", m.ID, err)
 		}
-		var deleted []store.Memory
-		for _, r := range results {
-			ok, err := g.store.DeleteMemoryByID(r.Memory.ID)
-			if err != nil {
-				return deleted, fmt.Errorf("deleting %s: %w", r.Memory.ID, err)
-			}
-			if ok {
-				deleted = append(deleted, r.Memory)
-			}
+		if ok {
+			forgotten = append(forgotten, m)
 		}
-		return deleted, nil
 	}
-
-	return g.store.DeleteMemoriesByFilter(filter)
+	return forgotten, nil
 }
 
-// ListMemories returns memories matching the filter, newest first.
+// ListMemories returns memories matching the filter in the instance
+// namespace, newest first.
 func (g *Goldie) ListMemories(filter store.MemoryFilter, limit int) ([]store.Memory, error) {
-	return g.store.ListMemories(filter, limit)
+	return g.store.ListMemories(g.scope(filter), limit)
 }
 
-// CountMemories returns the count of memories matching the filter.
+// CountMemories returns the count of memories matching the filter in the
+// instance namespace.
 func (g *Goldie) CountMemories(filter store.MemoryFilter) (int, error) {
-	return g.store.CountMemories(filter)
+	return g.store.CountMemories(g.scope(filter))
 }
 
-// GetMemory looks up a memory by id, falling back to name lookup.
+// GetMemory looks up a memory in the instance namespace by id, falling back
+// to name lookup.
 func (g *Goldie) GetMemory(idOrName string) (*store.Memory, error) {
 	return g.findMemory(idOrName)
 }
 
+// MemoryDetail is a memory with its chunks, the revisions saved for it
+// (newest first) and its direct links.
+type MemoryDetail struct {
+	Memory    store.Memory
+	Chunks    []store.MemoryChunk
+	Revisions []store.MemoryRevision
+	Links     []LinkedMemory
+}
+
+// GetMemoryDetail looks up a memory in the instance namespace by id or name
+// and loads everything stored alongside it. Returns nil, nil if not found.
+func (g *Goldie) GetMemoryDetail(idOrName string) (*MemoryDetail, error) {
+	m, err := g.findMemory(idOrName)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	d := &MemoryDetail{Memory: *m}
+	if d.Chunks, err = g.store.GetMemoryChunks(m.ID, false); err != nil {
+		return nil, err
+	}
+	if d.Revisions, err = g.store.ListMemoryRevisions(m.ID); err != nil {
+		return nil, err
+	}
+	links, err := g.DirectLinks([]string{m.ID})
+	if err != nil {
+		return nil, err
+	}
+	d.Links = links[m.ID]
+	return d, nil
+}
+
+// findMemory resolves an id or name to a live memory within the instance
+// namespace.
 func (g *Goldie) findMemory(idOrName string) (*store.Memory, error) {
+	m, err := g.findAnyMemory(idOrName)
+	if err != nil || m == nil || m.DeletedAt != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// findAnyMemory is findMemory including the trash. Across all namespaces
+// names are ambiguous, so only ids resolve.
+func (g *Goldie) findAnyMemory(idOrName string) (*store.Memory, error) {
 	m, err := g.store.GetMemory(idOrName)
 	if err != nil {
 		return nil, err
 	}
-	if m != nil {
+	if m != nil && g.inNamespace(m) {
 		return m, nil
 	}
-	return g.store.GetMemoryByName(idOrName)
+	if g.namespace == store.AllNamespaces {
+		return nil, nil
+	}
+	return g.store.GetMemoryByName(g.namespace, idOrName)
 }
 
 // embedChunks generates per-chunk embeddings, prefixing each chunk text with
-// the memory's name and description so semantic recall can hit on those
-// fields too — not just raw body content.
-func (g *Goldie) embedChunks(name, description string, chunks []string) ([][]float32, error) {
+// the memory's name and description, and the chunk's heading path, so
+// semantic recall can hit on those fields too — not just raw body content.
+func (g *Goldie) embedChunks(name, description string, chunks []store.ChunkText) ([][]float32, error) {
 	out := make([][]float32, len(chunks))
 	for i, chunk := range chunks {
 		text := composeEmbedText(name, description, chunk)
@@ -218,7 +648,7 @@ func (g *Goldie) embedChunks(name, description string, chunks []string) ([][]flo
 	return out, nil
 }
 
-func composeEmbedText(name, description, chunk string) string {
+func composeEmbedText(name, description string, chunk store.ChunkText) string {
 	var parts []string
 	if name != "" {
 		parts = append(parts, name)
@@ -226,6 +656,9 @@ func composeEmbedText(name, description, chunk string) string {
 	if description != "" {
 		parts = append(parts, description)
 	}
-	parts = append(parts, chunk)
+	if chunk.HeadingPath != "" {
+		parts = append(parts, chunk.HeadingPath)
+	}
+	parts = append(parts, chunk.Content)
 	return strings.Join(parts, "\n\n")
 }
@@ -0,0 +1,137 @@
+package goldie
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// RenameMemory gives a memory, by id or name, a new name in its namespace
+// and re-embeds it, since chunk embeddings include the name. Returns
+// store.ErrMemoryNameExists if the name is taken, or ErrMemoryInTrash if a
+// forgotten memory holds it.
+func (g *Goldie) RenameMemory(idOrName, newName string) (*store.Memory, error) {
+	if newName == "" {
+		return nil, fmt.Errorf("new name is required")
+	}
+	m, err := g.findMemory(idOrName)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("memory not found: %s", idOrName)
+	}
+	if m.Name == newName {
+		return m, nil
+	}
+
+	chunks := g.chunkBody(m.Body, m.Source)
+	embeddings, err := g.embedChunks(newName, m.Description, chunks)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.store.RenameMemory(m.ID, newName, chunks, embeddings); err != nil {
+		if errors.Is(err, store.ErrMemoryNameExists) {
+			if taken, _ := g.store.GetMemoryByName(m.Namespace, newName); taken != nil && taken.DeletedAt != nil {
+				return nil, ErrMemoryInTrash
+			}
+		}
+		return nil, err
+	}
+	return g.store.GetMemory(m.ID)
+}
+
+// Merge modes select how MergeMemories builds the target's body.
+const (
+	MergeConcat  = "concat"  // the target's body followed by each source's
+	MergeReplace = "replace" // the sources' bodies only
+)
+
+// MergeInput describes a MergeMemories call.
+type MergeInput struct {
+	Target  string   // id or name of the memory that remains
+	Sources []string // ids or names of the memories folded into it
+	Mode    string   // MergeConcat (default) or MergeReplace
+	// DeleteSources deletes the sources outright instead of archiving them
+	// to the trash linked from the target with derived_from.
+	DeleteSources bool
+}
+
+// MergeMemories folds the source memories into the target: the target's
+// body becomes the merged bodies, separated by blank lines, and takes the
+// union of their tags. Archived sources are linked from the target with
+// derived_from, and the links outlive the sources when the trash is purged;
+// deleted sources aren't linked. The target's previous state is saved as a
+// revision, and the whole merge is atomic.
+func (g *Goldie) MergeMemories(in MergeInput) (*store.Memory, error) {
+	switch in.Mode {
+	case "":
+		in.Mode = MergeConcat
+	case MergeConcat, MergeReplace:
+	default:
+		return nil, fmt.Errorf("invalid merge mode %q (allowed: %s, %s)", in.Mode, MergeConcat, MergeReplace)
+	}
+	if len(in.Sources) == 0 {
+		return nil, fmt.Errorf("at least one source memory is required")
+	}
+	target, err := g.findMemory(in.Target)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("memory not found: %s", in.Target)
+	}
+
+	var bodies []string
+	if in.Mode == MergeConcat {
+		bodies = append(bodies, strings.TrimSpace(target.Body))
+	}
+	tags := target.Tags
+	seen := map[string]bool{target.ID: true}
+	var sourceIDs []string
+	for _, ref := range in.Sources {
+		src, err := g.findMemory(ref)
+		if err != nil {
+			return nil, err
+		}
+		if src == nil {
+			return nil, fmt.Errorf("memory not found: %s", ref)
+		}
+		if seen[src.ID] {
+			return nil, fmt.Errorf("%s is listed twice or is the target", ref)
+		}
+		if src.Namespace != target.Namespace {
+			return nil, fmt.Errorf("cannot merge memories across namespaces")
+		}
+		seen[src.ID] = true
+		sourceIDs = append(sourceIDs, src.ID)
+		bodies = append(bodies, strings.TrimSpace(src.Body))
+		tags = append(tags, src.Tags...)
+	}
+	tags, err = store.NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	body := strings.Join(bodies, "\n\n")
+	chunks := g.chunkBody(body, target.Source)
+	embeddings, err := g.embedChunks(target.Name, target.Description, chunks)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.store.MergeMemories(store.MemoryMerge{
+		TargetID:      target.ID,
+		Body:          body,
+		Tags:          tags,
+		Chunks:        chunks,
+		Embeddings:    embeddings,
+		SourceIDs:     sourceIDs,
+		LinkType:      LinkDerivedFrom,
+		DeleteSources: in.DeleteSources,
+	}); err != nil {
+		return nil, fmt.Errorf("merging memories: %w", err)
+	}
+	return g.store.GetMemory(target.ID)
+}
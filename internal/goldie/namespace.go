@@ -0,0 +1,77 @@
+package goldie
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// maxNamespaceLen bounds namespace names; they are meant to be short project keys.
+const maxNamespaceLen = 100
+
+// ErrAllNamespaces is returned by operations that create memories when the
+// instance is scoped to every namespace, since there is no namespace to put
+// the new memory in.
+var ErrAllNamespaces = errors.New("cannot create memories across all namespaces; pick one namespace")
+
+// ValidateNamespace returns an error if ns is not a usable namespace name.
+// store.AllNamespaces is not a namespace name; see WithNamespace.
+func ValidateNamespace(ns string) error {
+	if ns == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if len(ns) > maxNamespaceLen {
+		return fmt.Errorf("namespace %q is longer than %d bytes", ns, maxNamespaceLen)
+	}
+	if strings.ContainsFunc(ns, func(r rune) bool { return r == '*' || unicode.IsSpace(r) || unicode.IsControl(r) }) {
+		return fmt.Errorf("invalid namespace %q: namespaces may not contain '*' or whitespace", ns)
+	}
+	return nil
+}
+
+// Namespace returns the namespace this instance reads and writes.
+func (g *Goldie) Namespace() string {
+	return g.namespace
+}
+
+// WithNamespace returns a view of g scoped to ns, sharing its store and
+// embedder; closing either closes both. ns may be store.AllNamespaces, in
+// which case reads span every namespace and creating memories is refused.
+func (g *Goldie) WithNamespace(ns string) (*Goldie, error) {
+	if ns != store.AllNamespaces {
+		if err := ValidateNamespace(ns); err != nil {
+			return nil, err
+		}
+	}
+	if ns == g.namespace {
+		return g, nil
+	}
+	view := *g
+	view.namespace = ns
+	return &view, nil
+}
+
+// scope applies the instance namespace to a filter that doesn't name one.
+func (g *Goldie) scope(filter store.MemoryFilter) store.MemoryFilter {
+	if filter.Namespace == "" {
+		filter.Namespace = g.namespace
+	}
+	return filter
+}
+
+// inNamespace reports whether m is visible from this instance.
+func (g *Goldie) inNamespace(m *store.Memory) bool {
+	return g.namespace == store.AllNamespaces || m.Namespace == g.namespace
+}
+
+// writableNamespace returns ErrAllNamespaces if new memories can't be
+// created from this instance.
+func (g *Goldie) writableNamespace() error {
+	if g.namespace == store.AllNamespaces {
+		return ErrAllNamespaces
+	}
+	return nil
+}
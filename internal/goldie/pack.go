@@ -0,0 +1,140 @@
+package goldie
+
+import (
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+const (
+	// TruncationMarker stands in for text left out of a packed body.
+	TruncationMarker = "[…]"
+	// packFullChunks is the most chunks a memory may have for its full body
+	// to be packed; longer ones get their matched chunk and its neighbours.
+	packFullChunks = 3
+	// packMinSize is the smallest room, in either unit, worth filling with a
+	// truncated excerpt.
+	packMinSize = 16
+)
+
+// PackedResult is a recall result with its body cut to fit a budget.
+type PackedResult struct {
+	store.MemorySearchResult
+	// Content is the full body, or the matched chunk with its neighbours,
+	// with TruncationMarker where text was left out.
+	Content   string
+	Truncated bool
+	Size      int // of Content, in the budget's unit
+}
+
+// PackResults fits ranked recall results into a budget greedily, best
+// first. Short memories get their full body; long ones, and short ones that
+// no longer fit, get the matched chunk and its neighbouring chunks, then the
+// matched chunk alone, then as much of it as fits. Results with no room left
+// are returned as omitted, in rank order.
+func (g *Goldie) PackResults(results []store.MemorySearchResult, budget Budget) ([]PackedResult, []store.MemorySearchResult, error) {
+	if err := budget.validate(); err != nil {
+		return nil, nil, err
+	}
+	var packed []PackedResult
+	var omitted []store.MemorySearchResult
+	room := budget.Limit()
+	for _, r := range results {
+		p, err := g.packResult(r, budget, room)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p == nil {
+			omitted = append(omitted, r)
+			continue
+		}
+		packed = append(packed, *p)
+		room -= p.Size
+	}
+	return packed, omitted, nil
+}
+
+// RecallPacked is Recall with the results fitted into a budget by
+// PackResults; a zero budget keeps every result with its full body. Only
+// the packed results are recorded as recalled, not the omitted ones.
+func (g *Goldie) RecallPacked(query string, opts RecallOptions, budget Budget) ([]PackedResult, []store.MemorySearchResult, error) {
+	if err := budget.validate(); err != nil {
+		return nil, nil, err
+	}
+	results, err := g.recall(query, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var packed []PackedResult
+	var omitted []store.MemorySearchResult
+	if budget.IsZero() {
+		packed = make([]PackedResult, len(results))
+		for i, r := range results {
+			packed[i] = PackedResult{MemorySearchResult: r, Content: r.Memory.Body}
+		}
+	} else if packed, omitted, err = g.PackResults(results, budget); err != nil {
+		return nil, nil, err
+	}
+	memories := make([]*store.Memory, len(packed))
+	for i := range packed {
+		memories[i] = &packed[i].Memory
+	}
+	g.markRecalled(memories)
+	return packed, omitted, nil
+}
+
+// packResult returns the largest candidate content for r that fits in room,
+// or nil if none does.
+func (g *Goldie) packResult(r store.MemorySearchResult, budget Budget, room int) (*PackedResult, error) {
+	if room < packMinSize {
+		return nil, nil
+	}
+	chunks, err := g.store.GetMemoryChunks(r.Memory.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	body := r.Memory.Body
+	try := func(content string, truncated bool) (*PackedResult, error) {
+		size, err := budget.size(content)
+		if err != nil || size > room {
+			return nil, err
+		}
+		return &PackedResult{MemorySearchResult: r, Content: content, Truncated: truncated, Size: size}, nil
+	}
+
+	if len(chunks) <= packFullChunks {
+		if p, err := try(body, false); p != nil || err != nil {
+			return p, err
+		}
+	}
+	spans := chunkSpans(body, chunks)
+	matched := min(max(r.ChunkIndex, 0), max(len(chunks)-1, 0))
+	if spans != nil {
+		for _, width := range []int{1, 0} {
+			lo, hi := max(matched-width, 0), min(matched+width, len(spans)-1)
+			content, truncated := excerptWithMarkers(body, spans[lo][0], spans[hi][1])
+			if p, err := try(content, truncated); p != nil || err != nil {
+				return p, err
+			}
+		}
+	}
+
+	// Cut the matched chunk down, leaving room for the markers.
+	chunk := body
+	if len(chunks) > 0 {
+		chunk = chunks[matched].Content
+	}
+	markers, err := budget.size(TruncationMarker + "\n\n" + TruncationMarker)
+	if err != nil {
+		return nil, err
+	}
+	cut, err := budget.truncate(chunk, room-markers)
+	if err != nil || cut == "" {
+		return nil, err
+	}
+	content := cut + "\n" + TruncationMarker
+	if !strings.HasPrefix(body, cut) {
+		content = TruncationMarker + "\n" + content
+	}
+	return try(content, true)
+}
@@ -0,0 +1,136 @@
+package goldie
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// RankBoosts weighs what recall adds to a memory's relevance, scaled to
+// [0, 1] across the candidates, when ranking boosts are on. Each signal is
+// itself between 0 and 1, so a weight is the most it can lift a memory.
+type RankBoosts struct {
+	Recency    float32       // weight of how recently the memory was updated
+	HalfLife   time.Duration // age of the last update at which recency halves
+	Usage      float32       // weight of how often the memory was recalled
+	Importance float32       // weight of the memory's importance
+}
+
+// DefaultRankBoosts are the boosts for each memory type. Work in flight
+// (projects, todos, reminders) goes stale quickly; facts about the user
+// don't go stale at all.
+var DefaultRankBoosts = map[string]RankBoosts{
+	"user":      {Usage: 0.05, Importance: 0.1},
+	"feedback":  {Recency: 0.1, HalfLife: 180 * 24 * time.Hour, Usage: 0.05, Importance: 0.1},
+	"project":   {Recency: 0.2, HalfLife: 30 * 24 * time.Hour, Usage: 0.05, Importance: 0.1},
+	"reference": {Recency: 0.05, HalfLife: 365 * 24 * time.Hour, Usage: 0.05, Importance: 0.1},
+	"opinion":   {Recency: 0.1, HalfLife: 90 * 24 * time.Hour, Usage: 0.05, Importance: 0.1},
+	"idea":      {Recency: 0.1, HalfLife: 90 * 24 * time.Hour, Usage: 0.05, Importance: 0.1},
+	"todo":      {Recency: 0.2, HalfLife: 14 * 24 * time.Hour, Usage: 0.05, Importance: 0.1},
+	"reminder":  {Recency: 0.2, HalfLife: 14 * 24 * time.Hour, Usage: 0.05, Importance: 0.1},
+}
+
+// ValidateImportance returns an error if a memory importance is outside 0
+// to 1.
+func ValidateImportance(v float32) error {
+	if v < 0 || v > 1 {
+		return fmt.Errorf("importance must be between 0 and 1, got %v", v)
+	}
+	return nil
+}
+
+// usageSaturation is the recall count at which the usage signal reaches
+// half its weight; it approaches the full weight from there.
+const usageSaturation = 5
+
+// BoostOptions turns ranking boosts on for a recall. Nil weights and a zero
+// HalfLife use the memory type's DefaultRankBoosts.
+type BoostOptions struct {
+	Recency    *float32
+	HalfLife   time.Duration
+	Usage      *float32
+	Importance *float32
+}
+
+func (o BoostOptions) validate() error {
+	weights := []struct {
+		name string
+		w    *float32
+	}{{"recency", o.Recency}, {"usage", o.Usage}, {"importance", o.Importance}}
+	for _, w := range weights {
+		if w.w != nil && (*w.w < 0 || *w.w > 1) {
+			return fmt.Errorf("%s boost must be between 0 and 1, got %v", w.name, *w.w)
+		}
+	}
+	if o.HalfLife < 0 {
+		return fmt.Errorf("recency half-life must be positive, got %v", o.HalfLife)
+	}
+	return nil
+}
+
+// rankBoostsFor returns the boosts for a memory type with the call's
+// overrides applied.
+func (o BoostOptions) rankBoostsFor(memType string) RankBoosts {
+	b := DefaultRankBoosts[memType]
+	if o.Recency != nil {
+		b.Recency = *o.Recency
+	}
+	if o.HalfLife > 0 {
+		b.HalfLife = o.HalfLife
+	}
+	if o.Usage != nil {
+		b.Usage = *o.Usage
+	}
+	if o.Importance != nil {
+		b.Importance = *o.Importance
+	}
+	return b
+}
+
+// boost re-ranks results by relevance plus the boosts for each memory's
+// type. Score becomes the relevance scaled to [0, 1] plus Boost.
+func boost(results []store.MemorySearchResult, opts BoostOptions, now time.Time) []store.MemorySearchResult {
+	relevance := normalizedScores(results)
+	for i := range results {
+		m := &results[i].Memory
+		b := opts.rankBoostsFor(m.Type)
+		var total float32
+		if b.Recency > 0 && b.HalfLife > 0 {
+			age := max(now.Sub(m.UpdatedAt), 0)
+			total += b.Recency * float32(math.Exp2(-age.Hours()/b.HalfLife.Hours()))
+		}
+		if m.RecallCount > 0 {
+			total += b.Usage * float32(m.RecallCount) / float32(m.RecallCount+usageSaturation)
+		}
+		total += b.Importance * m.Importance
+		results[i].Boost = total
+		results[i].Score = relevance[i] + total
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// normalizedScores returns the results' scores scaled to [0, 1] across the
+// results, or all 1 if they are equal.
+func normalizedScores(results []store.MemorySearchResult) []float32 {
+	out := make([]float32, len(results))
+	if len(results) == 0 {
+		return out
+	}
+	lo, hi := results[0].Score, results[0].Score
+	for _, r := range results {
+		lo, hi = min(lo, r.Score), max(hi, r.Score)
+	}
+	for i, r := range results {
+		out[i] = 1
+		if hi > lo {
+			out[i] = (r.Score - lo) / (hi - lo)
+		}
+	}
+	return out
+}
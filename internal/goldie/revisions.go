@@ -0,0 +1,98 @@
+package goldie
+
+import (
+	"fmt"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// CurrentRevision addresses a memory's current state in DiffMemory.
+const CurrentRevision = 0
+
+// MemoryHistory returns a memory and its saved revisions, newest first.
+func (g *Goldie) MemoryHistory(idOrName string) (*store.Memory, []store.MemoryRevision, error) {
+	m, err := g.findMemory(idOrName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m == nil {
+		return nil, nil, fmt.Errorf("memory not found: %s", idOrName)
+	}
+	revisions, err := g.store.ListMemoryRevisions(m.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, revisions, nil
+}
+
+// DiffMemory returns a unified diff of a memory's description and body from
+// revision `from` to revision `to`; CurrentRevision stands for the memory as
+// it is now. Equal revisions give an empty diff.
+func (g *Goldie) DiffMemory(idOrName string, from, to int) (string, error) {
+	m, err := g.findMemory(idOrName)
+	if err != nil {
+		return "", err
+	}
+	if m == nil {
+		return "", fmt.Errorf("memory not found: %s", idOrName)
+	}
+	a, err := g.revisionText(m, from)
+	if err != nil {
+		return "", err
+	}
+	b, err := g.revisionText(m, to)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(revisionLabel(m.Name, from), revisionLabel(m.Name, to), a, b), nil
+}
+
+// RestoreMemory rolls a memory's description and body back to a saved
+// revision and re-embeds it. The state being replaced is itself saved, so a
+// restore can be undone.
+func (g *Goldie) RestoreMemory(idOrName string, revision int) (*store.Memory, error) {
+	m, err := g.findMemory(idOrName)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("memory not found: %s", idOrName)
+	}
+	rev, err := g.store.GetMemoryRevision(m.ID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, fmt.Errorf("memory %s has no revision %d", m.Name, revision)
+	}
+	return g.UpdateMemory(m.ID, UpdateMemoryInput{
+		Description: &rev.Description,
+		Body:        &rev.Body,
+	})
+}
+
+// revisionText renders one revision of m for diffing.
+func (g *Goldie) revisionText(m *store.Memory, revision int) (string, error) {
+	description, body := m.Description, m.Body
+	if revision != CurrentRevision {
+		rev, err := g.store.GetMemoryRevision(m.ID, revision)
+		if err != nil {
+			return "", err
+		}
+		if rev == nil {
+			return "", fmt.Errorf("memory %s has no revision %d", m.Name, revision)
+		}
+		description, body = rev.Description, rev.Body
+	}
+	if description == "" {
+		return body, nil
+	}
+	return "description: " + description + "\n\n" + body, nil
+}
+
+func revisionLabel(name string, revision int) string {
+	if revision == CurrentRevision {
+		return name + " (current)"
+	}
+	return fmt.Sprintf("%s (revision %d)", name, revision)
+}
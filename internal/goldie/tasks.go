@@ -0,0 +1,123 @@
+package goldie
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// TaskTypes are the memory types that carry a due date and a status.
+var TaskTypes = []string{"todo", "reminder"}
+
+// IsTaskType reports whether memories of type t carry a due date and status.
+func IsTaskType(t string) bool {
+	return slices.Contains(TaskTypes, t)
+}
+
+// ValidateMemoryStatus returns an error if status is not a recognized task
+// status.
+func ValidateMemoryStatus(status string) error {
+	switch status {
+	case store.MemoryStatusOpen, store.MemoryStatusDone, store.MemoryStatusSnoozed:
+		return nil
+	}
+	return fmt.Errorf("invalid status %q (allowed: %s, %s, %s)", status,
+		store.MemoryStatusOpen, store.MemoryStatusDone, store.MemoryStatusSnoozed)
+}
+
+// errNotTask is returned when due dates or statuses are set on a memory
+// that isn't a todo or reminder.
+func errNotTask(memType string) error {
+	return fmt.Errorf("due_at and status only apply to todo and reminder memories, not %s", memType)
+}
+
+// taskPatch checks an update's due date and status against the memory's
+// resulting type. A memory turning into a todo or reminder starts out open;
+// one turning into anything else drops its due date and status.
+func taskPatch(existing *store.Memory, in *UpdateMemoryInput) error {
+	newType := existing.Type
+	if in.Type != "" {
+		newType = in.Type
+	}
+	if !IsTaskType(newType) {
+		if (in.DueAt != nil && !in.DueAt.IsZero()) || (in.Status != nil && *in.Status != "") {
+			return errNotTask(newType)
+		}
+		if IsTaskType(existing.Type) {
+			var none time.Time
+			cleared := ""
+			in.DueAt, in.Status = &none, &cleared
+		}
+		return nil
+	}
+	if in.Status != nil {
+		return ValidateMemoryStatus(*in.Status)
+	}
+	if existing.Status == "" {
+		open := store.MemoryStatusOpen
+		in.Status = &open
+	}
+	return nil
+}
+
+// CompleteMemory marks a todo or reminder, by id or name, as done.
+func (g *Goldie) CompleteMemory(idOrName string) (*store.Memory, error) {
+	status := store.MemoryStatusDone
+	return g.setTaskState(idOrName, store.MemoryUpdate{Status: &status})
+}
+
+// SnoozeMemory pushes a todo or reminder, by id or name, back until the
+// given time, which becomes its new due date.
+func (g *Goldie) SnoozeMemory(idOrName string, until time.Time) (*store.Memory, error) {
+	if !until.After(time.Now()) {
+		return nil, fmt.Errorf("snooze time must be in the future")
+	}
+	status := store.MemoryStatusSnoozed
+	return g.setTaskState(idOrName, store.MemoryUpdate{Status: &status, DueAt: &until})
+}
+
+func (g *Goldie) setTaskState(idOrName string, patch store.MemoryUpdate) (*store.Memory, error) {
+	m, err := g.findMemory(idOrName)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("memory not found: %s", idOrName)
+	}
+	if !IsTaskType(m.Type) {
+		return nil, fmt.Errorf("%s is a %s memory; only todo and reminder memories have a status", m.Name, m.Type)
+	}
+	if err := g.store.UpdateMemoryFields(m.ID, patch); err != nil {
+		return nil, fmt.Errorf("updating memory: %w", err)
+	}
+	return g.store.GetMemory(m.ID)
+}
+
+// DueReminders returns open and snoozed todos and reminders matching the
+// filter in the instance namespace that are due within the window from now,
+// overdue ones included, most urgent first.
+func (g *Goldie) DueReminders(within time.Duration, filter store.MemoryFilter, limit int) ([]store.Memory, error) {
+	if filter.Type != "" && !IsTaskType(filter.Type) {
+		return nil, errNotTask(filter.Type)
+	}
+	return g.store.ListDueMemories(g.scope(filter), time.Now().Add(within), limit)
+}
+
+// dueAt returns the memory's due date, or the zero time if it has none.
+func dueAt(m store.Memory) time.Time {
+	if m.DueAt == nil {
+		return time.Time{}
+	}
+	return *m.DueAt
+}
+
+// optionalString returns nil for an empty string, leaving a patch field
+// unchanged.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,270 @@
+package goldie
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// Conflict policies decide what Import does when a memory name already exists.
+const (
+	ConflictSkip      = "skip"      // keep the existing memory
+	ConflictOverwrite = "overwrite" // replace it with the imported one
+	ConflictRename    = "rename"    // import under a fresh name (name-2, name-3, ...)
+	ConflictNewer     = "newer"     // replace it only if the import's updated_at is later
+)
+
+// maxImportLine bounds a single JSONL record; bodies of indexed files can be large.
+const maxImportLine = 64 << 20
+
+// ExportRecord is one line of a JSONL export: a memory, optionally followed
+// by its chunks and the fingerprint of the model that embedded them.
+type ExportRecord struct {
+	store.Memory
+	Embedding *store.EmbeddingInfo `json:"embedding,omitempty"`
+	Chunks    []store.MemoryChunk  `json:"chunks,omitempty"`
+}
+
+// ExportOptions controls Export.
+type ExportOptions struct {
+	Filter store.MemoryFilter
+	// IncludeEmbeddings adds each memory's chunks and vectors so an import
+	// into a database using the same model can skip re-embedding.
+	IncludeEmbeddings bool
+}
+
+// ImportOptions controls Import.
+type ImportOptions struct {
+	OnConflict string // one of the Conflict* policies (default: skip)
+}
+
+// ImportResult summarizes an Import.
+type ImportResult struct {
+	Imported    int      `json:"imported"`
+	Overwritten int      `json:"overwritten"`
+	Renamed     int      `json:"renamed"`
+	Skipped     int      `json:"skipped"`
+	Reembedded  int      `json:"reembedded"`
+	Errors      []string `json:"errors,omitempty"`
+}
+
+// ValidateConflictPolicy returns an error if policy is not recognized. The
+// empty string is accepted and means the default (skip).
+func ValidateConflictPolicy(policy string) error {
+	switch policy {
+	case "", ConflictSkip, ConflictOverwrite, ConflictRename, ConflictNewer:
+		return nil
+	}
+	return fmt.Errorf("invalid conflict policy %q (allowed: %s, %s, %s, %s)",
+		policy, ConflictSkip, ConflictOverwrite, ConflictRename, ConflictNewer)
+}
+
+// Export writes every memory matching the filter in the instance namespace to
+// w as JSONL, one memory per line, sorted by namespace and name so exports of
+// the same pool diff cleanly. Memories are streamed from the database one at
+// a time. It returns the number of memories written.
+func (g *Goldie) Export(w io.Writer, opts ExportOptions) (int, error) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	n := 0
+	err := g.store.EachMemory(g.scope(opts.Filter), func(m *store.Memory) error {
+		rec := ExportRecord{Memory: *m}
+		if opts.IncludeEmbeddings {
+			chunks, err := g.store.GetMemoryChunks(m.ID, true)
+			if err != nil {
+				return fmt.Errorf("reading chunks for %s: %w", m.Name, err)
+			}
+			info := g.embedding
+			rec.Embedding = &info
+			rec.Chunks = chunks
+		}
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("writing %s: %w", m.Name, err)
+		}
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// Import reads a JSONL export from r and adds its memories to the instance
+// namespace, or to each record's own namespace when the instance spans all
+// namespaces. Names that already exist there are resolved with
+// opts.OnConflict. Stored vectors are reused only when they come from the
+// configured embedding model; otherwise bodies are re-chunked and
+// re-embedded. A malformed or invalid record is reported in the result and
+// does not stop the import.
+func (g *Goldie) Import(r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	if err := ValidateConflictPolicy(opts.OnConflict); err != nil {
+		return nil, err
+	}
+	policy := opts.OnConflict
+	if policy == "" {
+		policy = ConflictSkip
+	}
+
+	res := &ImportResult{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var rec ExportRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+		target, err := g.importTarget(rec.Namespace)
+		if err == nil {
+			err = target.importRecord(&rec, policy, res)
+		}
+		if err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("line %d (%s): %v", line, rec.Name, err))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return res, fmt.Errorf("reading import: %w", err)
+	}
+	return res, nil
+}
+
+func (g *Goldie) importRecord(rec *ExportRecord, policy string, res *ImportResult) error {
+	if rec.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if rec.Body == "" {
+		return fmt.Errorf("body is required")
+	}
+	if err := ValidateMemoryType(rec.Type); err != nil {
+		return err
+	}
+
+	existing, err := g.store.GetMemoryByName(g.namespace, rec.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.DeletedAt != nil {
+		// A forgotten memory yields to the import; ReplaceMemory untrashes it.
+		policy = ConflictOverwrite
+	}
+	if existing != nil {
+		switch policy {
+		case ConflictSkip:
+			res.Skipped++
+			return nil
+		case ConflictNewer:
+			if !rec.UpdatedAt.After(existing.UpdatedAt) {
+				res.Skipped++
+				return nil
+			}
+		case ConflictRename:
+			name, err := g.freeName(rec.Name)
+			if err != nil {
+				return err
+			}
+			rec.Name = name
+			// The stored vectors embed the old name, so they no longer apply.
+			rec.Chunks = nil
+		}
+	}
+
+	chunks, embeddings, reembedded, err := g.importChunks(rec)
+	if err != nil {
+		return err
+	}
+	m := rec.Memory
+	m.Namespace = g.namespace
+
+	if existing != nil && policy != ConflictRename {
+		m.ID = existing.ID
+		if err := g.store.ReplaceMemory(&m, chunks, embeddings); err != nil {
+			return err
+		}
+		res.Overwritten++
+	} else {
+		// Keep the exported id unless it already belongs to another memory.
+		if m.ID != "" {
+			taken, err := g.store.GetMemory(m.ID)
+			if err != nil {
+				return err
+			}
+			if taken != nil {
+				m.ID = ""
+			}
+		}
+		if err := g.store.AddMemory(&m, chunks, embeddings); err != nil {
+			return err
+		}
+		if existing != nil {
+			res.Renamed++
+		} else {
+			res.Imported++
+		}
+	}
+	if reembedded {
+		res.Reembedded++
+	}
+	return nil
+}
+
+// importTarget returns the instance that imports a record exported from
+// namespace ns: g itself, unless g spans all namespaces, in which case the
+// record goes back to its own namespace.
+func (g *Goldie) importTarget(ns string) (*Goldie, error) {
+	if g.namespace != store.AllNamespaces {
+		return g, nil
+	}
+	if ns == "" {
+		ns = store.DefaultNamespace
+	}
+	return g.WithNamespace(ns)
+}
+
+// importChunks returns the record's stored chunks and vectors when they were
+// produced by the configured model, and freshly embedded ones otherwise.
+func (g *Goldie) importChunks(rec *ExportRecord) ([]store.ChunkText, [][]float32, bool, error) {
+	if rec.Embedding != nil && *rec.Embedding == g.embedding && len(rec.Chunks) > 0 {
+		chunks := make([]store.ChunkText, len(rec.Chunks))
+		embeddings := make([][]float32, len(rec.Chunks))
+		usable := true
+		for i, c := range rec.Chunks {
+			if len(c.Embedding) != g.embedding.Dimensions {
+				usable = false
+				break
+			}
+			chunks[i] = store.ChunkText{Content: c.Content, HeadingPath: c.HeadingPath}
+			embeddings[i] = c.Embedding
+		}
+		if usable {
+			return chunks, embeddings, false, nil
+		}
+	}
+
+	chunks := g.chunkBody(rec.Body, rec.Source)
+	embeddings, err := g.embedChunks(rec.Name, rec.Description, chunks)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return chunks, embeddings, true, nil
+}
+
+// freeName returns the first of name-2, name-3, ... that is not taken in the
+// instance namespace.
+func (g *Goldie) freeName(name string) (string, error) {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		m, err := g.store.GetMemoryByName(g.namespace, candidate)
+		if err != nil {
+			return "", err
+		}
+		if m == nil {
+			return candidate, nil
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package goldie
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/srfrog/goldie-mcp/internal/store"
+)
+
+// DefaultTrashRetention is how long forgotten memories stay restorable.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// ErrMemoryInTrash is returned by Remember when the name belongs to a
+// forgotten memory that is still in the trash.
+var ErrMemoryInTrash = errors.New("memory with that name is in the trash")
+
+// ErrNotInTrash is returned by RestoreFromTrash when the memory isn't in the
+// trash.
+var ErrNotInTrash = errors.New("no memory in the trash")
+
+// PreviewForget returns the memories ForgetMemory would move to the trash
+// for the same arguments, without touching them. Queries are matched by
+// vector similarity only; see ForgetQuery.
+func (g *Goldie) PreviewForget(filter store.MemoryFilter, query ForgetQuery) ([]store.Memory, error) {
+	if filter.IsEmpty() && query.Text == "" {
+		return nil, fmt.Errorf("forget requires at least one filter (name, type, agent, source, tags) or a query")
+	}
+	filter = g.scope(filter)
+	if query.Text == "" {
+		return g.store.ListMemories(filter, 0)
+	}
+	results, err := g.recall(query.Text, RecallOptions{
+		Limit:    query.Limit,
+		Filter:   filter,
+		Mode:     RecallModeVector,
+		MinScore: query.MinScore,
+	})
+	if err != nil {
+		return nil, err
+	}
+	memories := make([]store.Memory, len(results))
+	for i, r := range results {
+		memories[i] = r.Memory
+	}
+	return memories, nil
+}
+
+// ListTrash returns forgotten memories matching the filter in the instance
+// namespace, most recently forgotten first.
+func (g *Goldie) ListTrash(filter store.MemoryFilter, limit int) ([]store.Memory, error) {
+	filter.Trashed = true
+	return g.store.ListMemories(g.scope(filter), limit)
+}
+
+// RestoreFromTrash takes a forgotten memory, by id or name, out of the trash
+// and, if revision is positive, rolls it back to that revision. The revision
+// is checked first, so a memory is never untrashed by a restore that fails.
+func (g *Goldie) RestoreFromTrash(idOrName string, revision int) (*store.Memory, error) {
+	m, err := g.findAnyMemory(idOrName)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || m.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotInTrash, idOrName)
+	}
+	if revision > 0 {
+		rev, err := g.store.GetMemoryRevision(m.ID, revision)
+		if err != nil {
+			return nil, err
+		}
+		if rev == nil {
+			return nil, fmt.Errorf("memory %s has no revision %d", m.Name, revision)
+		}
+	}
+	if _, err := g.store.UntrashMemory(m.ID); err != nil {
+		return nil, err
+	}
+	if revision > 0 {
+		return g.RestoreMemory(m.ID, revision)
+	}
+	return g.store.GetMemory(m.ID)
+}
+
+// TrashRetention returns how long forgotten memories are kept, or a negative
+// duration if they are kept until restored.
+func (g *Goldie) TrashRetention() time.Duration {
+	return g.trashRetention
+}
+
+// PurgeTrash permanently deletes, in every namespace, memories that have
+// been in the trash longer than the retention.
+func (g *Goldie) PurgeTrash() (int, error) {
+	if g.trashRetention < 0 {
+		return 0, nil
+	}
+	return g.store.PurgeTrash(time.Now().Add(-g.trashRetention))
+}
+
+// ParseDuration parses a Go duration ("36h", "90m") or a whole number of
+// days or weeks ("30d", "2w").
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
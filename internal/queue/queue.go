@@ -29,6 +29,73 @@ type IndexDirParams struct {
 	Agent     string `json:"agent,omitempty"`
 }
 
+// ReembedParams represents parameters for a reembed job: the embedding model
+// the pool is being migrated to
+type ReembedParams struct {
+	Target store.EmbeddingInfo `json:"target"`
+}
+
+// FindDuplicatesParams represents parameters for a find_duplicates job: the
+// similarity threshold (0 for the configured default) and which memories
+// to scan
+type FindDuplicatesParams struct {
+	Threshold float32  `json:"threshold,omitempty"`
+	Type      string   `json:"type,omitempty"`
+	Agent     string   `json:"agent,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	TagsAny   []string `json:"tags_any,omitempty"`
+	TagsAll   []string `json:"tags_all,omitempty"`
+}
+
+// FindDuplicatesResult is the result of a find_duplicates job, stored as
+// the job's Result
+type FindDuplicatesResult struct {
+	Scanned  int                `json:"scanned"`
+	Clusters []DuplicateCluster `json:"clusters"`
+}
+
+// DuplicateCluster is one cluster of a find_duplicates result. Number is
+// its 1-based position, used to select it for resolve_duplicates; the
+// first memory is the one to keep.
+type DuplicateCluster struct {
+	Number   int                `json:"cluster"`
+	Score    float32            `json:"score"`
+	Memories []DuplicateMember  `json:"memories"`
+	Pairs    []DuplicatePairRef `json:"pairs"`
+}
+
+// DuplicateMember is a memory in a DuplicateCluster
+type DuplicateMember struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DuplicatePairRef is a near-duplicate pair in a DuplicateCluster, by
+// memory id
+type DuplicatePairRef struct {
+	A          string  `json:"a"`
+	B          string  `json:"b"`
+	Similarity float32 `json:"similarity"`
+}
+
+const (
+	// staleJobAfter is how long a processing job may go untouched before it
+	// is presumed abandoned and requeued
+	staleJobAfter = 5 * time.Minute
+	// reembedBatch is how many memory ids a reembed job loads at a time
+	reembedBatch = 100
+	// trashPurgeEvery is how often memories past the trash retention are purged
+	trashPurgeEvery = time.Hour
+	// expirySweepEvery is how often expired memories get their type's expiry
+	// policy applied
+	expirySweepEvery = 5 * time.Minute
+	// duplicateProgressEvery is how many memories a find_duplicates job
+	// compares between progress updates
+	duplicateProgressEvery = 25
+)
+
 // Queue manages background job processing
 type Queue struct {
 	store   *store.Store
@@ -66,8 +133,8 @@ func (q *Queue) Stop() {
 	q.wg.Wait()
 }
 
-// EnqueueIndexFile creates a job to index a file
-func (q *Queue) EnqueueIndexFile(path, agent string) (string, error) {
+// EnqueueIndexFile creates a job to index a file into a namespace
+func (q *Queue) EnqueueIndexFile(namespace, path, agent string) (string, error) {
 	id := uuid.New().String()
 
 	params, err := json.Marshal(IndexFileParams{Path: path, Agent: agent})
@@ -75,15 +142,16 @@ func (q *Queue) EnqueueIndexFile(path, agent string) (string, error) {
 		return "", fmt.Errorf("marshaling params: %w", err)
 	}
 
-	if err := q.store.CreateJob(id, store.JobTypeIndexFile, string(params)); err != nil {
+	if err := q.store.CreateJob(id, store.JobTypeIndexFile, namespace, string(params)); err != nil {
 		return "", fmt.Errorf("creating job: %w", err)
 	}
 
 	return id, nil
 }
 
-// EnqueueIndexFileWithParent creates a job to index a file as a child of a parent job
-func (q *Queue) EnqueueIndexFileWithParent(path, agent, parentID string) (string, error) {
+// EnqueueIndexFileWithParent creates a job to index a file into a namespace
+// as a child of a parent job
+func (q *Queue) EnqueueIndexFileWithParent(namespace, path, agent, parentID string) (string, error) {
 	id := uuid.New().String()
 
 	params, err := json.Marshal(IndexFileParams{Path: path, Agent: agent})
@@ -91,15 +159,15 @@ func (q *Queue) EnqueueIndexFileWithParent(path, agent, parentID string) (string
 		return "", fmt.Errorf("marshaling params: %w", err)
 	}
 
-	if err := q.store.CreateJobWithParent(id, store.JobTypeIndexFile, string(params), parentID); err != nil {
+	if err := q.store.CreateJobWithParent(id, store.JobTypeIndexFile, namespace, string(params), parentID); err != nil {
 		return "", fmt.Errorf("creating job: %w", err)
 	}
 
 	return id, nil
 }
 
-// EnqueueIndexDirectory creates a job to index a directory
-func (q *Queue) EnqueueIndexDirectory(directory, pattern string, recursive bool, agent string) (string, error) {
+// EnqueueIndexDirectory creates a job to index a directory into a namespace
+func (q *Queue) EnqueueIndexDirectory(namespace, directory, pattern string, recursive bool, agent string) (string, error) {
 	id := uuid.New().String()
 
 	params, err := json.Marshal(IndexDirParams{
@@ -112,13 +180,55 @@ func (q *Queue) EnqueueIndexDirectory(directory, pattern string, recursive bool,
 		return "", fmt.Errorf("marshaling params: %w", err)
 	}
 
-	if err := q.store.CreateJob(id, store.JobTypeIndexDir, string(params)); err != nil {
+	if err := q.store.CreateJob(id, store.JobTypeIndexDir, namespace, string(params)); err != nil {
 		return "", fmt.Errorf("creating job: %w", err)
 	}
 
 	return id, nil
 }
 
+// EnqueueReembed switches the pool to the configured embedder and creates a
+// job that re-embeds every memory in every namespace. If a reembed job is
+// already pending, its id is returned instead of creating another.
+func (q *Queue) EnqueueReembed() (string, error) {
+	active, err := q.store.ActiveJob(store.JobTypeReembed)
+	if err != nil {
+		return "", err
+	}
+	if active != nil {
+		return active.ID, nil
+	}
+
+	if err := q.goldie.BeginReembed(); err != nil {
+		return "", fmt.Errorf("preparing reembed: %w", err)
+	}
+
+	id := uuid.New().String()
+	params, err := json.Marshal(ReembedParams{Target: q.goldie.EmbeddingInfo()})
+	if err != nil {
+		return "", fmt.Errorf("marshaling params: %w", err)
+	}
+	if err := q.store.CreateJob(id, store.JobTypeReembed, store.AllNamespaces, string(params)); err != nil {
+		return "", fmt.Errorf("creating job: %w", err)
+	}
+	return id, nil
+}
+
+// EnqueueFindDuplicates creates a job that clusters near-duplicate memories
+// in a namespace
+func (q *Queue) EnqueueFindDuplicates(namespace string, p FindDuplicatesParams) (string, error) {
+	id := uuid.New().String()
+
+	params, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("marshaling params: %w", err)
+	}
+	if err := q.store.CreateJob(id, store.JobTypeFindDups, namespace, string(params)); err != nil {
+		return "", fmt.Errorf("creating job: %w", err)
+	}
+	return id, nil
+}
+
 // worker is the background goroutine that processes jobs
 func (q *Queue) worker() {
 	defer q.wg.Done()
@@ -133,17 +243,80 @@ func (q *Queue) worker() {
 
 	ticker := time.NewTicker(q.polling)
 	defer ticker.Stop()
-
+	staleTicker := time.NewTicker(staleJobAfter / 2)
+	defer staleTicker.Stop()
+	purgeTicker := time.NewTicker(trashPurgeEvery)
+	defer purgeTicker.Stop()
+	expiryTicker := time.NewTicker(expirySweepEvery)
+	defer expiryTicker.Stop()
+
+	q.requeueStaleJobs()
+	q.sweepExpired()
+	q.purgeTrash()
 	for {
 		select {
 		case <-q.stop:
 			return
+		case <-staleTicker.C:
+			q.requeueStaleJobs()
+		case <-expiryTicker.C:
+			q.sweepExpired()
+		case <-purgeTicker.C:
+			q.purgeTrash()
 		case <-ticker.C:
 			q.processNextJob()
 		}
 	}
 }
 
+// requeueStaleJobs resumes reembed jobs abandoned by a killed or stopped
+// process. Other job types don't checkpoint, so a slow one looks the same as
+// an abandoned one and is left alone.
+func (q *Queue) requeueStaleJobs() {
+	n, err := q.store.RequeueStaleJobs(store.JobTypeReembed, staleJobAfter)
+	if err != nil {
+		q.logger.Printf("Error requeueing stale jobs: %v", err)
+		return
+	}
+	if n > 0 {
+		q.logger.Printf("Requeued %d interrupted job(s)", n)
+	}
+}
+
+// sweepExpired archives or deletes expired memories per their type's policy
+func (q *Queue) sweepExpired() {
+	res, err := q.goldie.SweepExpired()
+	if err != nil {
+		q.logger.Printf("Error sweeping expired memories: %v", err)
+		return
+	}
+	if res.Archived > 0 || res.Deleted > 0 {
+		q.logger.Printf("Expired memories: %d archived, %d deleted", res.Archived, res.Deleted)
+	}
+}
+
+// purgeTrash permanently deletes memories past the trash retention
+func (q *Queue) purgeTrash() {
+	n, err := q.goldie.PurgeTrash()
+	if err != nil {
+		q.logger.Printf("Error purging trash: %v", err)
+		return
+	}
+	if n > 0 {
+		q.logger.Printf("Purged %d memory(ies) from the trash", n)
+	}
+}
+
+// stopping reports whether Stop has been called
+func (q *Queue) stopping() bool {
+	select {
+	case <-q.stop:
+		return true
+	default:
+		return false
+	}
+}
+
 // processNextJob fetches and processes the next pending job
 func (q *Queue) processNextJob() {
 	job, err := q.store.GetNextPendingJob()
@@ -162,6 +335,10 @@ func (q *Queue) processNextJob() {
 		q.processIndexFile(job)
 	case store.JobTypeIndexDir:
 		q.processIndexDirectory(job)
+	case store.JobTypeReembed:
+		q.processReembed(job)
+	case store.JobTypeFindDups:
+		q.processFindDuplicates(job)
 	default:
 		q.logger.Printf("Unknown job type: %s", job.Type)
 		q.store.UpdateJobError(job.ID, fmt.Sprintf("unknown job type: %s", job.Type))
@@ -186,8 +363,13 @@ func (q *Queue) processIndexFile(job *store.Job) {
 	}
 	q.logger.Printf("Job %s: progress updated, calling IndexFile", job.ID)
 
-	// Index the file as a memory
-	result, err := q.goldie.IndexFile(params.Path, params.Agent)
+	// Index the file as a memory in the job's namespace
+	g, err := q.goldie.WithNamespace(job.Namespace)
+	if err != nil {
+		q.store.UpdateJobError(job.ID, err.Error())
+		return
+	}
+	result, err := g.IndexFile(params.Path, params.Agent)
 	if err != nil {
 		q.logger.Printf("Job %s: indexing failed: %v", job.ID, err)
 		q.store.UpdateJobError(job.ID, fmt.Sprintf("indexing failed: %v", err))
@@ -245,7 +427,7 @@ func (q *Queue) processIndexDirectory(job *store.Job) {
 	// Create a child job for each file
 	childJobIDs := make([]string, 0, fileCount)
 	for _, file := range scanResult.Files {
-		childID, err := q.EnqueueIndexFileWithParent(file, params.Agent, job.ID)
+		childID, err := q.EnqueueIndexFileWithParent(job.Namespace, file, params.Agent, job.ID)
 		if err != nil {
 			q.logger.Printf("Job %s: failed to create child job for %s: %v", job.ID, file, err)
 			continue
@@ -274,3 +456,147 @@ func (q *Queue) processIndexDirectory(job *store.Job) {
 
 	q.logger.Printf("Job %s: completed - created %d child jobs for indexing", job.ID, len(childJobIDs))
 }
+
+// processReembed handles a reembed job. Memories are walked in id order and
+// the last finished id is checkpointed after each one, so a job interrupted by
+// shutdown or a crash resumes where it stopped instead of starting over.
+func (q *Queue) processReembed(job *store.Job) {
+	q.logger.Printf("Job %s: processReembed started (checkpoint=%q, progress=%d)", job.ID, job.Checkpoint, job.Progress)
+
+	var params ReembedParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		q.logger.Printf("Job %s: invalid params: %v", job.ID, err)
+		q.store.UpdateJobError(job.ID, fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+	if current := q.goldie.EmbeddingInfo(); params.Target != current {
+		msg := fmt.Sprintf("job targets %s but the configured embedder is %s", params.Target, current)
+		q.logger.Printf("Job %s: %s", job.ID, msg)
+		q.store.UpdateJobError(job.ID, msg)
+		return
+	}
+	// Idempotent: a resumed job finds the new fingerprint already recorded.
+	if err := q.goldie.BeginReembed(); err != nil {
+		q.logger.Printf("Job %s: preparing reembed failed: %v", job.ID, err)
+		q.store.UpdateJobError(job.ID, fmt.Sprintf("preparing reembed failed: %v", err))
+		return
+	}
+
+	total, err := q.store.CountAllMemories()
+	if err != nil {
+		q.store.UpdateJobError(job.ID, fmt.Sprintf("counting memories failed: %v", err))
+		return
+	}
+	cursor, done := job.Checkpoint, job.Progress
+	q.store.UpdateJobCheckpoint(job.ID, cursor, done, max(total, done))
+
+	for {
+		ids, err := q.store.ListMemoryIDsAfter(cursor, reembedBatch)
+		if err != nil {
+			q.logger.Printf("Job %s: listing memories failed: %v", job.ID, err)
+			q.store.UpdateJobError(job.ID, fmt.Sprintf("listing memories failed: %v", err))
+			return
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			if q.stopping() {
+				// Left in processing; requeued as stale on the next start.
+				q.logger.Printf("Job %s: stopping at %d/%d, will resume", job.ID, done, total)
+				return
+			}
+			if err := q.goldie.ReembedMemory(id); err != nil {
+				q.logger.Printf("Job %s: %v", job.ID, err)
+				q.store.UpdateJobError(job.ID, err.Error())
+				return
+			}
+			cursor = id
+			done++
+			if err := q.store.UpdateJobCheckpoint(job.ID, cursor, done, max(total, done)); err != nil {
+				q.logger.Printf("Job %s: failed to checkpoint: %v", job.ID, err)
+			}
+		}
+	}
+
+	if err := q.goldie.FinishReembed(); err != nil {
+		q.logger.Printf("Job %s: %v", job.ID, err)
+		q.store.UpdateJobError(job.ID, err.Error())
+		return
+	}
+
+	resultJSON, err := json.Marshal(map[string]any{
+		"reembedded": done,
+		"backend":    params.Target.Backend,
+		"model":      params.Target.Model,
+		"dimensions": params.Target.Dimensions,
+	})
+	if err != nil {
+		q.store.UpdateJobError(job.ID, fmt.Sprintf("failed to marshal result: %v", err))
+		return
+	}
+	if err := q.store.UpdateJobResult(job.ID, string(resultJSON)); err != nil {
+		q.logger.Printf("Job %s: failed to update result: %v", job.ID, err)
+	}
+	q.logger.Printf("Job %s: completed - re-embedded %d memories with %s", job.ID, done, params.Target)
+}
+
+// processFindDuplicates handles a find_duplicates job. Progress counts the
+// memories compared so far; the clusters go in the job's result.
+func (q *Queue) processFindDuplicates(job *store.Job) {
+	q.logger.Printf("Job %s: processFindDuplicates started", job.ID)
+
+	var params FindDuplicatesParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		q.logger.Printf("Job %s: invalid params: %v", job.ID, err)
+		q.store.UpdateJobError(job.ID, fmt.Sprintf("invalid params: %v", err))
+		return
+	}
+	g, err := q.goldie.WithNamespace(job.Namespace)
+	if err != nil {
+		q.store.UpdateJobError(job.ID, err.Error())
+		return
+	}
+
+	clusters, scanned, err := g.FindDuplicateClusters(goldie.DuplicateScan{
+		Threshold: params.Threshold,
+		Filter: store.MemoryFilter{
+			Type:    params.Type,
+			Agent:   params.Agent,
+			Source:  params.Source,
+			TagsAny: params.TagsAny,
+			TagsAll: params.TagsAll,
+		},
+		Progress: func(done, total int) {
+			if done%duplicateProgressEvery == 0 || done == total {
+				q.store.UpdateJobProgress(job.ID, done, total)
+			}
+		},
+	})
+	if err != nil {
+		q.logger.Printf("Job %s: finding duplicates failed: %v", job.ID, err)
+		q.store.UpdateJobError(job.ID, fmt.Sprintf("finding duplicates failed: %v", err))
+		return
+	}
+
+	result := FindDuplicatesResult{Scanned: scanned, Clusters: make([]DuplicateCluster, len(clusters))}
+	for i, c := range clusters {
+		out := DuplicateCluster{Number: i + 1, Score: c.Score}
+		for _, m := range c.Memories {
+			out.Memories = append(out.Memories, DuplicateMember{ID: m.ID, Name: m.Name, Type: m.Type, UpdatedAt: m.UpdatedAt})
+		}
+		for _, p := range c.Pairs {
+			out.Pairs = append(out.Pairs, DuplicatePairRef{A: p.A, B: p.B, Similarity: p.Similarity})
+		}
+		result.Clusters[i] = out
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		q.store.UpdateJobError(job.ID, fmt.Sprintf("failed to marshal result: %v", err))
+		return
+	}
+	if err := q.store.UpdateJobResult(job.ID, string(resultJSON)); err != nil {
+		q.logger.Printf("Job %s: failed to update result: %v", job.ID, err)
+	}
+	q.logger.Printf("Job %s: completed - %d duplicate clusters among %d memories", job.ID, len(clusters), scanned)
+}
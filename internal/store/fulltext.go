@@ -0,0 +1,228 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrFullTextUnavailable is returned by keyword search when the SQLite driver
+// was compiled without FTS5 (build with -tags sqlite_fts5).
+var ErrFullTextUnavailable = errors.New("full-text search unavailable: binary built without the sqlite_fts5 tag")
+
+// initFullTextSchema creates the FTS5 tables that mirror memory text and chunk
+// content. When the driver lacks FTS5 the store keeps working vector-only.
+// The index is derived data whose availability depends on build tags, so it
+// lives outside the versioned migrations and is rebuilt whenever it drifts.
+func (s *Store) initFullTextSchema() error {
+	var existing int
+	if err := s.db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE name IN ('memories_fts', 'memory_chunks_fts')",
+	).Scan(&existing); err != nil {
+		return fmt.Errorf("checking fts tables: %w", err)
+	}
+
+	_, err := s.db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts5(
+			name, description, body, memory_id UNINDEXED,
+			tokenize = 'porter unicode61'
+		)
+	`)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return nil
+		}
+		return fmt.Errorf("creating memories_fts table: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS memory_chunks_fts USING fts5(
+			content, chunk_id UNINDEXED, memory_id UNINDEXED,
+			tokenize = 'porter unicode61'
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("creating memory_chunks_fts table: %w", err)
+	}
+	s.fts = true
+
+	// Databases created before the FTS tables existed need a one-time backfill,
+	// and ones written by a binary built without FTS5 need a resync.
+	stale := existing < 2
+	if !stale {
+		var memories, indexed int
+		if err := s.db.QueryRow(
+			"SELECT (SELECT COUNT(*) FROM memories), (SELECT COUNT(*) FROM memories_fts)",
+		).Scan(&memories, &indexed); err != nil {
+			return fmt.Errorf("checking fts row count: %w", err)
+		}
+		stale = memories != indexed
+	}
+	if stale {
+		return s.rebuildFullText()
+	}
+	return nil
+}
+
+// rebuildFullText repopulates both FTS tables from memories and memory_chunks.
+func (s *Store) rebuildFullText() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		"DELETE FROM memories_fts",
+		"DELETE FROM memory_chunks_fts",
+		`INSERT INTO memories_fts (name, description, body, memory_id)
+			SELECT name, COALESCE(description, ''), body, id FROM memories`,
+		`INSERT INTO memory_chunks_fts (content, chunk_id, memory_id)
+			SELECT content, id, memory_id FROM memory_chunks`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuilding full-text index: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// FullTextEnabled reports whether keyword (BM25) search is available.
+func (s *Store) FullTextEnabled() bool {
+	return s.fts
+}
+
+// SearchMemoriesKeyword runs a BM25 search over memory name, description and
+// body and returns up to `limit` memories, best first. Score carries the BM25
+// relevance (higher is better); Distance is unset. The excerpt is the
+// best-matching chunk, or the first chunk if no single chunk matches.
+func (s *Store) SearchMemoriesKeyword(query string, limit int, filter MemoryFilter) ([]MemorySearchResult, error) {
+	if !s.fts {
+		return nil, ErrFullTextUnavailable
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	// Name and description hits are weighted above body hits.
+	q := `
+		SELECT bm25(memories_fts, 10.0, 5.0, 1.0) AS rank, ` + memoryColumns + `
+		FROM memories_fts f
+		JOIN memories m ON m.id = f.memory_id
+		WHERE memories_fts MATCH ?`
+	clause, fargs := filter.where("m.")
+	q += " AND " + clause + " ORDER BY rank LIMIT ?"
+	args := append([]any{match}, fargs...)
+	args = append(args, limit)
+
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("keyword searching memories: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MemorySearchResult
+	for rows.Next() {
+		var rank float64
+		m, err := scanMemoryRow(rows, &rank)
+		if err != nil {
+			return nil, fmt.Errorf("scanning keyword search row: %w", err)
+		}
+		out = append(out, MemorySearchResult{
+			Memory: *m,
+			Score:  float32(-rank),
+			Metric: MetricBM25,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range out {
+		if err := s.keywordExcerpt(&out[i], match); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// keywordExcerpt sets a keyword result's excerpt to its best matching chunk,
+// or the first chunk if none matches on its own, and notes the chunks that
+// match with their BM25 relevance.
+func (s *Store) keywordExcerpt(r *MemorySearchResult, match string) error {
+	rows, err := s.db.Query(`
+		SELECT c.chunk_index, memory_chunks_fts.content, c.heading_path, rank
+		FROM memory_chunks_fts
+		JOIN memory_chunks c ON c.id = memory_chunks_fts.chunk_id
+		WHERE memory_chunks_fts MATCH ? AND memory_chunks_fts.memory_id = ?
+		ORDER BY rank
+	`, match, r.Memory.ID)
+	if err != nil {
+		return fmt.Errorf("querying keyword excerpt: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c ChunkMatch
+		var rank float64
+		if err := rows.Scan(&c.Index, &c.Content, &c.HeadingPath, &rank); err != nil {
+			return fmt.Errorf("scanning keyword excerpt: %w", err)
+		}
+		if r.Chunks == nil {
+			r.Excerpt, r.ChunkIndex, r.HeadingPath = c.Content, c.Index, c.HeadingPath
+		}
+		c.Score, c.Metric = float32(-rank), MetricBM25
+		r.Chunks = append(r.Chunks, c)
+	}
+	if err := rows.Err(); err != nil || r.Chunks != nil {
+		return err
+	}
+
+	err = s.db.QueryRow(
+		"SELECT chunk_index, content, heading_path FROM memory_chunks WHERE memory_id = ? ORDER BY chunk_index LIMIT 1",
+		r.Memory.ID,
+	).Scan(&r.ChunkIndex, &r.Excerpt, &r.HeadingPath)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("querying first chunk: %w", err)
+	}
+	return nil
+}
+
+// indexMemoryTextTx refreshes the memories_fts row for a memory. A memory
+// that no longer exists is simply removed from the index.
+func (s *Store) indexMemoryTextTx(tx *sql.Tx, memoryID string) error {
+	if !s.fts {
+		return nil
+	}
+	if _, err := tx.Exec("DELETE FROM memories_fts WHERE memory_id = ?", memoryID); err != nil {
+		return fmt.Errorf("deleting fts row: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO memories_fts (name, description, body, memory_id)
+		SELECT name, COALESCE(description, ''), body, id FROM memories WHERE id = ?
+	`, memoryID); err != nil {
+		return fmt.Errorf("inserting fts row: %w", err)
+	}
+	return nil
+}
+
+// ftsQuery turns free text into an FTS5 query that ORs every term as a quoted
+// phrase, so punctuation in identifiers (ERR_CONN_RESET, pkg.Func, PROJ-123)
+// never trips the FTS5 query parser.
+func ftsQuery(text string) string {
+	var terms []string
+	for _, f := range strings.Fields(text) {
+		f = strings.ReplaceAll(f, `"`, "")
+		if f == "" {
+			continue
+		}
+		terms = append(terms, `"`+f+`"`)
+	}
+	return strings.Join(terms, " OR ")
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MemoryLink is a typed, directed edge between two memories, read as
+// "FromID <type> ToID" (e.g. a supersedes b).
+type MemoryLink struct {
+	FromID    string    `json:"from_id"`
+	ToID      string    `json:"to_id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddMemoryLink records an edge. Reports false if it already existed.
+func (s *Store) AddMemoryLink(fromID, toID, linkType string) (bool, error) {
+	res, err := s.db.Exec(
+		"INSERT OR IGNORE INTO memory_links (from_id, to_id, type) VALUES (?, ?, ?)",
+		fromID, toID, linkType,
+	)
+	if err != nil {
+		return false, fmt.Errorf("adding link: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RemoveMemoryLinks deletes the edges from fromID to toID, only those of
+// linkType unless it is empty, and returns how many were removed.
+func (s *Store) RemoveMemoryLinks(fromID, toID, linkType string) (int, error) {
+	query := "DELETE FROM memory_links WHERE from_id = ? AND to_id = ?"
+	args := []any{fromID, toID}
+	if linkType != "" {
+		query += " AND type = ?"
+		args = append(args, linkType)
+	}
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("removing links: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// ListMemoryLinks returns every edge touching any of the given memories, in
+// either direction, oldest first.
+func (s *Store) ListMemoryLinks(ids []string) ([]MemoryLink, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]any, 0, 2*len(ids))
+	for range 2 {
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	rows, err := s.db.Query(fmt.Sprintf(
+		"SELECT from_id, to_id, type, created_at FROM memory_links WHERE from_id IN (%s) OR to_id IN (%s) ORDER BY created_at, from_id, to_id",
+		placeholders, placeholders,
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []MemoryLink
+	for rows.Next() {
+		var l MemoryLink
+		if err := rows.Scan(&l.FromID, &l.ToID, &l.Type, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
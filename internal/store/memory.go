@@ -14,38 +14,95 @@ import (
 // ErrMemoryNameExists is returned by AddMemory when a memory with the given name already exists.
 var ErrMemoryNameExists = errors.New("memory with that name already exists")
 
-// Memory is the canonical entity stored in the index. Each memory has a unique
-// human-readable name and may be backed by one or more embedded chunks for
-// semantic recall.
+const (
+	// DefaultNamespace holds memories written without an explicit namespace,
+	// including every memory from before namespaces existed.
+	DefaultNamespace = "default"
+	// AllNamespaces as a filter namespace matches every namespace.
+	AllNamespaces = "*"
+)
+
+// Memory is the canonical entity stored in the index. Each memory has a
+// human-readable name, unique within its namespace, and may be backed by one
+// or more embedded chunks for semantic recall.
 type Memory struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Type        string    `json:"type"`
-	Description string    `json:"description,omitempty"`
-	Body        string    `json:"body"`
-	Agent       string    `json:"agent,omitempty"`
-	Source      string    `json:"source,omitempty"`
-	Checksum    string    `json:"checksum,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-}
-
-// MemoryFilter narrows memory queries. Empty fields are ignored.
+	ID          string     `json:"id"`
+	Namespace   string     `json:"namespace"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Description string     `json:"description,omitempty"`
+	Body        string     `json:"body"`
+	Agent       string     `json:"agent,omitempty"`
+	Source      string     `json:"source,omitempty"`
+	Checksum    string     `json:"checksum,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // set while the memory is in the trash
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // hidden from queries from then on
+	DueAt       *time.Time `json:"due_at,omitempty"`     // todo and reminder memories only
+	Status      string     `json:"status,omitempty"`     // one of the MemoryStatus* values, for todos and reminders
+	Importance  float32    `json:"importance,omitempty"` // 0 to 1, boosts recall ranking
+	Pinned      bool       `json:"pinned,omitempty"`     // always loaded by bootstrap
+	// RecallCount and LastRecalledAt track how often and when the memory was
+	// last returned by recall.
+	RecallCount    int        `json:"recall_count,omitempty"`
+	LastRecalledAt *time.Time `json:"last_recalled_at,omitempty"`
+}
+
+// Statuses of todo and reminder memories.
+const (
+	MemoryStatusOpen    = "open"
+	MemoryStatusDone    = "done"
+	MemoryStatusSnoozed = "snoozed"
+)
+
+// MemoryFilter narrows memory queries. Empty fields are ignored, as is a
+// Namespace of AllNamespaces. Queries match live, unexpired memories, or
+// only those in the trash when Trashed is set (expired or not).
 type MemoryFilter struct {
-	Name   string
-	Type   string
-	Agent  string
-	Source string
+	Trashed        bool
+	IncludeExpired bool // also match live memories past their expires_at
+	Namespace      string
+	Name           string
+	Type           string
+	Agent          string
+	Source         string
+	TagsAny        []string // memory has at least one of these tags
+	TagsAll        []string // memory has every one of these tags
+	Status         string
+	Pinned         bool      // only pinned memories
+	DueBefore      time.Time // due_at at or before this time
+	DueAfter       time.Time // due_at at or after this time
 }
 
-// IsEmpty reports whether the filter has no constraints set.
+// IsEmpty reports whether the filter has no constraints set. Trashed and
+// IncludeExpired don't count as constraints.
 func (f MemoryFilter) IsEmpty() bool {
-	return f.Name == "" && f.Type == "" && f.Agent == "" && f.Source == ""
+	return (f.Namespace == "" || f.Namespace == AllNamespaces) &&
+		f.Name == "" && f.Type == "" && f.Agent == "" && f.Source == "" &&
+		len(normalizeFilterTags(f.TagsAny)) == 0 && len(normalizeFilterTags(f.TagsAll)) == 0 &&
+		f.Status == "" && f.DueBefore.IsZero() && f.DueAfter.IsZero()
 }
 
+// where returns the filter as SQL conditions on memories aliased by prefix.
+// It always includes the trash condition, so it is never empty.
 func (f MemoryFilter) where(prefix string) (string, []any) {
 	var clauses []string
 	var args []any
+	switch {
+	case f.Trashed:
+		clauses = append(clauses, prefix+"deleted_at IS NOT NULL")
+	case f.IncludeExpired:
+		clauses = append(clauses, prefix+"deleted_at IS NULL")
+	default:
+		clauses = append(clauses, prefix+"deleted_at IS NULL",
+			"("+prefix+"expires_at IS NULL OR "+prefix+"expires_at > CURRENT_TIMESTAMP)")
+	}
+	if f.Namespace != "" && f.Namespace != AllNamespaces {
+		clauses = append(clauses, prefix+"namespace = ?")
+		args = append(args, f.Namespace)
+	}
 	if f.Name != "" {
 		clauses = append(clauses, prefix+"name = ?")
 		args = append(args, f.Name)
@@ -62,9 +119,60 @@ func (f MemoryFilter) where(prefix string) (string, []any) {
 		clauses = append(clauses, prefix+"source = ?")
 		args = append(args, f.Source)
 	}
+	if f.Status != "" {
+		clauses = append(clauses, prefix+"status = ?")
+		args = append(args, f.Status)
+	}
+	if f.Pinned {
+		clauses = append(clauses, prefix+"pinned = 1")
+	}
+	if !f.DueBefore.IsZero() {
+		clauses = append(clauses, prefix+"due_at <= ?")
+		args = append(args, nullableTime(f.DueBefore))
+	}
+	if !f.DueAfter.IsZero() {
+		clauses = append(clauses, prefix+"due_at >= ?")
+		args = append(args, nullableTime(f.DueAfter))
+	}
+	tagClauses, tagArgs := tagsWhere(prefix+"id", f.TagsAny, f.TagsAll)
+	clauses = append(clauses, tagClauses...)
+	args = append(args, tagArgs...)
 	return strings.Join(clauses, " AND "), args
 }
 
+// memoryColumns selects a full Memory from `memories m` for scanMemoryRow.
+const memoryColumns = `m.id, m.namespace, m.name, m.type, m.description, m.body, m.agent, m.source, m.checksum,
+	m.created_at, m.updated_at, m.deleted_at, m.expires_at, m.due_at, m.status,
+	m.importance, m.recall_count, m.last_recalled_at, m.pinned,
+	(SELECT group_concat(tag, ',') FROM memory_tags WHERE memory_id = m.id)`
+
+// MemoryChunk is one stored chunk of a memory body. Embedding is only
+// populated when explicitly requested.
+type MemoryChunk struct {
+	Index       int       `json:"index"`
+	Content     string    `json:"content"`
+	HeadingPath string    `json:"heading_path,omitempty"`
+	Embedding   []float32 `json:"embedding,omitempty"`
+}
+
+// ChunkText is a chunk of a memory body to store. HeadingPath names the
+// Markdown sections it falls under, outermost first, joined by
+// HeadingPathSeparator; it is empty for plain text.
+type ChunkText struct {
+	Content     string
+	HeadingPath string
+}
+
+// HeadingPathSeparator joins the headings of a chunk's HeadingPath.
+const HeadingPathSeparator = " > "
+
+// Metrics a search result's Distance or Score is measured in.
+const (
+	MetricCosine = "cosine" // vector search: Distance is 1 - cosine similarity
+	MetricBM25   = "bm25"   // keyword search: Score is the BM25 relevance
+	MetricRRF    = "rrf"    // hybrid search: Score is the reciprocal rank fusion score
+)
+
 // MemorySearchResult is a memory returned by semantic search, with the matched
 // chunk excerpt and the underlying vector distance/score.
 type MemorySearchResult struct {
@@ -72,66 +180,51 @@ type MemorySearchResult struct {
 	Excerpt  string  `json:"excerpt"`
 	Score    float32 `json:"score"`
 	Distance float32 `json:"distance"`
+	Metric   string  `json:"metric"` // MetricCosine, MetricBM25 or MetricRRF
+	// ChunkIndex is the chunk_index of the Excerpt's best matching chunk,
+	// and HeadingPath its heading path; Chunks lists every chunk of the
+	// memory that matched, best first.
+	ChunkIndex  int          `json:"chunk_index"`
+	HeadingPath string       `json:"heading_path,omitempty"`
+	Chunks      []ChunkMatch `json:"chunks,omitempty"`
+	// ExcerptChunks lists the chunks a widened excerpt spans, in order.
+	ExcerptChunks []int `json:"excerpt_chunks,omitempty"`
+	// Boost is what ranking boosts added to the normalized score, if any.
+	Boost float32 `json:"boost,omitempty"`
+	// Embedding is the matched chunk's vector; vector search only.
+	Embedding []float32 `json:"-"`
 }
 
-func (s *Store) initMemorySchema() error {
-	_, err := s.db.Exec(`
-		CREATE TABLE IF NOT EXISTS memories (
-			id TEXT PRIMARY KEY,
-			name TEXT NOT NULL UNIQUE,
-			type TEXT NOT NULL,
-			description TEXT,
-			body TEXT NOT NULL,
-			agent TEXT,
-			source TEXT,
-			checksum TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("creating memories table: %w", err)
-	}
-
-	_, err = s.db.Exec(`
-		CREATE TABLE IF NOT EXISTS memory_chunks (
-			id TEXT PRIMARY KEY,
-			memory_id TEXT NOT NULL,
-			chunk_index INTEGER NOT NULL,
-			content TEXT NOT NULL,
-			UNIQUE(memory_id, chunk_index)
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("creating memory_chunks table: %w", err)
-	}
-
-	_, err = s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_memory_chunks_memory_id ON memory_chunks(memory_id)`)
-	if err != nil {
-		return fmt.Errorf("creating memory_chunks index: %w", err)
-	}
+// ChunkMatch is one matching chunk of a search result. Its Metric is the
+// search's own, MetricCosine or MetricBM25, even once the result is fused.
+type ChunkMatch struct {
+	Index       int     `json:"index"`
+	Content     string  `json:"content"`
+	HeadingPath string  `json:"heading_path,omitempty"`
+	Score       float32 `json:"score"`
+	Metric      string  `json:"metric"`
+}
 
-	query := fmt.Sprintf(`
-		CREATE VIRTUAL TABLE IF NOT EXISTS memories_vec USING vec0(
-			id TEXT PRIMARY KEY,
-			embedding FLOAT[%d]
-		)
-	`, s.dimensions)
-	if _, err := s.db.Exec(query); err != nil {
-		return fmt.Errorf("creating memories_vec table: %w", err)
+// MatchedIndexes returns the chunk indices of the result's matching chunks,
+// best first.
+func (r MemorySearchResult) MatchedIndexes() []int {
+	out := make([]int, len(r.Chunks))
+	for i, c := range r.Chunks {
+		out[i] = c.Index
 	}
-
-	return nil
+	return out
 }
 
 // AddMemory inserts a memory and its chunks (with embeddings) atomically.
 // Returns ErrMemoryNameExists if a memory with the same name is already stored.
-// chunkContents and chunkEmbeddings must have equal length.
-func (s *Store) AddMemory(m *Memory, chunkContents []string, chunkEmbeddings [][]float32) error {
-	if len(chunkContents) != len(chunkEmbeddings) {
-		return fmt.Errorf("chunk contents (%d) and embeddings (%d) length mismatch", len(chunkContents), len(chunkEmbeddings))
-	}
-	if len(chunkContents) == 0 {
+// chunks and chunkEmbeddings must have equal length. An empty
+// Namespace means DefaultNamespace. Non-zero CreatedAt/UpdatedAt are kept
+// (imports); zero values default to now.
+func (s *Store) AddMemory(m *Memory, chunks []ChunkText, chunkEmbeddings [][]float32) error {
+	if len(chunks) != len(chunkEmbeddings) {
+		return fmt.Errorf("chunk contents (%d) and embeddings (%d) length mismatch", len(chunks), len(chunkEmbeddings))
+	}
+	if len(chunks) == 0 {
 		return fmt.Errorf("at least one chunk is required")
 	}
 
@@ -144,31 +237,100 @@ func (s *Store) AddMemory(m *Memory, chunkContents []string, chunkEmbeddings [][
 	if m.ID == "" {
 		m.ID = uuid.New().String()
 	}
+	if m.Namespace == "" {
+		m.Namespace = DefaultNamespace
+	}
 
 	_, err = tx.Exec(`
-		INSERT INTO memories (id, name, type, description, body, agent, source, checksum)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, m.ID, m.Name, m.Type, nullableString(m.Description), m.Body,
-		nullableString(m.Agent), nullableString(m.Source), nullableString(m.Checksum))
+		INSERT INTO memories (id, namespace, name, type, description, body, agent, source, checksum, expires_at, due_at, status,
+			importance, recall_count, last_recalled_at, pinned, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), COALESCE(?, CURRENT_TIMESTAMP))
+	`, m.ID, m.Namespace, m.Name, m.Type, nullableString(m.Description), m.Body,
+		nullableString(m.Agent), nullableString(m.Source), nullableString(m.Checksum),
+		nullableTimePtr(m.ExpiresAt), nullableTimePtr(m.DueAt), nullableString(m.Status),
+		m.Importance, m.RecallCount, nullableTimePtr(m.LastRecalledAt), m.Pinned,
+		nullableTime(m.CreatedAt), nullableTime(m.UpdatedAt))
 	if err != nil {
 		if isUniqueConstraintErr(err) {
 			return ErrMemoryNameExists
 		}
 		return fmt.Errorf("inserting memory: %w", err)
 	}
+	if err := setTagsTx(tx, m.ID, m.Tags); err != nil {
+		return err
+	}
+	if err := s.indexMemoryTextTx(tx, m.ID); err != nil {
+		return err
+	}
 
-	if err := insertChunks(tx, m.ID, chunkContents, chunkEmbeddings); err != nil {
+	if err := s.insertChunks(tx, m.ID, chunks, chunkEmbeddings); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
+// ReplaceMemory overwrites every field of the memory with id m.ID except its
+// namespace, including its tags and timestamps, and replaces its chunks, in
+// one transaction. Zero timestamps default to now. A memory in the trash is
+// taken out of it. The replaced state is first saved as a MemoryRevision.
+func (s *Store) ReplaceMemory(m *Memory, chunks []ChunkText, chunkEmbeddings [][]float32) error {
+	if len(chunks) != len(chunkEmbeddings) {
+		return fmt.Errorf("chunk contents (%d) and embeddings (%d) length mismatch", len(chunks), len(chunkEmbeddings))
+	}
+	if len(chunks) == 0 {
+		return fmt.Errorf("at least one chunk is required")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveRevisionTx(tx, m.ID); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`
+		UPDATE memories SET
+			name = ?, type = ?, description = ?, body = ?, agent = ?, source = ?, checksum = ?, expires_at = ?, due_at = ?, status = ?,
+			importance = ?, recall_count = ?, last_recalled_at = ?, pinned = ?,
+			created_at = COALESCE(?, CURRENT_TIMESTAMP), updated_at = COALESCE(?, CURRENT_TIMESTAMP),
+			deleted_at = NULL
+		WHERE id = ?
+	`, m.Name, m.Type, nullableString(m.Description), m.Body,
+		nullableString(m.Agent), nullableString(m.Source), nullableString(m.Checksum), nullableTimePtr(m.ExpiresAt),
+		nullableTimePtr(m.DueAt), nullableString(m.Status), m.Importance, m.RecallCount, nullableTimePtr(m.LastRecalledAt),
+		m.Pinned, nullableTime(m.CreatedAt), nullableTime(m.UpdatedAt), m.ID)
+	if err != nil {
+		if isUniqueConstraintErr(err) {
+			return ErrMemoryNameExists
+		}
+		return fmt.Errorf("replacing memory: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if err := setTagsTx(tx, m.ID, m.Tags); err != nil {
+		return err
+	}
+	if err := s.indexMemoryTextTx(tx, m.ID); err != nil {
+		return err
+	}
+	if err := s.deleteChunksTx(tx, m.ID); err != nil {
+		return err
+	}
+	if err := s.insertChunks(tx, m.ID, chunks, chunkEmbeddings); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // ReplaceMemoryChunks deletes all existing chunks for the given memory and
 // inserts the provided chunks/embeddings. Used when a memory's body is rewritten.
-func (s *Store) ReplaceMemoryChunks(memoryID string, chunkContents []string, chunkEmbeddings [][]float32) error {
-	if len(chunkContents) != len(chunkEmbeddings) {
-		return fmt.Errorf("chunk contents (%d) and embeddings (%d) length mismatch", len(chunkContents), len(chunkEmbeddings))
+func (s *Store) ReplaceMemoryChunks(memoryID string, chunks []ChunkText, chunkEmbeddings [][]float32) error {
+	if len(chunks) != len(chunkEmbeddings) {
+		return fmt.Errorf("chunk contents (%d) and embeddings (%d) length mismatch", len(chunks), len(chunkEmbeddings))
 	}
 
 	tx, err := s.db.Begin()
@@ -177,11 +339,11 @@ func (s *Store) ReplaceMemoryChunks(memoryID string, chunkContents []string, chu
 	}
 	defer tx.Rollback()
 
-	if err := deleteChunksTx(tx, memoryID); err != nil {
+	if err := s.deleteChunksTx(tx, memoryID); err != nil {
 		return err
 	}
-	if len(chunkContents) > 0 {
-		if err := insertChunks(tx, memoryID, chunkContents, chunkEmbeddings); err != nil {
+	if len(chunks) > 0 {
+		if err := s.insertChunks(tx, memoryID, chunks, chunkEmbeddings); err != nil {
 			return err
 		}
 	}
@@ -190,7 +352,8 @@ func (s *Store) ReplaceMemoryChunks(memoryID string, chunkContents []string, chu
 
 // UpdateMemoryFields updates non-chunk memory fields. Pass empty strings to
 // leave a field untouched; pass a single space to clear an optional field.
-// (Type is treated as required: empty string leaves it.)
+// (Type is treated as required: empty string leaves it.) Unless only tags
+// change, the previous state is first saved as a MemoryRevision.
 func (s *Store) UpdateMemoryFields(id string, fields MemoryUpdate) error {
 	var sets []string
 	var args []any
@@ -218,14 +381,47 @@ func (s *Store) UpdateMemoryFields(id string, fields MemoryUpdate) error {
 		sets = append(sets, "checksum = ?")
 		args = append(args, nullableString(*fields.Checksum))
 	}
-	if len(sets) == 0 {
+	// Revisions hold the columns above; the rest are bookkeeping.
+	revised := len(sets) > 0
+	if fields.ExpiresAt != nil {
+		sets = append(sets, "expires_at = ?")
+		args = append(args, nullableTime(*fields.ExpiresAt))
+	}
+	if fields.DueAt != nil {
+		sets = append(sets, "due_at = ?")
+		args = append(args, nullableTime(*fields.DueAt))
+	}
+	if fields.Status != nil {
+		sets = append(sets, "status = ?")
+		args = append(args, nullableString(*fields.Status))
+	}
+	if fields.Importance != nil {
+		sets = append(sets, "importance = ?")
+		args = append(args, *fields.Importance)
+	}
+	if fields.Pinned != nil {
+		sets = append(sets, "pinned = ?")
+		args = append(args, *fields.Pinned)
+	}
+	if len(sets) == 0 && fields.Tags == nil {
 		return nil
 	}
 	sets = append(sets, "updated_at = CURRENT_TIMESTAMP")
 	args = append(args, id)
 
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if revised {
+		if err := saveRevisionTx(tx, id); err != nil {
+			return err
+		}
+	}
 	query := fmt.Sprintf("UPDATE memories SET %s WHERE id = ?", strings.Join(sets, ", "))
-	res, err := s.db.Exec(query, args...)
+	res, err := tx.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("updating memory: %w", err)
 	}
@@ -233,7 +429,17 @@ func (s *Store) UpdateMemoryFields(id string, fields MemoryUpdate) error {
 	if n == 0 {
 		return sql.ErrNoRows
 	}
-	return nil
+	if fields.Tags != nil {
+		if err := setTagsTx(tx, id, *fields.Tags); err != nil {
+			return err
+		}
+	}
+	if fields.Description != nil || fields.Body != nil {
+		if err := s.indexMemoryTextTx(tx, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // MemoryUpdate carries optional patch values for UpdateMemoryFields. A nil
@@ -245,22 +451,46 @@ type MemoryUpdate struct {
 	Source      *string
 	Agent       *string
 	Checksum    *string
+	ExpiresAt   *time.Time // a zero time clears the expiry
+	DueAt       *time.Time // a zero time clears the due date
+	Status      *string
+	Importance  *float32
+	Pinned      *bool
+	Tags        *[]string // replaces the whole set; an empty slice clears it
+}
+
+// MarkRecalled bumps recall_count and sets last_recalled_at on the given
+// memories. It is bookkeeping: updated_at is left alone and no revision is
+// saved.
+func (s *Store) MarkRecalled(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	_, err := s.db.Exec(fmt.Sprintf(`UPDATE memories SET recall_count = recall_count + 1,
+		last_recalled_at = CURRENT_TIMESTAMP WHERE id IN (%s)`, placeholders(len(ids))), args...)
+	if err != nil {
+		return fmt.Errorf("recording recall: %w", err)
+	}
+	return nil
 }
 
 // GetMemory fetches a memory by id. Returns nil, nil if not found.
 func (s *Store) GetMemory(id string) (*Memory, error) {
-	return s.queryMemory("WHERE id = ?", id)
+	return s.queryMemory("WHERE m.id = ?", id)
 }
 
-// GetMemoryByName fetches a memory by its unique name. Returns nil, nil if not found.
-func (s *Store) GetMemoryByName(name string) (*Memory, error) {
-	return s.queryMemory("WHERE name = ?", name)
+// GetMemoryByName fetches a memory by its name within a namespace. Returns
+// nil, nil if not found.
+func (s *Store) GetMemoryByName(namespace, name string) (*Memory, error) {
+	return s.queryMemory("WHERE m.namespace = ? AND m.name = ?", namespace, name)
 }
 
 func (s *Store) queryMemory(where string, args ...any) (*Memory, error) {
-	row := s.db.QueryRow(`
-		SELECT id, name, type, description, body, agent, source, checksum, created_at, updated_at
-		FROM memories `+where, args...)
+	row := s.db.QueryRow("SELECT "+memoryColumns+" FROM memories m "+where, args...)
 	m, err := scanMemoryRow(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -271,18 +501,53 @@ func (s *Store) queryMemory(where string, args ...any) (*Memory, error) {
 	return m, nil
 }
 
-// ListMemories returns memories matching the filter, newest first.
+// GetMemoryChunks returns a memory's chunks in order. With withEmbeddings set,
+// each chunk carries its stored vector (nil if the chunk has none).
+func (s *Store) GetMemoryChunks(memoryID string, withEmbeddings bool) ([]MemoryChunk, error) {
+	query := "SELECT c.chunk_index, c.content, c.heading_path, NULL FROM memory_chunks c WHERE c.memory_id = ? ORDER BY c.chunk_index"
+	if withEmbeddings {
+		query = `
+			SELECT c.chunk_index, c.content, c.heading_path, vec_to_json(v.embedding)
+			FROM memory_chunks c
+			LEFT JOIN memories_vec v ON v.id = c.id
+			WHERE c.memory_id = ?
+			ORDER BY c.chunk_index`
+	}
+	rows, err := s.db.Query(query, memoryID)
+	if err != nil {
+		return nil, fmt.Errorf("listing chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MemoryChunk
+	for rows.Next() {
+		var (
+			c   MemoryChunk
+			vec sql.NullString
+		)
+		if err := rows.Scan(&c.Index, &c.Content, &c.HeadingPath, &vec); err != nil {
+			return nil, fmt.Errorf("scanning chunk: %w", err)
+		}
+		if vec.Valid {
+			if err := json.Unmarshal([]byte(vec.String), &c.Embedding); err != nil {
+				return nil, fmt.Errorf("decoding chunk %d embedding: %w", c.Index, err)
+			}
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// ListMemories returns memories matching the filter, newest first, or most
+// recently trashed first for the trash.
 func (s *Store) ListMemories(filter MemoryFilter, limit int) ([]Memory, error) {
-	query := `
-		SELECT id, name, type, description, body, agent, source, checksum, created_at, updated_at
-		FROM memories`
-	var args []any
-	if !filter.IsEmpty() {
-		clause, fargs := filter.where("")
-		query += " WHERE " + clause
-		args = append(args, fargs...)
+	clause, args := filter.where("m.")
+	query := "SELECT " + memoryColumns + " FROM memories m WHERE " + clause
+	if filter.Trashed {
+		query += " ORDER BY m.deleted_at DESC"
+	} else {
+		query += " ORDER BY m.updated_at DESC"
 	}
-	query += " ORDER BY updated_at DESC"
 	if limit > 0 {
 		query += " LIMIT ?"
 		args = append(args, limit)
@@ -305,22 +570,72 @@ func (s *Store) ListMemories(filter MemoryFilter, limit int) ([]Memory, error) {
 	return out, rows.Err()
 }
 
+// EachMemory calls fn with every memory matching the filter, ordered by
+// namespace and name, reading them from a cursor one row at a time so large
+// pools are never held in memory. It stops at the first error fn returns.
+func (s *Store) EachMemory(filter MemoryFilter, fn func(*Memory) error) error {
+	clause, args := filter.where("m.")
+	rows, err := s.db.Query(
+		"SELECT "+memoryColumns+" FROM memories m WHERE "+clause+" ORDER BY m.namespace, m.name",
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("listing memories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanMemoryRow(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // CountMemories returns the number of memories matching the filter.
 func (s *Store) CountMemories(filter MemoryFilter) (int, error) {
-	query := "SELECT COUNT(*) FROM memories"
-	var args []any
-	if !filter.IsEmpty() {
-		clause, fargs := filter.where("")
-		query += " WHERE " + clause
-		args = append(args, fargs...)
-	}
+	clause, args := filter.where("")
+	query := "SELECT COUNT(*) FROM memories WHERE " + clause
 	var n int
 	err := s.db.QueryRow(query, args...).Scan(&n)
 	return n, err
 }
 
+// CountAllMemories returns the number of memories in every namespace,
+// trashed and expired ones included: the pool ListMemoryIDsAfter walks.
+func (s *Store) CountAllMemories() (int, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM memories").Scan(&n)
+	return n, err
+}
+
+// ListMemoryIDsAfter returns up to limit memory ids greater than afterID in
+// id order. Used to walk the whole pool in resumable batches.
+func (s *Store) ListMemoryIDsAfter(afterID string, limit int) ([]string, error) {
+	rows, err := s.db.Query("SELECT id FROM memories WHERE id > ? ORDER BY id LIMIT ?", afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing memory ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning memory id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // SearchMemories runs filtered KNN over the chunk vector index and returns up
-// to `limit` distinct memories ordered by best chunk distance.
+// to `limit` distinct memories ordered by best chunk distance. Distance is
+// the cosine distance and Score the cosine similarity, 1 - Distance.
 func (s *Store) SearchMemories(embedding []float32, limit int, filter MemoryFilter) ([]MemorySearchResult, error) {
 	if limit <= 0 {
 		limit = 5
@@ -331,27 +646,26 @@ func (s *Store) SearchMemories(embedding []float32, limit int, filter MemoryFilt
 		return nil, fmt.Errorf("marshaling query embedding: %w", err)
 	}
 
-	// Over-fetch from vec to give the filter+dedup pass room to work.
+	// Namespace and trash state are filtered inside the KNN, so other pools
+	// and the trash never crowd out matches. Over-fetch from vec to give the
+	// remaining filters and the dedup pass room to work.
 	probeK := max(limit*5, 25)
 
 	query := `
-		SELECT
-			v.distance,
-			c.content,
-			m.id, m.name, m.type, m.description, m.body, m.agent, m.source, m.checksum,
-			m.created_at, m.updated_at
+		SELECT v.distance, c.chunk_index, c.content, c.heading_path, vec_to_json(v.embedding), ` + memoryColumns + `
 		FROM memories_vec v
 		JOIN memory_chunks c ON v.id = c.id
 		JOIN memories m ON c.memory_id = m.id
-		WHERE v.embedding MATCH ? AND k = ?`
-
-	args := []any{string(embJSON), probeK}
-	if !filter.IsEmpty() {
-		clause, fargs := filter.where("m.")
-		query += " AND " + clause
-		args = append(args, fargs...)
+		WHERE v.embedding MATCH ? AND k = ? AND v.trashed = ?`
+	args := []any{string(embJSON), probeK, filter.Trashed}
+	if filter.Namespace != "" && filter.Namespace != AllNamespaces {
+		query += " AND v.namespace = ?"
+		args = append(args, filter.Namespace)
 	}
-	query += " ORDER BY v.distance"
+
+	clause, fargs := filter.where("m.")
+	query += " AND " + clause + " ORDER BY v.distance"
+	args = append(args, fargs...)
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -359,62 +673,54 @@ func (s *Store) SearchMemories(embedding []float32, limit int, filter MemoryFilt
 	}
 	defer rows.Close()
 
-	seen := make(map[string]struct{})
+	// Keep reading past the limit to note the other matching chunks of the
+	// memories already found.
+	seen := make(map[string]int)
 	var out []MemorySearchResult
 	for rows.Next() {
 		var (
-			distance       float32
-			excerpt        string
-			id, name, typ  string
-			descNS, bodyNS sql.NullString
-			agentNS        sql.NullString
-			sourceNS       sql.NullString
-			checksumNS     sql.NullString
-			createdAt      time.Time
-			updatedAt      time.Time
+			distance float32
+			index    int
+			excerpt  string
+			heading  string
+			vec      string
 		)
-		if err := rows.Scan(
-			&distance, &excerpt,
-			&id, &name, &typ, &descNS, &bodyNS, &agentNS, &sourceNS, &checksumNS,
-			&createdAt, &updatedAt,
-		); err != nil {
+		m, err := scanMemoryRow(rows, &distance, &index, &excerpt, &heading, &vec)
+		if err != nil {
 			return nil, fmt.Errorf("scanning memory search row: %w", err)
 		}
-		if _, dup := seen[id]; dup {
+		match := ChunkMatch{Index: index, Content: excerpt, HeadingPath: heading, Score: 1 - distance, Metric: MetricCosine}
+		if i, dup := seen[m.ID]; dup {
+			out[i].Chunks = append(out[i].Chunks, match)
 			continue
 		}
-		seen[id] = struct{}{}
+		if len(out) >= limit {
+			continue
+		}
+		seen[m.ID] = len(out)
 
-		body := ""
-		if bodyNS.Valid {
-			body = bodyNS.String
+		var emb []float32
+		if err := json.Unmarshal([]byte(vec), &emb); err != nil {
+			return nil, fmt.Errorf("decoding chunk embedding: %w", err)
 		}
 		out = append(out, MemorySearchResult{
-			Memory: Memory{
-				ID:          id,
-				Name:        name,
-				Type:        typ,
-				Description: descNS.String,
-				Body:        body,
-				Agent:       agentNS.String,
-				Source:      sourceNS.String,
-				Checksum:    checksumNS.String,
-				CreatedAt:   createdAt,
-				UpdatedAt:   updatedAt,
-			},
-			Excerpt:  excerpt,
-			Score:    1 - distance,
-			Distance: distance,
+			Memory:      *m,
+			Excerpt:     excerpt,
+			Score:       1 - distance,
+			Distance:    distance,
+			Metric:      MetricCosine,
+			ChunkIndex:  index,
+			HeadingPath: heading,
+			Chunks:      []ChunkMatch{match},
+			Embedding:   emb,
 		})
-		if len(out) >= limit {
-			break
-		}
 	}
 	return out, rows.Err()
 }
 
-// DeleteMemoryByID removes a memory and all its chunks/vectors. Returns false
-// if no memory with that id existed.
+// DeleteMemoryByID permanently removes a memory and all its chunks/vectors,
+// whether or not it is in the trash. Returns false if no memory with that id
+// existed.
 func (s *Store) DeleteMemoryByID(id string) (bool, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -422,22 +728,48 @@ func (s *Store) DeleteMemoryByID(id string) (bool, error) {
 	}
 	defer tx.Rollback()
 
-	if err := deleteChunksTx(tx, id); err != nil {
+	deleted, err := s.deleteMemoryTx(tx, id)
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("committing delete: %w", err)
+	}
+	return deleted, nil
+}
+
+// deleteMemoryTx removes a memory with its chunks, tags, revisions and links,
+// except derived_from links pointing at it: they are the provenance of the
+// memories derived from it (such as a merge target) and outlive the source.
+func (s *Store) deleteMemoryTx(tx *sql.Tx, id string) (bool, error) {
+	if err := s.deleteChunksTx(tx, id); err != nil {
 		return false, err
 	}
 	res, err := tx.Exec("DELETE FROM memories WHERE id = ?", id)
 	if err != nil {
 		return false, fmt.Errorf("deleting memory: %w", err)
 	}
-	n, _ := res.RowsAffected()
-	if err := tx.Commit(); err != nil {
-		return false, fmt.Errorf("committing delete: %w", err)
+	if _, err := tx.Exec("DELETE FROM memory_tags WHERE memory_id = ?", id); err != nil {
+		return false, fmt.Errorf("deleting tags: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM memory_revisions WHERE memory_id = ?", id); err != nil {
+		return false, fmt.Errorf("deleting revisions: %w", err)
+	}
+	if _, err := tx.Exec(
+		"DELETE FROM memory_links WHERE from_id = ? OR (to_id = ? AND type != 'derived_from')", id, id,
+	); err != nil {
+		return false, fmt.Errorf("deleting links: %w", err)
 	}
+	if err := s.indexMemoryTextTx(tx, id); err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
 	return n > 0, nil
 }
 
-// DeleteMemoriesByFilter removes every memory matching the (non-empty) filter
-// and returns the deleted memory rows (for caller verification).
+// DeleteMemoriesByFilter permanently removes every memory matching the
+// (non-empty) filter and returns the deleted memory rows (for caller
+// verification).
 func (s *Store) DeleteMemoriesByFilter(filter MemoryFilter) ([]Memory, error) {
 	if filter.IsEmpty() {
 		return nil, fmt.Errorf("refusing to delete with empty filter")
@@ -456,45 +788,68 @@ func (s *Store) DeleteMemoriesByFilter(filter MemoryFilter) ([]Memory, error) {
 
 // --- helpers ---
 
-func insertChunks(tx *sql.Tx, memoryID string, contents []string, embeddings [][]float32) error {
-	for i, content := range contents {
+func (s *Store) insertChunks(tx *sql.Tx, memoryID string, chunks []ChunkText, embeddings [][]float32) error {
+	for i, chunk := range chunks {
 		chunkID := uuid.New().String()
 		if _, err := tx.Exec(
-			"INSERT INTO memory_chunks (id, memory_id, chunk_index, content) VALUES (?, ?, ?, ?)",
-			chunkID, memoryID, i, content,
+			"INSERT INTO memory_chunks (id, memory_id, chunk_index, content, heading_path) VALUES (?, ?, ?, ?, ?)",
+			chunkID, memoryID, i, chunk.Content, chunk.HeadingPath,
 		); err != nil {
 			return fmt.Errorf("inserting chunk %d: %w", i, err)
 		}
+		if s.fts {
+			if _, err := tx.Exec(
+				"INSERT INTO memory_chunks_fts (content, chunk_id, memory_id) VALUES (?, ?, ?)",
+				chunk.Content, chunkID, memoryID,
+			); err != nil {
+				return fmt.Errorf("inserting chunk %d fts row: %w", i, err)
+			}
+		}
 		embJSON, err := json.Marshal(embeddings[i])
 		if err != nil {
 			return fmt.Errorf("marshaling embedding %d: %w", i, err)
 		}
-		if _, err := tx.Exec(
-			"INSERT INTO memories_vec (id, embedding) VALUES (?, ?)",
-			chunkID, string(embJSON),
-		); err != nil {
+		// The vector carries the memory's namespace and trash state so KNN
+		// search can filter on them.
+		res, err := tx.Exec(
+			`INSERT INTO memories_vec (id, embedding, namespace, trashed)
+				SELECT ?, ?, namespace, deleted_at IS NOT NULL FROM memories WHERE id = ?`,
+			chunkID, string(embJSON), memoryID,
+		)
+		if err != nil {
 			return fmt.Errorf("inserting vector %d: %w", i, err)
 		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("inserting vector %d: memory not found: %s", i, memoryID)
+		}
 	}
 	return nil
 }
 
-func deleteChunksTx(tx *sql.Tx, memoryID string) error {
+// chunkIDsTx returns the ids of a memory's chunks, which are also the ids of
+// their vectors.
+func chunkIDsTx(tx *sql.Tx, memoryID string) ([]string, error) {
 	rows, err := tx.Query("SELECT id FROM memory_chunks WHERE memory_id = ?", memoryID)
 	if err != nil {
-		return fmt.Errorf("listing chunks: %w", err)
+		return nil, fmt.Errorf("listing chunks: %w", err)
 	}
+	defer rows.Close()
 	var ids []string
 	for rows.Next() {
 		var id string
 		if err := rows.Scan(&id); err != nil {
-			rows.Close()
-			return fmt.Errorf("scanning chunk id: %w", err)
+			return nil, fmt.Errorf("scanning chunk id: %w", err)
 		}
 		ids = append(ids, id)
 	}
-	rows.Close()
+	return ids, rows.Err()
+}
 
+func (s *Store) deleteChunksTx(tx *sql.Tx, memoryID string) error {
+	ids, err := chunkIDsTx(tx, memoryID)
+	if err != nil {
+		return err
+	}
 	for _, id := range ids {
 		if _, err := tx.Exec("DELETE FROM memories_vec WHERE id = ?", id); err != nil {
 			return fmt.Errorf("deleting vec row: %w", err)
@@ -503,6 +858,11 @@ func deleteChunksTx(tx *sql.Tx, memoryID string) error {
 	if _, err := tx.Exec("DELETE FROM memory_chunks WHERE memory_id = ?", memoryID); err != nil {
 		return fmt.Errorf("deleting chunks: %w", err)
 	}
+	if s.fts {
+		if _, err := tx.Exec("DELETE FROM memory_chunks_fts WHERE memory_id = ?", memoryID); err != nil {
+			return fmt.Errorf("deleting chunk fts rows: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -510,24 +870,45 @@ type rowScanner interface {
 	Scan(dest ...any) error
 }
 
-func scanMemoryRow(r rowScanner) (*Memory, error) {
+// scanMemoryRow scans the memoryColumns of a row. lead receives any columns
+// selected before them.
+func scanMemoryRow(r rowScanner, lead ...any) (*Memory, error) {
 	var (
 		m                         Memory
 		desc, agent, source, csum sql.NullString
+		status, tags              sql.NullString
 		createdAt, updatedAt      time.Time
+		deletedAt, expiresAt      sql.NullTime
+		dueAt, lastRecalledAt     sql.NullTime
 	)
-	if err := r.Scan(
-		&m.ID, &m.Name, &m.Type, &desc, &m.Body, &agent, &source, &csum,
-		&createdAt, &updatedAt,
-	); err != nil {
+	dest := append(lead,
+		&m.ID, &m.Namespace, &m.Name, &m.Type, &desc, &m.Body, &agent, &source, &csum,
+		&createdAt, &updatedAt, &deletedAt, &expiresAt, &dueAt, &status,
+		&m.Importance, &m.RecallCount, &lastRecalledAt, &m.Pinned, &tags,
+	)
+	if err := r.Scan(dest...); err != nil {
 		return nil, err
 	}
+	m.Tags = splitTags(tags)
 	m.Description = desc.String
 	m.Agent = agent.String
 	m.Source = source.String
 	m.Checksum = csum.String
+	m.Status = status.String
 	m.CreatedAt = createdAt
 	m.UpdatedAt = updatedAt
+	if deletedAt.Valid {
+		m.DeletedAt = &deletedAt.Time
+	}
+	if expiresAt.Valid {
+		m.ExpiresAt = &expiresAt.Time
+	}
+	if dueAt.Valid {
+		m.DueAt = &dueAt.Time
+	}
+	if lastRecalledAt.Valid {
+		m.LastRecalledAt = &lastRecalledAt.Time
+	}
 	return &m, nil
 }
 
@@ -538,6 +919,24 @@ func nullableString(s string) any {
 	return s
 }
 
+// sqliteTimeFormat matches CURRENT_TIMESTAMP so explicit and defaulted
+// timestamps sort together.
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+func nullableTimePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return nullableTime(*t)
+}
+
 func isUniqueConstraintErr(err error) bool {
 	if err == nil {
 		return false
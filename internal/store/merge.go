@@ -0,0 +1,121 @@
+package store
+
+import (
+	"fmt"
+)
+
+// RenameMemory changes a memory's name and swaps in chunks embedded under
+// the new name, in one transaction. Returns ErrMemoryNameExists if the name
+// is taken in the memory's namespace.
+func (s *Store) RenameMemory(id, name string, chunks []ChunkText, embeddings [][]float32) error {
+	if len(chunks) != len(embeddings) {
+		return fmt.Errorf("chunk contents (%d) and embeddings (%d) length mismatch", len(chunks), len(embeddings))
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE memories SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", name, id)
+	if err != nil {
+		if isUniqueConstraintErr(err) {
+			return ErrMemoryNameExists
+		}
+		return fmt.Errorf("renaming memory: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("memory not found: %s", id)
+	}
+	if err := s.deleteChunksTx(tx, id); err != nil {
+		return err
+	}
+	if err := s.insertChunks(tx, id, chunks, embeddings); err != nil {
+		return err
+	}
+	if err := s.indexMemoryTextTx(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MemoryMerge describes folding source memories into a target: the target
+// takes Body, Tags and the matching chunks, gains a LinkType link to each
+// source, and the sources go to the trash or are deleted. The links outlive
+// deleted sources, as DeleteMemoryByID keeps derived_from links to them.
+type MemoryMerge struct {
+	TargetID      string
+	Body          string
+	Tags          []string
+	Chunks        []ChunkText
+	Embeddings    [][]float32
+	SourceIDs     []string
+	LinkType      string
+	DeleteSources bool
+}
+
+// MergeMemories applies a merge in one transaction, saving the target's
+// previous state as a revision first.
+func (s *Store) MergeMemories(mg MemoryMerge) error {
+	if len(mg.Chunks) != len(mg.Embeddings) {
+		return fmt.Errorf("chunk contents (%d) and embeddings (%d) length mismatch", len(mg.Chunks), len(mg.Embeddings))
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := saveRevisionTx(tx, mg.TargetID); err != nil {
+		return err
+	}
+	res, err := tx.Exec("UPDATE memories SET body = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", mg.Body, mg.TargetID)
+	if err != nil {
+		return fmt.Errorf("updating merge target: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("memory not found: %s", mg.TargetID)
+	}
+	if err := setTagsTx(tx, mg.TargetID, mg.Tags); err != nil {
+		return err
+	}
+	if err := s.deleteChunksTx(tx, mg.TargetID); err != nil {
+		return err
+	}
+	if err := s.insertChunks(tx, mg.TargetID, mg.Chunks, mg.Embeddings); err != nil {
+		return err
+	}
+
+	for _, id := range mg.SourceIDs {
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO memory_links (from_id, to_id, type) VALUES (?, ?, ?)",
+			mg.TargetID, id, mg.LinkType,
+		); err != nil {
+			return fmt.Errorf("linking merge source: %w", err)
+		}
+		if mg.DeleteSources {
+			deleted, err := s.deleteMemoryTx(tx, id)
+			if err != nil {
+				return err
+			}
+			if !deleted {
+				return fmt.Errorf("memory not found: %s", id)
+			}
+			continue
+		}
+		res, err := tx.Exec("UPDATE memories SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", id)
+		if err != nil {
+			return fmt.Errorf("archiving merge source: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("memory not found: %s", id)
+		}
+		if err := setVectorsTrashedTx(tx, id, true); err != nil {
+			return err
+		}
+	}
+	if err := s.indexMemoryTextTx(tx, mg.TargetID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	metaEmbedBackend    = "embed_backend"
+	metaEmbedModel      = "embed_model"
+	metaEmbedDimensions = "embed_dimensions"
+	metaReembedPending  = "reembed_pending"
+)
+
+// EmbeddingInfo fingerprints the model that produced the vectors in a
+// database. Vectors from different models live in different spaces even when
+// their dimensions agree, so they must never be mixed in one index.
+type EmbeddingInfo struct {
+	Backend    string `json:"backend"`
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+// String formats the fingerprint for logs and error messages.
+func (e EmbeddingInfo) String() string {
+	return fmt.Sprintf("%s/%s (%d dims)", e.Backend, e.Model, e.Dimensions)
+}
+
+// EmbeddingInfo returns the recorded embedding fingerprint, or nil for
+// databases created before fingerprints were recorded.
+func (s *Store) EmbeddingInfo() (*EmbeddingInfo, error) {
+	vals, err := s.getMeta(metaEmbedBackend, metaEmbedModel, metaEmbedDimensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	dims, err := strconv.Atoi(vals[metaEmbedDimensions])
+	if err != nil {
+		return nil, fmt.Errorf("parsing recorded dimensions %q: %w", vals[metaEmbedDimensions], err)
+	}
+	return &EmbeddingInfo{
+		Backend:    vals[metaEmbedBackend],
+		Model:      vals[metaEmbedModel],
+		Dimensions: dims,
+	}, nil
+}
+
+// SetEmbeddingInfo records the embedding fingerprint.
+func (s *Store) SetEmbeddingInfo(info EmbeddingInfo) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, value := range map[string]string{
+		metaEmbedBackend:    info.Backend,
+		metaEmbedModel:      info.Model,
+		metaEmbedDimensions: strconv.Itoa(info.Dimensions),
+	} {
+		if err := setMetaTx(tx, key, value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReembedPending reports whether a re-embed has dropped the vectors and not
+// yet finished putting them back.
+func (s *Store) ReembedPending() (bool, error) {
+	vals, err := s.getMeta(metaReembedPending)
+	if err != nil {
+		return false, err
+	}
+	return vals[metaReembedPending] != "", nil
+}
+
+// SetReembedPending sets or clears the pending re-embed marker.
+func (s *Store) SetReembedPending(pending bool) error {
+	if !pending {
+		if _, err := s.db.Exec("DELETE FROM store_meta WHERE key = ?", metaReembedPending); err != nil {
+			return fmt.Errorf("clearing %s: %w", metaReembedPending, err)
+		}
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setMetaTx(tx, metaReembedPending, "1"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+var vecDimensionsRe = regexp.MustCompile(`(?i)FLOAT\[(\d+)\]`)
+
+// VectorDimensions returns the dimension memories_vec was created with.
+func (s *Store) VectorDimensions() (int, error) {
+	var ddl string
+	err := s.db.QueryRow("SELECT sql FROM sqlite_master WHERE name = 'memories_vec'").Scan(&ddl)
+	if err != nil {
+		return 0, fmt.Errorf("reading memories_vec definition: %w", err)
+	}
+	m := vecDimensionsRe.FindStringSubmatch(ddl)
+	if m == nil {
+		return 0, fmt.Errorf("no dimension in memories_vec definition: %s", ddl)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// RecreateVectorIndex drops every vector and recreates memories_vec at the
+// given dimension. Chunks are kept; callers must re-embed them.
+func (s *Store) RecreateVectorIndex(dimensions int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DROP TABLE IF EXISTS memories_vec"); err != nil {
+		return fmt.Errorf("dropping memories_vec: %w", err)
+	}
+	if _, err := tx.Exec(vecTableDDL(dimensions)); err != nil {
+		return fmt.Errorf("creating memories_vec: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.dimensions = dimensions
+	return nil
+}
+
+func vecTableDDL(dimensions int) string {
+	return fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS memories_vec USING vec0(
+		id TEXT PRIMARY KEY,
+		embedding FLOAT[%d] distance_metric=cosine,
+		namespace text,
+		trashed boolean
+	)`, dimensions)
+}
+
+func (s *Store) getMeta(keys ...string) (map[string]string, error) {
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		var value string
+		err := s.db.QueryRow("SELECT value FROM store_meta WHERE key = ?", key).Scan(&value)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", key, err)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+func setMetaTx(tx *sql.Tx, key, value string) error {
+	_, err := tx.Exec(`
+		INSERT INTO store_meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,440 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrSchemaTooNew is returned by New when the database was migrated by a newer
+// binary than this one. Opening it could silently drop columns we don't know.
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+// migration upgrades the schema by exactly one version. apply runs inside the
+// same transaction that records the new version, so a failed migration leaves
+// the database at the previous version.
+type migration struct {
+	version int
+	name    string
+	apply   func(s *Store, tx *sql.Tx) error
+}
+
+// migrations is the ordered schema history. Never edit or reorder an entry
+// that has shipped; append a new one instead, and add a fixture for the
+// version it supersedes under testdata/schema.
+var migrations = []migration{
+	{1, "initial memories, chunks, vectors and jobs", migrateInitial},
+	{2, "store metadata", migrateStoreMeta},
+	{3, "job checkpoints", migrateJobCheckpoint},
+	{4, "memory tags", migrateMemoryTags},
+	{5, "namespaces", migrateNamespaces},
+	{6, "memory revisions", migrateMemoryRevisions},
+	{7, "memory trash", migrateMemoryTrash},
+	{8, "memory expiry", migrateMemoryExpiry},
+	{9, "memory due dates", migrateMemoryDueDates},
+	{10, "memory links", migrateMemoryLinks},
+	{11, "cosine vector distance", migrateCosineVectors},
+	{12, "memory recall stats and importance", migrateMemoryRecallStats},
+	{13, "pinned memories", migratePinnedMemories},
+	{14, "chunk heading paths", migrateChunkHeadingPaths},
+	{15, "vector namespace and trash columns", migrateVectorMetadata},
+}
+
+// LatestSchemaVersion is the schema version this binary migrates databases to.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the database's current schema version.
+func (s *Store) SchemaVersion() (int, error) {
+	var v int
+	err := s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&v)
+	if err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return v, nil
+}
+
+// migrate brings the database up to LatestSchemaVersion, one transaction per
+// step. Databases created before versioning existed are adopted as version 1.
+// It reports whether the database was created from scratch.
+func (s *Store) migrate() (created bool, err error) {
+	if _, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return false, fmt.Errorf("creating schema_version table: %w", err)
+	}
+
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return false, err
+	}
+	if current == 0 {
+		legacy, err := s.tableExists("memories")
+		if err != nil {
+			return false, err
+		}
+		if legacy {
+			if _, err := s.db.Exec("INSERT OR IGNORE INTO schema_version (version) VALUES (1)"); err != nil {
+				return false, fmt.Errorf("adopting unversioned schema: %w", err)
+			}
+			current = 1
+		}
+	}
+	if latest := LatestSchemaVersion(); current > latest {
+		return false, fmt.Errorf("%w: database is at version %d, binary supports up to %d", ErrSchemaTooNew, current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.applyMigration(m); err != nil {
+			return false, err
+		}
+	}
+	return current == 0, nil
+}
+
+func (s *Store) applyMigration(m migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	// Recording the version first takes the write lock, so a second process
+	// racing us on the same file fails here instead of applying twice.
+	if _, err := tx.Exec("INSERT INTO schema_version (version) VALUES (?)", m.version); err != nil {
+		if isUniqueConstraintErr(err) {
+			return nil
+		}
+		return fmt.Errorf("recording migration %d: %w", m.version, err)
+	}
+	if err := m.apply(s, tx); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing migration %d: %w", m.version, err)
+	}
+	return nil
+}
+
+func (s *Store) tableExists(name string) (bool, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("checking for table %s: %w", name, err)
+	}
+	return n > 0, nil
+}
+
+// --- migrations ---
+
+func migrateInitial(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS memories (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			type TEXT NOT NULL,
+			description TEXT,
+			body TEXT NOT NULL,
+			agent TEXT,
+			source TEXT,
+			checksum TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS memory_chunks (
+			id TEXT PRIMARY KEY,
+			memory_id TEXT NOT NULL,
+			chunk_index INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			UNIQUE(memory_id, chunk_index)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_memory_chunks_memory_id ON memory_chunks(memory_id)`,
+		// L2 distance until migrateCosineVectors.
+		fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS memories_vec USING vec0(
+			id TEXT PRIMARY KEY,
+			embedding FLOAT[%d]
+		)`, s.dimensions),
+		`CREATE TABLE IF NOT EXISTS jobs (
+			id TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			status TEXT DEFAULT 'queued',
+			params TEXT NOT NULL,
+			result TEXT,
+			error TEXT,
+			progress INTEGER DEFAULT 0,
+			total INTEGER DEFAULT 0,
+			parent_id TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateStoreMeta(s *Store, tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS store_meta (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL
+		)
+	`)
+	return err
+}
+
+func migrateJobCheckpoint(s *Store, tx *sql.Tx) error {
+	_, err := tx.Exec("ALTER TABLE jobs ADD COLUMN checkpoint TEXT")
+	return err
+}
+
+func migrateMemoryTags(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS memory_tags (
+			memory_id TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (memory_id, tag)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_memory_tags_tag ON memory_tags(tag)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateNamespaces scopes memory names to a namespace. SQLite can't drop the
+// column-level UNIQUE on name, so memories is rebuilt; ids are kept, so
+// chunks, vectors, tags and the FTS index stay attached.
+func migrateNamespaces(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE memories_new (
+			id TEXT PRIMARY KEY,
+			namespace TEXT NOT NULL DEFAULT 'default',
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			description TEXT,
+			body TEXT NOT NULL,
+			agent TEXT,
+			source TEXT,
+			checksum TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(namespace, name)
+		)`,
+		`INSERT INTO memories_new (id, name, type, description, body, agent, source, checksum, created_at, updated_at)
+			SELECT id, name, type, description, body, agent, source, checksum, created_at, updated_at FROM memories`,
+		`DROP TABLE memories`,
+		`ALTER TABLE memories_new RENAME TO memories`,
+		`ALTER TABLE jobs ADD COLUMN namespace TEXT NOT NULL DEFAULT 'default'`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateMemoryRevisions(s *Store, tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS memory_revisions (
+		memory_id TEXT NOT NULL,
+		revision INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		description TEXT,
+		body TEXT NOT NULL,
+		agent TEXT,
+		source TEXT,
+		checksum TEXT,
+		updated_at DATETIME,
+		replaced_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (memory_id, revision)
+	)`)
+	return err
+}
+
+func migrateMemoryTrash(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE memories ADD COLUMN deleted_at DATETIME`,
+		`CREATE INDEX IF NOT EXISTS idx_memories_deleted_at ON memories(deleted_at)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateMemoryExpiry(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE memories ADD COLUMN expires_at DATETIME`,
+		`CREATE INDEX IF NOT EXISTS idx_memories_expires_at ON memories(expires_at)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateMemoryDueDates(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE memories ADD COLUMN due_at DATETIME`,
+		`ALTER TABLE memories ADD COLUMN status TEXT`,
+		`CREATE INDEX IF NOT EXISTS idx_memories_due_at ON memories(due_at)`,
+		`UPDATE memories SET status = 'open' WHERE type IN ('todo', 'reminder')`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateMemoryLinks(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS memory_links (
+			from_id TEXT NOT NULL,
+			to_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (from_id, to_id, type)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_memory_links_to_id ON memory_links(to_id)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateCosineVectors rebuilds memories_vec to rank by cosine distance
+// instead of L2, so search scores (1 - distance) are cosine similarities
+// whether or not a backend normalizes its vectors. vec0 tables can't be
+// altered, so the vectors are copied out and back in.
+func migrateCosineVectors(s *Store, tx *sql.Tx) error {
+	var ddl string
+	if err := tx.QueryRow("SELECT sql FROM sqlite_master WHERE name = 'memories_vec'").Scan(&ddl); err != nil {
+		return fmt.Errorf("reading memories_vec definition: %w", err)
+	}
+	if strings.Contains(ddl, "distance_metric=cosine") {
+		return nil
+	}
+	m := vecDimensionsRe.FindStringSubmatch(ddl)
+	if m == nil {
+		return fmt.Errorf("no dimension in memories_vec definition: %s", ddl)
+	}
+	dimensions, err := strconv.Atoi(m[1])
+	if err != nil {
+		return err
+	}
+
+	stmts := []string{
+		`CREATE TEMP TABLE memories_vec_copy AS SELECT id, embedding FROM memories_vec`,
+		`DROP TABLE memories_vec`,
+		// The table as of this version; later migrations reshape it.
+		fmt.Sprintf(`CREATE VIRTUAL TABLE memories_vec USING vec0(
+			id TEXT PRIMARY KEY,
+			embedding FLOAT[%d] distance_metric=cosine
+		)`, dimensions),
+		`INSERT INTO memories_vec (id, embedding) SELECT id, embedding FROM memories_vec_copy`,
+		`DROP TABLE memories_vec_copy`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateMemoryRecallStats(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE memories ADD COLUMN importance REAL NOT NULL DEFAULT 0`,
+		`ALTER TABLE memories ADD COLUMN recall_count INTEGER NOT NULL DEFAULT 0`,
+		`ALTER TABLE memories ADD COLUMN last_recalled_at DATETIME`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migratePinnedMemories(s *Store, tx *sql.Tx) error {
+	stmts := []string{
+		`ALTER TABLE memories ADD COLUMN pinned INTEGER NOT NULL DEFAULT 0`,
+		`CREATE INDEX IF NOT EXISTS idx_memories_pinned ON memories(pinned) WHERE pinned = 1`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateChunkHeadingPaths records the Markdown heading path of each chunk.
+// Existing chunks get an empty path until their memory is re-embedded.
+func migrateChunkHeadingPaths(s *Store, tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE memory_chunks ADD COLUMN heading_path TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// migrateVectorMetadata copies each memory's namespace and trash state onto
+// its vectors, so KNN search filters on them itself instead of spending its
+// probe on other namespaces and the trash. Vectors whose chunk is gone are
+// dropped on the way.
+func migrateVectorMetadata(s *Store, tx *sql.Tx) error {
+	var ddl string
+	if err := tx.QueryRow("SELECT sql FROM sqlite_master WHERE name = 'memories_vec'").Scan(&ddl); err != nil {
+		return fmt.Errorf("reading memories_vec definition: %w", err)
+	}
+	m := vecDimensionsRe.FindStringSubmatch(ddl)
+	if m == nil {
+		return fmt.Errorf("no dimension in memories_vec definition: %s", ddl)
+	}
+	dimensions, err := strconv.Atoi(m[1])
+	if err != nil {
+		return err
+	}
+
+	stmts := []string{
+		`CREATE TEMP TABLE memories_vec_copy AS
+			SELECT v.id, v.embedding, m.namespace, m.deleted_at IS NOT NULL AS trashed
+			FROM memories_vec v
+			JOIN memory_chunks c ON c.id = v.id
+			JOIN memories m ON m.id = c.memory_id`,
+		`DROP TABLE memories_vec`,
+		vecTableDDL(dimensions),
+		`INSERT INTO memories_vec (id, embedding, namespace, trashed)
+			SELECT id, embedding, namespace, trashed FROM memories_vec_copy`,
+		`DROP TABLE memories_vec_copy`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MemoryRevision is a past state of a memory, saved by UpdateMemoryFields or
+// ReplaceMemory just before the memory was changed. Revisions are numbered
+// from 1 per memory; the highest is the state the current one replaced.
+type MemoryRevision struct {
+	MemoryID    string    `json:"memory_id"`
+	Revision    int       `json:"revision"`
+	Type        string    `json:"type"`
+	Description string    `json:"description,omitempty"`
+	Body        string    `json:"body"`
+	Agent       string    `json:"agent,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Checksum    string    `json:"checksum,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`  // when this state was written
+	ReplacedAt  time.Time `json:"replaced_at"` // when it was superseded
+}
+
+const revisionColumns = "memory_id, revision, type, description, body, agent, source, checksum, updated_at, replaced_at"
+
+// saveRevisionTx copies the current state of a memory into memory_revisions.
+func saveRevisionTx(tx *sql.Tx, memoryID string) error {
+	_, err := tx.Exec(`
+		INSERT INTO memory_revisions (memory_id, revision, type, description, body, agent, source, checksum, updated_at)
+		SELECT id,
+			(SELECT COALESCE(MAX(revision), 0) + 1 FROM memory_revisions WHERE memory_id = ?),
+			type, description, body, agent, source, checksum, updated_at
+		FROM memories WHERE id = ?
+	`, memoryID, memoryID)
+	if err != nil {
+		return fmt.Errorf("saving revision: %w", err)
+	}
+	return nil
+}
+
+// ListMemoryRevisions returns a memory's saved revisions, newest first.
+func (s *Store) ListMemoryRevisions(memoryID string) ([]MemoryRevision, error) {
+	rows, err := s.db.Query(
+		"SELECT "+revisionColumns+" FROM memory_revisions WHERE memory_id = ? ORDER BY revision DESC",
+		memoryID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []MemoryRevision
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *r)
+	}
+	return revisions, rows.Err()
+}
+
+// GetMemoryRevision fetches one revision of a memory. Returns nil, nil if not found.
+func (s *Store) GetMemoryRevision(memoryID string, revision int) (*MemoryRevision, error) {
+	row := s.db.QueryRow(
+		"SELECT "+revisionColumns+" FROM memory_revisions WHERE memory_id = ? AND revision = ?",
+		memoryID, revision,
+	)
+	r, err := scanRevision(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+func scanRevision(r rowScanner) (*MemoryRevision, error) {
+	var rev MemoryRevision
+	var desc, agent, source, csum sql.NullString
+	if err := r.Scan(
+		&rev.MemoryID, &rev.Revision, &rev.Type, &desc, &rev.Body, &agent, &source, &csum,
+		&rev.UpdatedAt, &rev.ReplacedAt,
+	); err != nil {
+		return nil, err
+	}
+	rev.Description = desc.String
+	rev.Agent = agent.String
+	rev.Source = source.String
+	rev.Checksum = csum.String
+	return &rev, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	sqlite_vec "github.com/asg017/sqlite-vec-go-bindings/cgo"
@@ -18,17 +19,19 @@ func init() {
 
 // Job represents an async indexing job.
 type Job struct {
-	ID        string    `json:"id"`
-	Type      string    `json:"type"`
-	Status    string    `json:"status"`
-	Params    string    `json:"params"`
-	Result    string    `json:"result,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	Progress  int       `json:"progress"`
-	Total     int       `json:"total"`
-	ParentID  string    `json:"parent_id,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Namespace  string    `json:"namespace"`
+	Status     string    `json:"status"`
+	Params     string    `json:"params"`
+	Result     string    `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Progress   int       `json:"progress"`
+	Total      int       `json:"total"`
+	ParentID   string    `json:"parent_id,omitempty"`
+	Checkpoint string    `json:"checkpoint,omitempty"` // resume position for long-running jobs
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 const (
@@ -41,20 +44,26 @@ const (
 const (
 	JobTypeIndexFile = "index_file"
 	JobTypeIndexDir  = "index_directory"
+	JobTypeReembed   = "reembed"
+	JobTypeFindDups  = "find_duplicates"
 )
 
+const jobColumns = "id, type, namespace, status, params, result, error, progress, total, parent_id, checkpoint, created_at, updated_at"
+
 // Store manages memory storage, vector search, and the indexing job queue.
 type Store struct {
 	db         *sql.DB
 	dimensions int
+	fts        bool // FTS5 keyword index available
 }
 
-// New creates a new Store with the given database path and embedding dimensions.
-// New creates a new Store. journalMode is the SQLite journal_mode PRAGMA;
-// empty defaults to "DELETE" (rollback journal — safe to use under cloud
-// sync). Set "WAL" explicitly for local-only DBs that benefit from
-// read-during-write concurrency.
-func New(dbPath string, dimensions int, journalMode string) (*Store, error) {
+// New creates a new Store. embedding describes the model whose vectors the
+// index holds; it sizes the vector table and is recorded as the database's
+// fingerprint when the file is created. journalMode is the SQLite
+// journal_mode PRAGMA; empty defaults to "DELETE" (rollback journal — safe to
+// use under cloud sync). Set "WAL" explicitly for local-only DBs that benefit
+// from read-during-write concurrency.
+func New(dbPath string, embedding EmbeddingInfo, journalMode string) (*Store, error) {
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return nil, fmt.Errorf("creating database directory: %w", err)
@@ -73,51 +82,34 @@ func New(dbPath string, dimensions int, journalMode string) (*Store, error) {
 
 	store := &Store{
 		db:         db,
-		dimensions: dimensions,
+		dimensions: embedding.Dimensions,
 	}
 
-	if err := store.initSchema(); err != nil {
+	created, err := store.migrate()
+	if err != nil {
 		db.Close()
-		return nil, fmt.Errorf("initializing schema: %w", err)
+		return nil, fmt.Errorf("migrating schema: %w", err)
 	}
-
-	return store, nil
-}
-
-func (s *Store) initSchema() error {
-	if err := s.initMemorySchema(); err != nil {
-		return err
+	if created {
+		if err := store.SetEmbeddingInfo(embedding); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("recording embedding fingerprint: %w", err)
+		}
 	}
-	return s.initJobSchema()
-}
-
-func (s *Store) initJobSchema() error {
-	_, err := s.db.Exec(`
-		CREATE TABLE IF NOT EXISTS jobs (
-			id TEXT PRIMARY KEY,
-			type TEXT NOT NULL,
-			status TEXT DEFAULT 'queued',
-			params TEXT NOT NULL,
-			result TEXT,
-			error TEXT,
-			progress INTEGER DEFAULT 0,
-			total INTEGER DEFAULT 0,
-			parent_id TEXT,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("creating jobs table: %w", err)
+	if err := store.initFullTextSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing full-text index: %w", err)
 	}
-	return nil
+
+	return store, nil
 }
 
-// CreateJob creates a new job in the queue.
-func (s *Store) CreateJob(id, jobType, params string) error {
+// CreateJob creates a new job in the queue. namespace is the memory
+// namespace the job writes to, or AllNamespaces for pool-wide jobs.
+func (s *Store) CreateJob(id, jobType, namespace, params string) error {
 	_, err := s.db.Exec(
-		"INSERT INTO jobs (id, type, params) VALUES (?, ?, ?)",
-		id, jobType, params,
+		"INSERT INTO jobs (id, type, namespace, params) VALUES (?, ?, ?, ?)",
+		id, jobType, namespace, params,
 	)
 	if err != nil {
 		return fmt.Errorf("creating job: %w", err)
@@ -126,10 +118,10 @@ func (s *Store) CreateJob(id, jobType, params string) error {
 }
 
 // CreateJobWithParent creates a new job linked to a parent job.
-func (s *Store) CreateJobWithParent(id, jobType, params, parentID string) error {
+func (s *Store) CreateJobWithParent(id, jobType, namespace, params, parentID string) error {
 	_, err := s.db.Exec(
-		"INSERT INTO jobs (id, type, params, parent_id) VALUES (?, ?, ?, ?)",
-		id, jobType, params, parentID,
+		"INSERT INTO jobs (id, type, namespace, params, parent_id) VALUES (?, ?, ?, ?, ?)",
+		id, jobType, namespace, params, parentID,
 	)
 	if err != nil {
 		return fmt.Errorf("creating job: %w", err)
@@ -139,34 +131,14 @@ func (s *Store) CreateJobWithParent(id, jobType, params, parentID string) error
 
 // GetJob retrieves a job by ID. Returns nil, nil if not found.
 func (s *Store) GetJob(id string) (*Job, error) {
-	var job Job
-	var result, errMsg, parentID sql.NullString
-
-	err := s.db.QueryRow(`
-		SELECT id, type, status, params, result, error, progress, total, parent_id, created_at, updated_at
-		FROM jobs WHERE id = ?
-	`, id).Scan(
-		&job.ID, &job.Type, &job.Status, &job.Params,
-		&result, &errMsg, &job.Progress, &job.Total, &parentID,
-		&job.CreatedAt, &job.UpdatedAt,
-	)
+	job, err := scanJob(s.db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("querying job: %w", err)
 	}
-
-	if result.Valid {
-		job.Result = result.String
-	}
-	if errMsg.Valid {
-		job.Error = errMsg.String
-	}
-	if parentID.Valid {
-		job.ParentID = parentID.String
-	}
-	return &job, nil
+	return job, nil
 }
 
 // WaitForJob blocks until the job reaches a terminal state or the timeout elapses.
@@ -190,22 +162,12 @@ func (s *Store) WaitForJob(id string, timeout time.Duration) (*Job, error) {
 	return s.GetJob(id)
 }
 
-// ListJobs returns jobs, optionally filtered by status.
-func (s *Store) ListJobs(status string) ([]Job, error) {
-	var rows *sql.Rows
-	var err error
-
-	if status == "" {
-		rows, err = s.db.Query(`
-			SELECT id, type, status, params, result, error, progress, total, parent_id, created_at, updated_at
-			FROM jobs ORDER BY created_at DESC
-		`)
-	} else {
-		rows, err = s.db.Query(`
-			SELECT id, type, status, params, result, error, progress, total, parent_id, created_at, updated_at
-			FROM jobs WHERE status = ? ORDER BY created_at DESC
-		`, status)
-	}
+// ListJobs returns jobs, optionally filtered by status and namespace. A
+// namespace filter also matches pool-wide jobs; "" or AllNamespaces matches
+// every job.
+func (s *Store) ListJobs(status, namespace string) ([]Job, error) {
+	where, args := jobsWhere(status, namespace, true)
+	rows, err := s.db.Query("SELECT "+jobColumns+" FROM jobs"+where+" ORDER BY created_at DESC", args...)
 	if err != nil {
 		return nil, fmt.Errorf("querying jobs: %w", err)
 	}
@@ -213,26 +175,11 @@ func (s *Store) ListJobs(status string) ([]Job, error) {
 
 	var jobs []Job
 	for rows.Next() {
-		var job Job
-		var result, errMsg, parentID sql.NullString
-
-		if err := rows.Scan(
-			&job.ID, &job.Type, &job.Status, &job.Params,
-			&result, &errMsg, &job.Progress, &job.Total, &parentID,
-			&job.CreatedAt, &job.UpdatedAt,
-		); err != nil {
+		job, err := scanJob(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scanning job: %w", err)
 		}
-		if result.Valid {
-			job.Result = result.String
-		}
-		if errMsg.Valid {
-			job.Error = errMsg.String
-		}
-		if parentID.Valid {
-			job.ParentID = parentID.String
-		}
-		jobs = append(jobs, job)
+		jobs = append(jobs, *job)
 	}
 	return jobs, rows.Err()
 }
@@ -255,6 +202,16 @@ func (s *Store) UpdateJobProgress(id string, progress, total int) error {
 	return err
 }
 
+// UpdateJobCheckpoint records a resume position along with progress, so a
+// job interrupted by a restart can pick up where it left off.
+func (s *Store) UpdateJobCheckpoint(id, checkpoint string, progress, total int) error {
+	_, err := s.db.Exec(
+		"UPDATE jobs SET checkpoint = ?, progress = ?, total = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		checkpoint, progress, total, id,
+	)
+	return err
+}
+
 // UpdateJobResult marks the job completed with a serialized result.
 func (s *Store) UpdateJobResult(id, result string) error {
 	_, err := s.db.Exec(
@@ -325,17 +282,10 @@ func (s *Store) GetNextPendingJob() (*Job, error) {
 	}
 	defer tx.Rollback()
 
-	var job Job
-	var result, errMsg, parentID sql.NullString
-
-	err = tx.QueryRow(`
-		SELECT id, type, status, params, result, error, progress, total, parent_id, created_at, updated_at
-		FROM jobs WHERE status = ? ORDER BY created_at ASC LIMIT 1
-	`, JobStatusQueued).Scan(
-		&job.ID, &job.Type, &job.Status, &job.Params,
-		&result, &errMsg, &job.Progress, &job.Total, &parentID,
-		&job.CreatedAt, &job.UpdatedAt,
-	)
+	job, err := scanJob(tx.QueryRow(
+		"SELECT "+jobColumns+" FROM jobs WHERE status = ? ORDER BY created_at ASC LIMIT 1",
+		JobStatusQueued,
+	))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -343,16 +293,6 @@ func (s *Store) GetNextPendingJob() (*Job, error) {
 		return nil, fmt.Errorf("querying pending job: %w", err)
 	}
 
-	if result.Valid {
-		job.Result = result.String
-	}
-	if errMsg.Valid {
-		job.Error = errMsg.String
-	}
-	if parentID.Valid {
-		job.ParentID = parentID.String
-	}
-
 	_, err = tx.Exec(
 		"UPDATE jobs SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
 		JobStatusProcessing, job.ID,
@@ -365,19 +305,52 @@ func (s *Store) GetNextPendingJob() (*Job, error) {
 	}
 
 	job.Status = JobStatusProcessing
-	return &job, nil
+	return job, nil
 }
 
-// DeleteJobs removes jobs by status, or all jobs if status is "all".
-func (s *Store) DeleteJobs(status string) (int, error) {
-	var result sql.Result
-	var err error
+// RequeueStaleJobs returns jobs of jobType stuck in processing — left behind
+// by a process that died or shut down mid-job — to the queue. A job counts as
+// stale once it has not been touched for staleAfter. Only job types that keep
+// themselves fresh by checkpointing may be requeued this way; a live job of
+// any other type can legitimately go untouched that long.
+func (s *Store) RequeueStaleJobs(jobType string, staleAfter time.Duration) (int, error) {
+	res, err := s.db.Exec(
+		"UPDATE jobs SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE type = ? AND status = ? AND updated_at < datetime('now', ?)",
+		JobStatusQueued, jobType, JobStatusProcessing, fmt.Sprintf("-%d seconds", int(staleAfter.Seconds())),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("requeueing stale jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
 
+// ActiveJob returns the oldest queued or processing job of the given type,
+// or nil if there is none.
+func (s *Store) ActiveJob(jobType string) (*Job, error) {
+	job, err := scanJob(s.db.QueryRow(
+		"SELECT "+jobColumns+" FROM jobs WHERE type = ? AND status IN (?, ?) ORDER BY created_at ASC LIMIT 1",
+		jobType, JobStatusQueued, JobStatusProcessing,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying active job: %w", err)
+	}
+	return job, nil
+}
+
+// DeleteJobs removes jobs by status, or of every status if status is "all",
+// within namespace. Unlike ListJobs, a namespace filter leaves pool-wide jobs
+// alone: they belong to every namespace, so only "" or AllNamespaces clears
+// them.
+func (s *Store) DeleteJobs(status, namespace string) (int, error) {
 	if status == "all" {
-		result, err = s.db.Exec("DELETE FROM jobs")
-	} else {
-		result, err = s.db.Exec("DELETE FROM jobs WHERE status = ?", status)
+		status = ""
 	}
+	where, args := jobsWhere(status, namespace, false)
+	result, err := s.db.Exec("DELETE FROM jobs"+where, args...)
 	if err != nil {
 		return 0, fmt.Errorf("deleting jobs: %w", err)
 	}
@@ -386,6 +359,47 @@ func (s *Store) DeleteJobs(status string) (int, error) {
 	return int(count), nil
 }
 
+// jobsWhere builds the filter for ListJobs and DeleteJobs. poolWide makes a
+// namespace filter also match jobs recorded under AllNamespaces.
+func jobsWhere(status, namespace string, poolWide bool) (string, []any) {
+	var clauses []string
+	var args []any
+	if status != "" {
+		clauses = append(clauses, "status = ?")
+		args = append(args, status)
+	}
+	if namespace != "" && namespace != AllNamespaces {
+		if poolWide {
+			clauses = append(clauses, "namespace IN (?, ?)")
+			args = append(args, namespace, AllNamespaces)
+		} else {
+			clauses = append(clauses, "namespace = ?")
+			args = append(args, namespace)
+		}
+	}
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func scanJob(r rowScanner) (*Job, error) {
+	var job Job
+	var result, errMsg, parentID, checkpoint sql.NullString
+	if err := r.Scan(
+		&job.ID, &job.Type, &job.Namespace, &job.Status, &job.Params,
+		&result, &errMsg, &job.Progress, &job.Total, &parentID, &checkpoint,
+		&job.CreatedAt, &job.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	job.Result = result.String
+	job.Error = errMsg.String
+	job.ParentID = parentID.String
+	job.Checkpoint = checkpoint.String
+	return &job, nil
+}
+
 // Close closes the database connection.
 func (s *Store) Close() error {
 	return s.db.Close()
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NormalizeTags lowercases and trims tags, drops empties and duplicates, and
+// returns them sorted. Tags are stored comma-joined in query results, so a
+// tag may not contain a comma.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if strings.Contains(t, ",") {
+			return nil, fmt.Errorf("invalid tag %q: tags may not contain commas", t)
+		}
+		if _, dup := seen[t]; dup {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// setTagsTx replaces a memory's tags.
+func setTagsTx(tx *sql.Tx, memoryID string, tags []string) error {
+	tags, err := NormalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM memory_tags WHERE memory_id = ?", memoryID); err != nil {
+		return fmt.Errorf("deleting tags: %w", err)
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO memory_tags (memory_id, tag) VALUES (?, ?)", memoryID, tag); err != nil {
+			return fmt.Errorf("inserting tag %q: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// tagsWhere returns the tag clauses of a filter. Both match through
+// subqueries on memory_tags so they compose with every other clause,
+// including the KNN constraint in SearchMemories.
+func tagsWhere(idColumn string, anyTags, allTags []string) ([]string, []any) {
+	var clauses []string
+	var args []any
+	if tags := normalizeFilterTags(anyTags); len(tags) > 0 {
+		clauses = append(clauses, fmt.Sprintf(
+			"%s IN (SELECT memory_id FROM memory_tags WHERE tag IN (%s))",
+			idColumn, placeholders(len(tags))))
+		for _, t := range tags {
+			args = append(args, t)
+		}
+	}
+	if tags := normalizeFilterTags(allTags); len(tags) > 0 {
+		clauses = append(clauses, fmt.Sprintf(
+			"%s IN (SELECT memory_id FROM memory_tags WHERE tag IN (%s) GROUP BY memory_id HAVING COUNT(*) = ?)",
+			idColumn, placeholders(len(tags))))
+		for _, t := range tags {
+			args = append(args, t)
+		}
+		args = append(args, len(tags))
+	}
+	return clauses, args
+}
+
+// normalizeFilterTags is NormalizeTags for filters, where a tag containing a
+// comma simply matches nothing.
+func normalizeFilterTags(tags []string) []string {
+	out, err := NormalizeTags(tags)
+	if err != nil {
+		return []string{","}
+	}
+	return out
+}
+
+// splitTags parses the comma-joined tags column of a memory query.
+func splitTags(joined sql.NullString) []string {
+	if !joined.Valid || joined.String == "" {
+		return nil
+	}
+	tags := strings.Split(joined.String, ",")
+	sort.Strings(tags)
+	return tags
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
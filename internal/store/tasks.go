@@ -0,0 +1,35 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// ListDueMemories returns memories matching the filter that are still open
+// or snoozed and due at or before the given time, most urgent first.
+func (s *Store) ListDueMemories(filter MemoryFilter, before time.Time, limit int) ([]Memory, error) {
+	clause, args := filter.where("m.")
+	query := "SELECT " + memoryColumns + " FROM memories m WHERE " + clause +
+		" AND m.status IN (?, ?) AND m.due_at IS NOT NULL AND m.due_at <= ? ORDER BY m.due_at, m.name"
+	args = append(args, MemoryStatusOpen, MemoryStatusSnoozed, nullableTime(before))
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing due memories: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Memory
+	for rows.Next() {
+		m, err := scanMemoryRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
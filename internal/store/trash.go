@@ -0,0 +1,136 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TrashMemory moves a live memory to the trash, hiding it from every query
+// that doesn't ask for the trash. Returns false if no live memory has that id.
+func (s *Store) TrashMemory(id string) (bool, error) {
+	return s.setTrashed(id, true)
+}
+
+// TrashMemoriesByFilter moves every live memory matching the (non-empty)
+// filter to the trash and returns them.
+func (s *Store) TrashMemoriesByFilter(filter MemoryFilter) ([]Memory, error) {
+	if filter.IsEmpty() {
+		return nil, fmt.Errorf("refusing to trash with empty filter")
+	}
+	filter.Trashed = false
+	matches, err := s.ListMemories(filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		if _, err := s.TrashMemory(m.ID); err != nil {
+			return nil, fmt.Errorf("trashing %s: %w", m.ID, err)
+		}
+	}
+	return matches, nil
+}
+
+// UntrashMemory takes a memory out of the trash. Returns false if the memory
+// doesn't exist or isn't in the trash.
+func (s *Store) UntrashMemory(id string) (bool, error) {
+	return s.setTrashed(id, false)
+}
+
+// setTrashed moves a memory into or out of the trash, with its vectors.
+// Reports false if it already was where it was asked to go.
+func (s *Store) setTrashed(id string, trashed bool) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE memories SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL"
+	if trashed {
+		query = "UPDATE memories SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL"
+	}
+	res, err := tx.Exec(query, id)
+	if err != nil {
+		return false, fmt.Errorf("updating trash state: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if err := setVectorsTrashedTx(tx, id, trashed); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// setVectorsTrashedTx mirrors a memory's trash state onto its vectors, so
+// KNN search skips trashed chunks instead of spending its probe on them.
+func setVectorsTrashedTx(tx *sql.Tx, memoryID string, trashed bool) error {
+	ids, err := chunkIDsTx(tx, memoryID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := tx.Exec("UPDATE memories_vec SET trashed = ? WHERE id = ?", trashed, id); err != nil {
+			return fmt.Errorf("updating vec row: %w", err)
+		}
+	}
+	return nil
+}
+
+// PurgeTrash permanently deletes memories trashed before the given time and
+// returns how many were removed.
+func (s *Store) PurgeTrash(before time.Time) (int, error) {
+	rows, err := s.db.Query("SELECT id FROM memories WHERE deleted_at IS NOT NULL AND deleted_at < ?", nullableTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("listing expired trash: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning trash id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		ok, err := s.DeleteMemoryByID(id)
+		if err != nil {
+			return purged, fmt.Errorf("purging %s: %w", id, err)
+		}
+		if ok {
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// ListExpiredMemories returns live memories, in every namespace, whose
+// expires_at is before the given time.
+func (s *Store) ListExpiredMemories(before time.Time) ([]Memory, error) {
+	rows, err := s.db.Query(
+		"SELECT "+memoryColumns+" FROM memories m WHERE m.deleted_at IS NULL AND m.expires_at < ? ORDER BY m.expires_at",
+		nullableTime(before),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing expired memories: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Memory
+	for rows.Next() {
+		m, err := scanMemoryRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -48,6 +50,8 @@ var (
 func main() {
 	logFile := flag.String("l", "", "Log errors to file (default: stderr)")
 	backend := flag.String("b", "minilm", "Embedding backend: minilm, ollama")
+	reembed := flag.Bool("reembed", false, "Re-embed every memory with the configured backend (runs as a background job)")
+	namespace := flag.String("namespace", "", "Default memory namespace for this instance (default: $GOLDIE_NAMESPACE or \"default\")")
 	flag.Parse()
 
 	var errWriter io.Writer = os.Stderr
@@ -72,6 +76,45 @@ func main() {
 	if jm := os.Getenv("GOLDIE_JOURNAL_MODE"); jm != "" {
 		cfg.JournalMode = jm
 	}
+	if tr := os.Getenv("GOLDIE_TRASH_RETENTION"); tr != "" {
+		d, err := goldie.ParseDuration(tr)
+		if err != nil {
+			errLog.Printf("GOLDIE_TRASH_RETENTION: %v", err)
+			os.Exit(1)
+		}
+		if d <= 0 {
+			d = -1 // keep forgotten memories until restored
+		}
+		cfg.TrashRetention = d
+	}
+	if ep := os.Getenv("GOLDIE_EXPIRY_POLICY"); ep != "" {
+		policy, err := goldie.ParseExpiryPolicy(ep)
+		if err != nil {
+			errLog.Printf("GOLDIE_EXPIRY_POLICY: %v", err)
+			os.Exit(1)
+		}
+		cfg.ExpiryPolicy = policy
+	}
+	if ms := os.Getenv("GOLDIE_MIN_SCORE"); ms != "" {
+		v, err := strconv.ParseFloat(ms, 32)
+		if err != nil || v > 1 {
+			errLog.Printf("GOLDIE_MIN_SCORE: must be a number up to 1, got %q", ms)
+			os.Exit(1)
+		}
+		cfg.MinScore = float32(v)
+	}
+	if dt := os.Getenv("GOLDIE_DUPLICATE_THRESHOLD"); dt != "" {
+		v, err := strconv.ParseFloat(dt, 32)
+		if err != nil || v <= 0 || v > 1 {
+			errLog.Printf("GOLDIE_DUPLICATE_THRESHOLD: must be a number in (0, 1], got %q", dt)
+			os.Exit(1)
+		}
+		cfg.DuplicateThreshold = float32(v)
+	}
+	cfg.Namespace = os.Getenv("GOLDIE_NAMESPACE")
+	if *namespace != "" {
+		cfg.Namespace = *namespace
+	}
 	errLog.Printf("DB path: %s", cfg.DBPath)
 	jmLog := cfg.JournalMode
 	if jmLog == "" {
@@ -79,6 +122,9 @@ func main() {
 	}
 	errLog.Printf("Journal mode: %s", jmLog)
 	errLog.Printf("Backend: %s", *backend)
+	if cfg.Namespace != "" {
+		errLog.Printf("Namespace: %s", cfg.Namespace)
+	}
 
 	var emb embedder.Interface
 	var err error
@@ -133,6 +179,7 @@ func main() {
 		os.Exit(1)
 	}
 	cfg.Embedder = emb
+	cfg.Reembed = *reembed
 
 	goldieInstance, err = goldie.New(cfg)
 	if err != nil {
@@ -147,8 +194,26 @@ func main() {
 		os.Exit(1)
 	}
 
+	if flag.NArg() > 0 {
+		err := runCommand(goldieInstance, flag.Args())
+		if err != nil {
+			errLog.Printf("%s: %v", flag.Arg(0), err)
+			goldieInstance.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
 	storeInstance = goldieInstance.Store()
 	queueInstance = queue.New(storeInstance, goldieInstance, errLog)
+	if *reembed {
+		jobID, err := queueInstance.EnqueueReembed()
+		if err != nil {
+			errLog.Printf("Failed to queue re-embedding: %v", err)
+			os.Exit(1)
+		}
+		errLog.Printf("Re-embedding all memories in the background (job_id: %s)", jobID)
+	}
 	queueInstance.Start()
 	defer queueInstance.Stop()
 
@@ -156,9 +221,11 @@ func main() {
 		"goldie-mcp",
 		"2.0.0",
 		server.WithToolCapabilities(true),
+		server.WithPromptCapabilities(true),
 	)
 
 	registerTools(s)
+	registerPrompts(s)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -195,6 +262,8 @@ func main() {
 
 func registerTools(s *server.MCPServer) {
 	allowedTypes := strings.Join(goldie.MemoryTypes, ", ")
+	namespaceArg := mcp.WithString("namespace", mcp.Description("Memory namespace (default: the server's namespace)"))
+	anyNamespaceArg := mcp.WithString("namespace", mcp.Description("Memory namespace (default: the server's namespace; '*' for every namespace)"))
 
 	s.AddTool(
 		mcp.NewTool("remember",
@@ -205,44 +274,152 @@ func registerTools(s *server.MCPServer) {
 			mcp.WithString("description", mcp.Description("One-line summary used to decide relevance later")),
 			mcp.WithString("agent", mcp.Description("The agent that created this memory (e.g. 'claude-opus-4-7')")),
 			mcp.WithString("source", mcp.Description("Where the memory was generated (e.g. file path, editor, URL)")),
+			mcp.WithArray("tags", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Tags for grouping by project, repo or topic (e.g. ['goldie', 'sqlite'])")),
+			mcp.WithString("expires_at", mcp.Description("When the memory expires, for todos and reminders: an RFC 3339 time, a YYYY-MM-DD date, or a duration from now such as '36h' or '7d'. Expired memories are hidden from recall and lists")),
+			mcp.WithString("due_at", mcp.Description("When a todo or reminder is due, in the same forms as expires_at. Todos and reminders start out open; see due_reminders")),
+			mcp.WithString("on_duplicate", mcp.Description("What to do when an existing memory has nearly the same content: warn (default: create it and list the similar memories), error (refuse and name the closest one), merge (append the body and tags to the closest one instead), or ignore (skip the check)")),
+			mcp.WithNumber("importance", mcp.Description("How important the memory is, from 0 (default) to 1; lifts it in recall when ranking boosts are on")),
+			mcp.WithBoolean("pinned", mcp.Description("Load this memory at the start of every session through bootstrap, whatever the query (default: false). Meant for standing user preferences and feedback")),
+			namespaceArg,
 		),
 		handleRemember,
 	)
 
 	s.AddTool(
 		mcp.NewTool("recall",
-			mcp.WithDescription("Semantic recall over the shared multi-agent memory pool. Prefer this over reading local memory files. Returns the most relevant memories with a matched chunk excerpt. Filter by type, agent, or source to narrow scope."),
+			mcp.WithDescription("Semantic recall over the shared multi-agent memory pool. Prefer this over reading local memory files. Returns the most relevant memories with a matched chunk excerpt. By default combines semantic similarity with keyword matching, so exact identifiers (error codes, function names, ticket keys) are found too. Filter by type, agent, source, or tags to narrow scope."),
 			mcp.WithString("query", mcp.Required(), mcp.Description("The topic or question to recall about")),
 			mcp.WithNumber("limit", mcp.Description("Maximum results to return (default: 5, max: 20)")),
+			mcp.WithString("mode", mcp.Description("Ranking mode: vector (semantic only), keyword (exact terms, BM25), or hybrid (default: both, fused)")),
+			mcp.WithNumber("min_score", mcp.Description("Drop semantic matches below this cosine similarity, from -1 to 1, even if fewer than limit results remain (default: the server's minimum score for its embedding backend). Keyword matches are kept")),
+			mcp.WithNumber("diversity", mcp.Description("Trade relevance for variety, from 0 (default: by relevance only) to 1, so near-copies of one memory don't fill every slot. Around 0.3 works well")),
+			mcp.WithBoolean("boost", mcp.Description("Rank by relevance plus boosts for recently updated, often recalled and important memories, weighted per memory type (default: false; implied by any *_boost argument)")),
+			mcp.WithNumber("recency_boost", mcp.Description("Weight, from 0 to 1, of how recently a memory was updated (default: per type, e.g. 0.2 for todos and projects, 0 for user memories)")),
+			mcp.WithString("recency_half_life", mcp.Description("Age at which the recency boost halves, such as '14d' or '72h' (default: per type, e.g. 14d for todos, 1 year for references)")),
+			mcp.WithNumber("usage_boost", mcp.Description("Weight, from 0 to 1, of how often a memory was recalled before (default: 0.05)")),
+			mcp.WithNumber("importance_boost", mcp.Description("Weight, from 0 to 1, of a memory's importance (default: 0.1)")),
+			mcp.WithNumber("max_tokens", mcp.Description("Fit the results' bodies into this many tokens, best first: full bodies for short memories, the matched chunk and its neighbours for long ones, marked with […] where text is left out. Results that don't fit are listed under omitted")),
+			mcp.WithNumber("max_chars", mcp.Description("Like max_tokens, in characters")),
+			mcp.WithNumber("context_chunks", mcp.Description(fmt.Sprintf("Widen each excerpt to the best matching chunk plus this many chunks before and after it, so it doesn't stop mid-thought (default: 0, max: %d)", goldie.MaxContextChunks))),
+			mcp.WithNumber("chunks_per_memory", mcp.Description(fmt.Sprintf("Return up to this many matching chunks of each memory, best first, with their scores and chunk indices (default: none; %d when aggregating; max: %d)", goldie.DefaultChunksPerMemory, goldie.MaxChunksPerMemory))),
+			mcp.WithString("aggregate", mcp.Description("Score each memory's semantic match from its matching chunks: max (default: the best chunk), mean, or sum of the best chunks_per_memory. Sum favours long memories and indexed files with several relevant sections")),
+			mcp.WithBoolean("expand_links", mcp.Description("Add each result's directly linked memories (default: false)")),
 			mcp.WithString("type", mcp.Description("Filter by memory type")),
 			mcp.WithString("agent", mcp.Description("Filter by agent")),
 			mcp.WithString("source", mcp.Description("Filter by source")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with all of these tags")),
+			mcp.WithBoolean("include_expired", mcp.Description("Also match memories past their expires_at (default: false)")),
+			anyNamespaceArg,
 		),
 		handleRecall,
 	)
 
+	s.AddTool(
+		mcp.NewTool("bootstrap",
+			mcp.WithDescription("Load what to keep in mind for this session: every pinned memory for the agent, then the most recently updated memories, within a size budget. Call this once at the start of a session instead of relying on static instruction files."),
+			mcp.WithString("agent", mcp.Description("Only memories written by this agent or by no agent in particular (default: every agent)")),
+			mcp.WithNumber("max_chars", mcp.Description(fmt.Sprintf("Budget for the rendered memories in characters (default: %d)", goldie.DefaultBootstrapMaxChars))),
+			mcp.WithNumber("max_tokens", mcp.Description("Budget in tokens, used instead of max_chars")),
+			mcp.WithNumber("recent", mcp.Description(fmt.Sprintf("How many recent memories to add after the pinned ones (default: %d; 0 for none)", goldie.DefaultBootstrapRecent))),
+			anyNamespaceArg,
+		),
+		handleBootstrap,
+	)
+
+	s.AddTool(
+		mcp.NewTool("get_memory",
+			mcp.WithDescription("Read memories by id or name: the full body, every chunk with its index, the checksum, saved revisions and direct links. Use this instead of recall when you already know which memory you want."),
+			mcp.WithString("id_or_name", mcp.Description("The memory's id or name")),
+			mcp.WithArray("ids_or_names", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Several memory ids or names to read in one call")),
+			anyNamespaceArg,
+		),
+		handleGetMemory,
+	)
+
 	s.AddTool(
 		mcp.NewTool("update_memory",
-			mcp.WithDescription("Update an existing memory in the shared pool by id or name. Use this after `remember` fails with a duplicate-name error. Body and description changes trigger re-embedding. Name is immutable."),
+			mcp.WithDescription("Update an existing memory in the shared pool by id or name. Use this after `remember` fails with a duplicate-name error. Body and description changes trigger re-embedding. To change the name, use rename_memory."),
 			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory's id or name")),
 			mcp.WithString("type", mcp.Description("New type (must be one of: "+allowedTypes+")")),
 			mcp.WithString("description", mcp.Description("New description (pass empty string to clear)")),
 			mcp.WithString("body", mcp.Description("New body content")),
 			mcp.WithString("source", mcp.Description("New source (pass empty string to clear)")),
 			mcp.WithString("agent", mcp.Description("New agent (pass empty string to clear)")),
+			mcp.WithArray("tags", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Replace the memory's tags (pass an empty list to clear)")),
+			mcp.WithString("expires_at", mcp.Description("New expiry: an RFC 3339 time, a YYYY-MM-DD date, or a duration from now such as '7d' (pass empty string to clear)")),
+			mcp.WithString("due_at", mcp.Description("New due date for a todo or reminder, in the same forms as expires_at (pass empty string to clear)")),
+			mcp.WithString("status", mcp.Description("New status for a todo or reminder: open, done, or snoozed")),
+			mcp.WithNumber("importance", mcp.Description("New importance, from 0 to 1")),
+			mcp.WithBoolean("pinned", mcp.Description("Pin or unpin the memory for bootstrap")),
+			anyNamespaceArg,
 		),
 		handleUpdateMemory,
 	)
 
+	s.AddTool(
+		mcp.NewTool("rename_memory",
+			mcp.WithDescription("Give a memory a new name, keeping its id, created_at, provenance and history. The memory is re-embedded since names take part in semantic recall. Fails if the new name is already in use."),
+			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory's id or current name")),
+			mcp.WithString("new_name", mcp.Required(), mcp.Description("The new unique name")),
+			anyNamespaceArg,
+		),
+		handleRenameMemory,
+	)
+
+	s.AddTool(
+		mcp.NewTool("merge_memories",
+			mcp.WithDescription("Fold several memories into one target memory in a single step. The target's body becomes its own body followed by the sources' (concat) or the sources' bodies only (replace), and it takes the union of their tags. The sources are linked from the target with derived_from, then archived to the trash or deleted outright. The target's previous state is saved as a revision."),
+			mcp.WithString("target", mcp.Required(), mcp.Description("Id or name of the memory that remains")),
+			mcp.WithArray("sources", mcp.Required(), mcp.Items(map[string]any{"type": "string"}), mcp.Description("Ids or names of the memories to fold into the target")),
+			mcp.WithString("mode", mcp.Description("concat (default) or replace")),
+			mcp.WithString("originals", mcp.Description("What happens to the sources: archive (default, restorable from the trash) or delete")),
+			anyNamespaceArg,
+		),
+		handleMergeMemories,
+	)
+
+	s.AddTool(
+		mcp.NewTool("find_duplicates",
+			mcp.WithDescription("Scan a namespace for near-duplicate memories in the background and group them into clusters. Returns a job id; the clusters, with similarity scores and the memory to keep listed first, end up in the job's result (see job_status). Follow up with resolve_duplicates to merge or forget selected clusters."),
+			mcp.WithNumber("threshold", mcp.Description("Similarity from 0 to 1 at which two memories count as duplicates (default: the server's duplicate threshold)")),
+			mcp.WithString("type", mcp.Description("Only scan memories of this type")),
+			mcp.WithString("agent", mcp.Description("Only scan memories from this agent")),
+			mcp.WithString("source", mcp.Description("Only scan memories from this source")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only scan memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only scan memories with all of these tags")),
+			namespaceArg,
+		),
+		handleFindDuplicates,
+	)
+
+	s.AddTool(
+		mcp.NewTool("resolve_duplicates",
+			mcp.WithDescription("Resolve clusters from a completed find_duplicates job. For each selected cluster, the first memory is kept and the others are merged into it (see merge_memories) or forgotten. Memories changed away since the scan are skipped."),
+			mcp.WithString("job_id", mcp.Required(), mcp.Description("The find_duplicates job")),
+			mcp.WithArray("clusters", mcp.Required(), mcp.Items(map[string]any{"type": "number"}), mcp.Description("Cluster numbers from the job's result")),
+			mcp.WithString("action", mcp.Required(), mcp.Description("merge or forget")),
+			mcp.WithString("originals", mcp.Description("When merging, what happens to the merged memories: archive (default, restorable from the trash) or delete")),
+			anyNamespaceArg,
+		),
+		handleResolveDuplicates,
+	)
+
 	s.AddTool(
 		mcp.NewTool("forget",
-			mcp.WithDescription("Delete memories from the shared pool. Use this instead of editing local memory files. Provide at least one filter (name, type, agent, source) or a semantic query. With a query, top-N matching memories are deleted (default 5)."),
-			mcp.WithString("name", mcp.Description("Delete the memory with this exact name")),
+			mcp.WithDescription("Forget memories in the shared pool. Use this instead of editing local memory files. Provide at least one filter (name, type, agent, source, tags) or a semantic query. With a query, top-N matching memories are forgotten (default 5). Forgotten memories move to the trash and can be brought back with restore_memory until they are purged. Use dry_run to check what a query would forget first."),
+			mcp.WithString("name", mcp.Description("Forget the memory with this exact name (moved to the trash)")),
 			mcp.WithString("type", mcp.Description("Filter by memory type")),
 			mcp.WithString("agent", mcp.Description("Filter by agent")),
 			mcp.WithString("source", mcp.Description("Filter by source")),
-			mcp.WithString("query", mcp.Description("Semantic query: delete the top matches within the (optional) filter")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with all of these tags")),
+			mcp.WithString("query", mcp.Description("Semantic query: move the top matches within the (optional) filter to the trash")),
 			mcp.WithNumber("limit", mcp.Description("Max matches when query is given (default: 5)")),
+			mcp.WithNumber("min_score", mcp.Description("With a query, ignore semantic matches below this cosine similarity, from -1 to 1 (default: the server's minimum score)")),
+			mcp.WithBoolean("dry_run", mcp.Description("Return the memories that would be forgotten without forgetting them (default: false)")),
+			mcp.WithBoolean("include_expired", mcp.Description("Also match memories past their expires_at (default: false)")),
+			anyNamespaceArg,
 		),
 		handleForget,
 	)
@@ -254,6 +431,14 @@ func registerTools(s *server.MCPServer) {
 			mcp.WithString("agent", mcp.Description("Filter by agent")),
 			mcp.WithString("source", mcp.Description("Filter by source")),
 			mcp.WithNumber("limit", mcp.Description("Maximum results (default: unlimited)")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with all of these tags")),
+			mcp.WithString("status", mcp.Description("Filter todos and reminders by status: open, done, or snoozed")),
+			mcp.WithString("due_before", mcp.Description("Only memories due at or before this time (RFC 3339 time, YYYY-MM-DD date, or duration from now)")),
+			mcp.WithString("due_after", mcp.Description("Only memories due at or after this time (RFC 3339 time, YYYY-MM-DD date, or duration from now)")),
+			mcp.WithBoolean("pinned", mcp.Description("Only pinned memories (default: false)")),
+			mcp.WithBoolean("include_expired", mcp.Description("Also match memories past their expires_at (default: false)")),
+			anyNamespaceArg,
 		),
 		handleListMemories,
 	)
@@ -264,15 +449,165 @@ func registerTools(s *server.MCPServer) {
 			mcp.WithString("type", mcp.Description("Filter by memory type")),
 			mcp.WithString("agent", mcp.Description("Filter by agent")),
 			mcp.WithString("source", mcp.Description("Filter by source")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with all of these tags")),
+			mcp.WithString("status", mcp.Description("Filter todos and reminders by status: open, done, or snoozed")),
+			mcp.WithString("due_before", mcp.Description("Only memories due at or before this time (RFC 3339 time, YYYY-MM-DD date, or duration from now)")),
+			mcp.WithString("due_after", mcp.Description("Only memories due at or after this time (RFC 3339 time, YYYY-MM-DD date, or duration from now)")),
+			mcp.WithBoolean("include_expired", mcp.Description("Also match memories past their expires_at (default: false)")),
+			anyNamespaceArg,
 		),
 		handleCountMemories,
 	)
 
+	s.AddTool(
+		mcp.NewTool("memory_history",
+			mcp.WithDescription("List the saved revisions of a memory, newest first. Every update_memory, re-index or restore saves the state it replaces, so clobbered text can be recovered with memory_diff and restore_memory."),
+			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory's id or name")),
+			anyNamespaceArg,
+		),
+		handleMemoryHistory,
+	)
+
+	s.AddTool(
+		mcp.NewTool("memory_diff",
+			mcp.WithDescription("Show a unified diff of a memory's description and body between two revisions. Revision 0 is the current state."),
+			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory's id or name")),
+			mcp.WithNumber("from", mcp.Description("Revision to diff from (default: the latest saved revision)")),
+			mcp.WithNumber("to", mcp.Description("Revision to diff to (default: 0, the current state)")),
+			anyNamespaceArg,
+		),
+		handleMemoryDiff,
+	)
+
+	s.AddTool(
+		mcp.NewTool("restore_memory",
+			mcp.WithDescription("Bring a forgotten memory back from the trash, and/or roll a memory's description and body back to a saved revision and re-embed it. The replaced state is saved as a new revision, so restores can be undone."),
+			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory's id or name")),
+			mcp.WithNumber("revision", mcp.Description("The revision to restore (see memory_history); required unless the memory is in the trash")),
+			anyNamespaceArg,
+		),
+		handleRestoreMemory,
+	)
+
+	s.AddTool(
+		mcp.NewTool("list_trash",
+			mcp.WithDescription("List forgotten memories still in the trash, most recently forgotten first, with when each will be purged. Bring one back with restore_memory."),
+			mcp.WithString("type", mcp.Description("Filter by memory type")),
+			mcp.WithString("agent", mcp.Description("Filter by agent")),
+			mcp.WithString("source", mcp.Description("Filter by source")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with all of these tags")),
+			mcp.WithNumber("limit", mcp.Description("Maximum results (default: unlimited)")),
+			anyNamespaceArg,
+		),
+		handleListTrash,
+	)
+
+	s.AddTool(
+		mcp.NewTool("due_reminders",
+			mcp.WithDescription("List open todos and reminders due within a window from now, overdue ones included, most urgent first. Use this at the start of a session to pick up tasks other agents left. Mark items done with complete_memory or push them back with snooze_memory."),
+			mcp.WithString("within", mcp.Description("How far ahead to look, as a duration such as '24h', '7d' or '2w' (default: 1d)")),
+			mcp.WithString("type", mcp.Description("Only todo or only reminder memories")),
+			mcp.WithString("agent", mcp.Description("Filter by agent")),
+			mcp.WithString("source", mcp.Description("Filter by source")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with all of these tags")),
+			mcp.WithNumber("limit", mcp.Description("Maximum results (default: unlimited)")),
+			anyNamespaceArg,
+		),
+		handleDueReminders,
+	)
+
+	s.AddTool(
+		mcp.NewTool("complete_memory",
+			mcp.WithDescription("Mark a todo or reminder as done, so due_reminders stops returning it."),
+			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory's id or name")),
+			anyNamespaceArg,
+		),
+		handleCompleteMemory,
+	)
+
+	s.AddTool(
+		mcp.NewTool("snooze_memory",
+			mcp.WithDescription("Push a todo or reminder back: its status becomes snoozed and its due date moves to `until`."),
+			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory's id or name")),
+			mcp.WithString("until", mcp.Description("New due date: an RFC 3339 time, a YYYY-MM-DD date, or a duration from now such as '4h' or '2d' (default: 1d)")),
+			anyNamespaceArg,
+		),
+		handleSnoozeMemory,
+	)
+
+	linkTypes := strings.Join(goldie.LinkTypes, ", ")
+	s.AddTool(
+		mcp.NewTool("link_memories",
+			mcp.WithDescription("Record a typed link from one memory to another, read as `from <type> to`: a feedback memory relates_to the project it refines, a new idea supersedes an old one, two opinions contradict each other, a summary is derived_from its sources. Links are followed by `related` and by `recall` with expand_links."),
+			mcp.WithString("from", mcp.Required(), mcp.Description("Id or name of the memory the link starts at")),
+			mcp.WithString("to", mcp.Required(), mcp.Description("Id or name of the memory the link points to")),
+			mcp.WithString("type", mcp.Description("Link type: "+linkTypes+" (default: relates_to)")),
+			anyNamespaceArg,
+		),
+		handleLinkMemories,
+	)
+
+	s.AddTool(
+		mcp.NewTool("unlink_memories",
+			mcp.WithDescription("Remove links from one memory to another."),
+			mcp.WithString("from", mcp.Required(), mcp.Description("Id or name of the memory the link starts at")),
+			mcp.WithString("to", mcp.Required(), mcp.Description("Id or name of the memory the link points to")),
+			mcp.WithString("type", mcp.Description("Only remove links of this type (default: every type)")),
+			anyNamespaceArg,
+		),
+		handleUnlinkMemories,
+	)
+
+	s.AddTool(
+		mcp.NewTool("related",
+			mcp.WithDescription("Walk links from a memory, in both directions, up to `depth` hops, and return the memories reached with the link that led to each."),
+			mcp.WithString("id_or_name", mcp.Required(), mcp.Description("The memory to start from")),
+			mcp.WithNumber("depth", mcp.Description(fmt.Sprintf("Hops to walk (default: 1, max: %d)", goldie.MaxRelatedDepth))),
+			mcp.WithArray("types", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only follow these link types: "+linkTypes+" (default: all)")),
+			mcp.WithNumber("limit", mcp.Description("Maximum results (default: unlimited)")),
+			anyNamespaceArg,
+		),
+		handleRelated,
+	)
+
+	s.AddTool(
+		mcp.NewTool("export_memories",
+			mcp.WithDescription("Export memories for backups, diffs, or moving memories to another machine. The jsonl format writes one memory per line sorted by name, to `path` when given or inline otherwise. The markdown format writes a directory tree of <type>/<name>.md files with YAML frontmatter plus a MEMORY.md index, for reading and editing in an editor."),
+			mcp.WithString("path", mcp.Description(fmt.Sprintf("File (jsonl) or directory (markdown) to write the export to (default: return jsonl inline, up to %d MiB)", maxInlineExport>>20))),
+			mcp.WithString("format", mcp.Description("jsonl (default) or markdown")),
+			mcp.WithString("name", mcp.Description("Export only the memory with this exact name")),
+			mcp.WithString("type", mcp.Description("Filter by memory type")),
+			mcp.WithString("agent", mcp.Description("Filter by agent")),
+			mcp.WithString("source", mcp.Description("Filter by source")),
+			mcp.WithBoolean("include_embeddings", mcp.Description("Include chunks and their vectors so imports using the same model skip re-embedding (default: false)")),
+			mcp.WithArray("tags_any", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with at least one of these tags")),
+			mcp.WithArray("tags_all", mcp.Items(map[string]any{"type": "string"}), mcp.Description("Only memories with all of these tags")),
+			mcp.WithBoolean("include_expired", mcp.Description("Also match memories past their expires_at (default: false)")),
+			anyNamespaceArg,
+		),
+		handleExportMemories,
+	)
+
+	s.AddTool(
+		mcp.NewTool("import_memories",
+			mcp.WithDescription("Import memories from a JSONL export, or from a Markdown directory tree (a markdown export, or a Claude-style MEMORY.md directory). Memories without vectors, or with vectors from a different embedding model, are re-embedded."),
+			mcp.WithString("path", mcp.Description("JSONL file, or directory of Markdown memories, to import")),
+			mcp.WithString("data", mcp.Description("Inline JSONL to import (alternative to path)")),
+			mcp.WithString("on_conflict", mcp.Description("When a name already exists: skip (default), overwrite, rename, or newer (overwrite only if the import's updated_at is later)")),
+			anyNamespaceArg,
+		),
+		handleImportMemories,
+	)
+
 	s.AddTool(
 		mcp.NewTool("index_file",
 			mcp.WithDescription("Import a file from the filesystem as a reference memory. The memory's name is the absolute path; re-indexing the same path updates in place when the file's checksum changes. Set `agent` to your agent identity (e.g. 'claude-opus-4-7', 'codex') so future sessions can filter by provenance."),
 			mcp.WithString("path", mcp.Required(), mcp.Description("Path to the file")),
 			mcp.WithString("agent", mcp.Description("The agent triggering the import")),
+			namespaceArg,
 		),
 		handleIndexFile,
 	)
@@ -284,6 +619,7 @@ func registerTools(s *server.MCPServer) {
 			mcp.WithString("pattern", mcp.Description("Glob pattern (default: '*')")),
 			mcp.WithBoolean("recursive", mcp.Description("Walk subdirectories (default: false)")),
 			mcp.WithString("agent", mcp.Description("The agent triggering the import")),
+			namespaceArg,
 		),
 		handleIndexDirectory,
 	)
@@ -294,6 +630,7 @@ func registerTools(s *server.MCPServer) {
 			mcp.WithString("id", mcp.Required(), mcp.Description("The job ID")),
 			mcp.WithBoolean("block", mcp.Description("Wait for completion (default: false)")),
 			mcp.WithNumber("timeout", mcp.Description("Timeout in seconds when blocking (default: 30)")),
+			anyNamespaceArg,
 		),
 		handleJobStatus,
 	)
@@ -302,6 +639,7 @@ func registerTools(s *server.MCPServer) {
 		mcp.NewTool("list_jobs",
 			mcp.WithDescription("List indexing jobs, optionally filtered by status"),
 			mcp.WithString("status", mcp.Description("queued, processing, completed, failed")),
+			anyNamespaceArg,
 		),
 		handleListJobs,
 	)
@@ -310,11 +648,26 @@ func registerTools(s *server.MCPServer) {
 		mcp.NewTool("clear_queue",
 			mcp.WithDescription("Clear jobs from the queue"),
 			mcp.WithString("status", mcp.Required(), mcp.Description("queued, completed, failed, or all")),
+			anyNamespaceArg,
 		),
 		handleClearQueue,
 	)
 }
 
+func registerPrompts(s *server.MCPServer) {
+	s.AddPrompt(
+		mcp.NewPrompt("bootstrap",
+			mcp.WithPromptDescription("Start a session with the pinned and recent memories from the shared pool, as the bootstrap tool returns them"),
+			mcp.WithArgument("agent", mcp.ArgumentDescription("Only memories written by this agent or by no agent in particular")),
+			mcp.WithArgument("namespace", mcp.ArgumentDescription("Memory namespace (default: the server's namespace)")),
+			mcp.WithArgument("max_chars", mcp.ArgumentDescription(fmt.Sprintf("Budget in characters (default: %d)", goldie.DefaultBootstrapMaxChars))),
+			mcp.WithArgument("max_tokens", mcp.ArgumentDescription("Budget in tokens, used instead of max_chars")),
+			mcp.WithArgument("recent", mcp.ArgumentDescription(fmt.Sprintf("How many recent memories to add (default: %d)", goldie.DefaultBootstrapRecent))),
+		),
+		handleBootstrapPrompt,
+	)
+}
+
 // --- helpers ---
 
 func argString(args map[string]any, key string) string {
@@ -327,6 +680,95 @@ func argBool(args map[string]any, key string) bool {
 	return v
 }
 
+// argStrings reads a string list argument. Clients that can't send arrays
+// may pass a comma-separated string instead.
+func argStrings(args map[string]any, key string) []string {
+	var out []string
+	switch v := args[key].(type) {
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	case []string:
+		for _, s := range v {
+			if strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	}
+	return out
+}
+
+// argMinScore returns the min_score argument, or nil if it is absent.
+func argMinScore(args map[string]any) *float32 {
+	v, ok := args["min_score"].(float64)
+	if !ok {
+		return nil
+	}
+	score := float32(v)
+	return &score
+}
+
+// argBoost returns the recall ranking boosts asked for, or nil if boosts
+// are off.
+func argBoost(args map[string]any) (*goldie.BoostOptions, error) {
+	opts := &goldie.BoostOptions{}
+	on := argBool(args, "boost")
+	for key, w := range map[string]**float32{
+		"recency_boost":    &opts.Recency,
+		"usage_boost":      &opts.Usage,
+		"importance_boost": &opts.Importance,
+	} {
+		if v, ok := args[key].(float64); ok {
+			f := float32(v)
+			*w, on = &f, true
+		}
+	}
+	if v := argString(args, "recency_half_life"); v != "" {
+		d, err := goldie.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("recency_half_life must be positive: %q", v)
+		}
+		opts.HalfLife, on = d, true
+	}
+	if !on {
+		return nil, nil
+	}
+	return opts, nil
+}
+
+// argBudget returns the max_tokens / max_chars budget arguments.
+func argBudget(args map[string]any) goldie.Budget {
+	return goldie.Budget{
+		MaxChars:  argInt(args, "max_chars", 0),
+		MaxTokens: argInt(args, "max_tokens", 0),
+	}
+}
+
+// argInts returns the whole numbers in an array argument.
+func argInts(args map[string]any, key string) []int {
+	var out []int
+	if v, ok := args[key].([]any); ok {
+		for _, item := range v {
+			if n, ok := item.(float64); ok {
+				out = append(out, int(n))
+			}
+		}
+	}
+	return out
+}
+
 func argInt(args map[string]any, key string, def int) int {
 	if v, ok := args[key].(float64); ok {
 		return int(v)
@@ -334,32 +776,111 @@ func argInt(args map[string]any, key string, def int) int {
 	return def
 }
 
+// goldieFor returns the instance scoped to the request's namespace argument,
+// or the server's default namespace.
+func goldieFor(args map[string]any) (*goldie.Goldie, error) {
+	ns := argString(args, "namespace")
+	if ns == "" {
+		return goldieInstance, nil
+	}
+	return goldieInstance.WithNamespace(ns)
+}
+
+// jobNamespace returns the namespace whose jobs a job tool sees.
+func jobNamespace(args map[string]any) (string, error) {
+	g, err := goldieFor(args)
+	if err != nil {
+		return "", err
+	}
+	return g.Namespace(), nil
+}
+
 func filterFromArgs(args map[string]any) store.MemoryFilter {
 	return store.MemoryFilter{
-		Name:   argString(args, "name"),
-		Type:   argString(args, "type"),
-		Agent:  argString(args, "agent"),
-		Source: argString(args, "source"),
+		Name:    argString(args, "name"),
+		Type:    argString(args, "type"),
+		Agent:   argString(args, "agent"),
+		Source:  argString(args, "source"),
+		TagsAny: argStrings(args, "tags_any"),
+		TagsAll: argStrings(args, "tags_all"),
+
+		IncludeExpired: argBool(args, "include_expired"),
+	}
+}
+
+// argTime parses a time argument given as accepted by goldie.ParseTime. An
+// absent or empty argument yields the zero time.
+func argTime(args map[string]any, key string) (time.Time, error) {
+	v := argString(args, key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := goldie.ParseTime(v, time.Now())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", key, err)
 	}
+	return t, nil
+}
+
+// taskFilterFromArgs adds the status and due date filters to filter.
+func taskFilterFromArgs(args map[string]any, filter *store.MemoryFilter) error {
+	filter.Status = argString(args, "status")
+	if filter.Status != "" {
+		if err := goldie.ValidateMemoryStatus(filter.Status); err != nil {
+			return err
+		}
+	}
+	var err error
+	if filter.DueBefore, err = argTime(args, "due_before"); err != nil {
+		return err
+	}
+	filter.DueAfter, err = argTime(args, "due_after")
+	return err
 }
 
 func memorySummary(m store.Memory) map[string]any {
-	return map[string]any{
+	summary := map[string]any{
 		"id":          m.ID,
+		"namespace":   m.Namespace,
 		"name":        m.Name,
 		"type":        m.Type,
 		"description": m.Description,
 		"agent":       m.Agent,
 		"source":      m.Source,
+		"tags":        m.Tags,
 		"created_at":  m.CreatedAt,
 		"updated_at":  m.UpdatedAt,
 	}
+	if m.ExpiresAt != nil {
+		summary["expires_at"] = m.ExpiresAt
+	}
+	if m.DueAt != nil {
+		summary["due_at"] = m.DueAt
+	}
+	if m.Status != "" {
+		summary["status"] = m.Status
+	}
+	if m.Importance != 0 {
+		summary["importance"] = m.Importance
+	}
+	if m.Pinned {
+		summary["pinned"] = true
+	}
+	if m.RecallCount > 0 {
+		summary["recall_count"] = m.RecallCount
+		summary["last_recalled_at"] = m.LastRecalledAt
+	}
+	return summary
 }
 
 // --- memory handlers ---
 
 func handleRemember(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	in := goldie.RememberInput{
 		Name:        argString(args, "name"),
 		Type:        argString(args, "type"),
@@ -367,133 +888,906 @@ func handleRemember(_ context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 		Description: argString(args, "description"),
 		Agent:       argString(args, "agent"),
 		Source:      argString(args, "source"),
+		Tags:        argStrings(args, "tags"),
 	}
+	if in.ExpiresAt, err = argTime(args, "expires_at"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if in.DueAt, err = argTime(args, "due_at"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if v, ok := args["importance"].(float64); ok {
+		in.Importance = float32(v)
+	}
+	in.Pinned = argBool(args, "pinned")
 
-	m, err := goldieInstance.Remember(in)
+	res, err := g.RememberChecked(in, argString(args, "on_duplicate"))
 	if err != nil {
+		if errors.Is(err, goldie.ErrDuplicateMemory) {
+			best := res.Duplicates[0]
+			return mcp.NewToolResultError(fmt.Sprintf("memory %q has nearly the same content (similarity %.2f) — recall it and use update_memory, or pass on_duplicate=warn to create this one anyway", best.Memory.Name, best.Similarity)), nil
+		}
 		if goldie.IsErrMemoryNameExists(err) {
 			return mcp.NewToolResultError(fmt.Sprintf("memory %q already exists — recall it and use update_memory to change it", in.Name)), nil
 		}
+		if errors.Is(err, goldie.ErrMemoryInTrash) {
+			return mcp.NewToolResultError(fmt.Sprintf("memory %q was forgotten and is in the trash — bring it back with restore_memory and use update_memory to change it", in.Name)), nil
+		}
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+	m := res.Memory
+	if res.Merged {
+		return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+			"success":     true,
+			"merged_into": m.Name,
+			"memory":      memorySummary(*m),
+			"message":     formatMessage("Merged into similar memory %q (id: %s)", m.Name, m.ID),
+		})), nil
+	}
+	out := map[string]any{
 		"success": true,
 		"memory":  memorySummary(*m),
 		"message": formatMessage("Remembered %q (id: %s)", m.Name, m.ID),
-	})), nil
+	}
+	if len(res.Duplicates) > 0 {
+		dups := make([]map[string]any, len(res.Duplicates))
+		for i, d := range res.Duplicates {
+			dups[i] = map[string]any{
+				"id":         d.Memory.ID,
+				"name":       d.Memory.Name,
+				"similarity": d.Similarity,
+			}
+		}
+		out["duplicates"] = dups
+		out["message"] = formatMessage("Remembered %q (id: %s), but %d similar memories exist — consider merge_memories", m.Name, m.ID, len(dups))
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(out)), nil
 }
 
 func handleRecall(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	query := argString(args, "query")
 	if query == "" {
 		return mcp.NewToolResultError("query is required"), nil
 	}
 	limit := max(min(argInt(args, "limit", 5), 20), 1)
-
 	filter := store.MemoryFilter{
-		Type:   argString(args, "type"),
-		Agent:  argString(args, "agent"),
-		Source: argString(args, "source"),
+		Type:    argString(args, "type"),
+		Agent:   argString(args, "agent"),
+		Source:  argString(args, "source"),
+		TagsAny: argStrings(args, "tags_any"),
+		TagsAll: argStrings(args, "tags_all"),
+
+		IncludeExpired: argBool(args, "include_expired"),
 	}
 
-	results, err := goldieInstance.RecallMemory(query, limit, filter)
+	minScore := argMinScore(args)
+	diversity, _ := args["diversity"].(float64)
+	boost, err := argBoost(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	budget := argBudget(args)
+	aggregate := argString(args, "aggregate")
+	chunksPerMemory := argInt(args, "chunks_per_memory", 0)
+
+	packed, omitted, err := g.RecallPacked(query, goldie.RecallOptions{
+		Limit:     limit,
+		Filter:    filter,
+		Mode:      argString(args, "mode"),
+		MinScore:  minScore,
+		Diversity: float32(diversity),
+		Boost:     boost,
+
+		ContextChunks:   argInt(args, "context_chunks", 0),
+		ChunksPerMemory: chunksPerMemory,
+		Aggregate:       aggregate,
+	}, budget)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("recall failed: %v", err)), nil
 	}
-	if len(results) == 0 {
+	if len(packed) == 0 && len(omitted) == 0 {
 		return mcp.NewToolResultText(formatMessage("No memories found for %q", query)), nil
 	}
 
-	formatted := make([]map[string]any, 0, len(results))
-	for _, r := range results {
+	var links map[string][]goldie.LinkedMemory
+	if argBool(args, "expand_links") {
+		ids := make([]string, len(packed))
+		for i, r := range packed {
+			ids[i] = r.Memory.ID
+		}
+		if links, err = g.DirectLinks(ids); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("expanding links: %v", err)), nil
+		}
+	}
+
+	formatted := make([]map[string]any, 0, len(packed))
+	used := 0
+	for _, r := range packed {
 		entry := memorySummary(r.Memory)
-		entry["body"] = r.Memory.Body
-		entry["excerpt"] = r.Excerpt
+		entry["body"] = r.Content
+		if budget.IsZero() {
+			entry["excerpt"] = r.Excerpt
+		} else if r.Truncated {
+			entry["truncated"] = true
+		}
+		entry["chunk_index"] = r.ChunkIndex
+		if r.HeadingPath != "" {
+			entry["heading_path"] = r.HeadingPath
+		}
+		entry["matched_chunks"] = r.MatchedIndexes()
+		if r.ExcerptChunks != nil {
+			entry["excerpt_chunks"] = r.ExcerptChunks
+		}
+		if chunksPerMemory > 0 || aggregate != "" {
+			chunks := make([]map[string]any, 0, len(r.Chunks))
+			for _, c := range r.Chunks {
+				chunk := map[string]any{"index": c.Index, "score": c.Score, "metric": c.Metric}
+				if c.HeadingPath != "" {
+					chunk["heading_path"] = c.HeadingPath
+				}
+				if budget.IsZero() {
+					chunk["excerpt"] = c.Content
+				}
+				chunks = append(chunks, chunk)
+			}
+			entry["chunks"] = chunks
+		}
+		used += r.Size
 		entry["score"] = r.Score
+		entry["metric"] = r.Metric
+		if r.Metric == store.MetricCosine {
+			entry["distance"] = r.Distance
+		}
+		if boost != nil {
+			entry["boost"] = r.Boost
+		}
+		if links != nil {
+			linked := make([]map[string]any, 0, len(links[r.Memory.ID]))
+			for _, l := range links[r.Memory.ID] {
+				linked = append(linked, linkSummary(l))
+			}
+			entry["links"] = linked
+		}
 		formatted = append(formatted, entry)
 	}
 
-	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
-		"query":   query,
-		"count":   len(results),
-		"results": formatted,
-		"message": formatMessage("Recalled %d memory(ies) for %q", len(results), query),
-	})), nil
-}
-
-func handleUpdateMemory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	args := request.Params.Arguments
-	idOrName := argString(args, "id_or_name")
-	if idOrName == "" {
-		return mcp.NewToolResultError("id_or_name is required"), nil
-	}
-
-	patch := goldie.UpdateMemoryInput{
-		Type: argString(args, "type"),
-	}
-	if v, present := args["description"].(string); present {
-		patch.Description = &v
+	if minScore == nil {
+		v := g.MinScore()
+		minScore = &v
 	}
-	if v, present := args["body"].(string); present {
-		patch.Body = &v
+	out := map[string]any{
+		"query":     query,
+		"count":     len(formatted),
+		"min_score": *minScore,
+		"results":   formatted,
+		"message":   formatMessage("Recalled %d memory(ies) for %q", len(formatted), query),
 	}
-	if v, present := args["source"].(string); present {
-		patch.Source = &v
+	if aggregate != "" {
+		out["aggregate"] = aggregate
 	}
-	if v, present := args["agent"].(string); present {
-		patch.Agent = &v
+	if !budget.IsZero() {
+		out["budget"] = map[string]any{"unit": budget.Unit(), "limit": budget.Limit(), "used": used}
 	}
-
-	m, err := goldieInstance.UpdateMemory(idOrName, patch)
-	if err != nil {
-		return mcp.NewToolResultError(err.Error()), nil
+	if len(omitted) > 0 {
+		names := make([]string, len(omitted))
+		for i, r := range omitted {
+			names[i] = r.Memory.Name
+		}
+		out["omitted"] = names
+		out["message"] = formatMessage("Recalled %d memory(ies) for %q; %d more did not fit the budget", len(formatted), query, len(omitted))
 	}
-
-	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
-		"success": true,
-		"memory":  memorySummary(*m),
-		"message": formatMessage("Updated %q", m.Name),
-	})), nil
+	return mcp.NewToolResultText(safeJSONMarshal(out)), nil
 }
 
-func handleForget(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func handleBootstrap(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.Params.Arguments
-	filter := filterFromArgs(args)
-	query := argString(args, "query")
-	limit := argInt(args, "limit", 5)
-
-	deleted, err := goldieInstance.ForgetMemory(filter, query, limit)
+	g, err := goldieFor(args)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
-	if len(deleted) == 0 {
-		return mcp.NewToolResultText(formatMessage("No memories matched")), nil
+	res, err := g.Bootstrap(bootstrapOptions(args))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	summaries := make([]map[string]any, 0, len(deleted))
-	for _, m := range deleted {
+	// Bodies only go out once, in text, which is what the budget measures.
+	entries := func(memories []store.Memory) []map[string]any {
+		out := make([]map[string]any, 0, len(memories))
+		for _, m := range memories {
+			out = append(out, memorySummary(m))
+		}
+		return out
+	}
+	omitted := make([]string, len(res.Omitted))
+	for i, m := range res.Omitted {
+		omitted[i] = m.Name
+	}
+	out := map[string]any{
+		"pinned":  entries(res.Pinned),
+		"recent":  entries(res.Recent),
+		"text":    res.Text,
+		"size":    res.Size,
+		"unit":    argBudget(args).Unit(),
+		"message": formatMessage("Loaded %d pinned and %d recent memory(ies)", len(res.Pinned), len(res.Recent)),
+	}
+	if len(omitted) > 0 {
+		out["omitted"] = omitted
+		out["message"] = formatMessage("Loaded %d pinned and %d recent memory(ies); %d did not fit the budget", len(res.Pinned), len(res.Recent), len(omitted))
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(out)), nil
+}
+
+func handleBootstrapPrompt(_ context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	// Prompt arguments are strings; convert them to tool argument types.
+	args := make(map[string]any, len(request.Params.Arguments))
+	for k, v := range request.Params.Arguments {
+		switch k {
+		case "max_chars", "max_tokens", "recent":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a whole number, got %q", k, v)
+			}
+			args[k] = float64(n)
+		default:
+			args[k] = v
+		}
+	}
+	g, err := goldieFor(args)
+	if err != nil {
+		return nil, err
+	}
+	res, err := g.Bootstrap(bootstrapOptions(args))
+	if err != nil {
+		return nil, err
+	}
+	text := "No pinned or recent memories yet. Save what should carry over to later sessions with remember."
+	if res.Text != "" {
+		text = "Keep these memories from the shared pool in mind for this session. Recall more with the recall tool when a topic comes up.\n\n" + res.Text
+	}
+	return mcp.NewGetPromptResult("Pinned and recent memories", []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	}), nil
+}
+
+// bootstrapOptions reads the bootstrap tool and prompt arguments. A recent
+// count of 0 means none, unlike the goldie default.
+func bootstrapOptions(args map[string]any) goldie.BootstrapOptions {
+	opts := goldie.BootstrapOptions{
+		Agent:  argString(args, "agent"),
+		Budget: argBudget(args),
+		Recent: argInt(args, "recent", goldie.DefaultBootstrapRecent),
+	}
+	if opts.Recent == 0 {
+		opts.Recent = -1
+	}
+	return opts
+}
+
+func handleGetMemory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	refs := argStrings(args, "ids_or_names")
+	if v := argString(args, "id_or_name"); v != "" {
+		refs = append([]string{v}, refs...)
+	}
+	if len(refs) == 0 {
+		return mcp.NewToolResultError("id_or_name or ids_or_names is required"), nil
+	}
+
+	memories := make([]map[string]any, 0, len(refs))
+	notFound := []string{}
+	for _, ref := range refs {
+		d, err := g.GetMemoryDetail(ref)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if d == nil {
+			notFound = append(notFound, ref)
+			continue
+		}
+		memories = append(memories, memoryDetail(d))
+	}
+	if len(memories) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("memory not found: %s", strings.Join(notFound, ", "))), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"count":     len(memories),
+		"memories":  memories,
+		"not_found": notFound,
+		"message":   formatMessage("Found %d of %d memory(ies)", len(memories), len(refs)),
+	})), nil
+}
+
+// memoryDetail describes a memory in full for get_memory.
+func memoryDetail(d *goldie.MemoryDetail) map[string]any {
+	entry := memorySummary(d.Memory)
+	entry["body"] = d.Memory.Body
+	if d.Memory.Checksum != "" {
+		entry["checksum"] = d.Memory.Checksum
+	}
+	chunks := make([]map[string]any, 0, len(d.Chunks))
+	for _, c := range d.Chunks {
+		chunk := map[string]any{"index": c.Index, "content": c.Content}
+		if c.HeadingPath != "" {
+			chunk["heading_path"] = c.HeadingPath
+		}
+		chunks = append(chunks, chunk)
+	}
+	entry["chunks"] = chunks
+	if len(d.Revisions) > 0 {
+		entry["revisions"] = map[string]any{
+			"count":       len(d.Revisions),
+			"latest":      d.Revisions[0].Revision,
+			"replaced_at": d.Revisions[0].ReplacedAt,
+		}
+	}
+	if len(d.Links) > 0 {
+		links := make([]map[string]any, 0, len(d.Links))
+		for _, l := range d.Links {
+			links = append(links, linkSummary(l))
+		}
+		entry["links"] = links
+	}
+	return entry
+}
+
+func handleUpdateMemory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName := argString(args, "id_or_name")
+	if idOrName == "" {
+		return mcp.NewToolResultError("id_or_name is required"), nil
+	}
+
+	patch := goldie.UpdateMemoryInput{
+		Type: argString(args, "type"),
+	}
+	if v, present := args["description"].(string); present {
+		patch.Description = &v
+	}
+	if v, present := args["body"].(string); present {
+		patch.Body = &v
+	}
+	if v, present := args["source"].(string); present {
+		patch.Source = &v
+	}
+	if v, present := args["agent"].(string); present {
+		patch.Agent = &v
+	}
+	if _, present := args["tags"]; present {
+		tags := argStrings(args, "tags")
+		patch.Tags = &tags
+	}
+	if _, present := args["expires_at"].(string); present {
+		t, err := argTime(args, "expires_at")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		patch.ExpiresAt = &t
+	}
+	if _, present := args["due_at"].(string); present {
+		t, err := argTime(args, "due_at")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		patch.DueAt = &t
+	}
+	if v, present := args["status"].(string); present {
+		patch.Status = &v
+	}
+	if v, present := args["importance"].(float64); present {
+		importance := float32(v)
+		patch.Importance = &importance
+	}
+	if v, present := args["pinned"].(bool); present {
+		patch.Pinned = &v
+	}
+
+	m, err := g.UpdateMemory(idOrName, patch)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"memory":  memorySummary(*m),
+		"message": formatMessage("Updated %q", m.Name),
+	})), nil
+}
+
+func handleRenameMemory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName, newName := argString(args, "id_or_name"), argString(args, "new_name")
+	if idOrName == "" || newName == "" {
+		return mcp.NewToolResultError("id_or_name and new_name are required"), nil
+	}
+
+	m, err := g.RenameMemory(idOrName, newName)
+	if err != nil {
+		if goldie.IsErrMemoryNameExists(err) {
+			return mcp.NewToolResultError(fmt.Sprintf("memory %q already exists — pick another name or merge the two with merge_memories", newName)), nil
+		}
+		if errors.Is(err, goldie.ErrMemoryInTrash) {
+			return mcp.NewToolResultError(fmt.Sprintf("memory %q was forgotten and is in the trash — pick another name", newName)), nil
+		}
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"memory":  memorySummary(*m),
+		"message": formatMessage("Renamed %q to %q", idOrName, m.Name),
+	})), nil
+}
+
+func handleMergeMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	in := goldie.MergeInput{
+		Target:  argString(args, "target"),
+		Sources: argStrings(args, "sources"),
+		Mode:    argString(args, "mode"),
+	}
+	if in.Target == "" || len(in.Sources) == 0 {
+		return mcp.NewToolResultError("target and sources are required"), nil
+	}
+	switch argString(args, "originals") {
+	case "", "archive":
+	case "delete":
+		in.DeleteSources = true
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("invalid originals %q (allowed: archive, delete)", argString(args, "originals"))), nil
+	}
+
+	m, err := g.MergeMemories(in)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	verb := "archived"
+	if in.DeleteSources {
+		verb = "deleted"
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"memory":  memorySummary(*m),
+		"sources": in.Sources,
+		"message": formatMessage("Merged %d memory(ies) into %q and %s the originals", len(in.Sources), m.Name, verb),
+	})), nil
+}
+
+func handleFindDuplicates(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if g.Namespace() == store.AllNamespaces {
+		return mcp.NewToolResultError(goldie.ErrAllNamespaces.Error()), nil
+	}
+	params := queue.FindDuplicatesParams{
+		Type:    argString(args, "type"),
+		Agent:   argString(args, "agent"),
+		Source:  argString(args, "source"),
+		TagsAny: argStrings(args, "tags_any"),
+		TagsAll: argStrings(args, "tags_all"),
+	}
+	if t, ok := args["threshold"].(float64); ok {
+		if t <= 0 || t > 1 {
+			return mcp.NewToolResultError(fmt.Sprintf("threshold must be in (0, 1], got %v", t)), nil
+		}
+		params.Threshold = float32(t)
+	}
+
+	jobID, err := queueInstance.EnqueueFindDuplicates(g.Namespace(), params)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to queue job: %v", err)), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"job_id":  jobID,
+		"status":  store.JobStatusQueued,
+		"message": formatMessage("Job queued for finding duplicates in %s (job_id: %s)", g.Namespace(), jobID),
+	})), nil
+}
+
+func handleResolveDuplicates(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	id := argString(args, "job_id")
+	numbers := argInts(args, "clusters")
+	action := argString(args, "action")
+	if id == "" || len(numbers) == 0 {
+		return mcp.NewToolResultError("job_id and clusters are required"), nil
+	}
+	if action != goldie.ResolveMerge && action != goldie.ResolveForget {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid action %q (allowed: %s, %s)", action, goldie.ResolveMerge, goldie.ResolveForget)), nil
+	}
+	var deleteOriginals bool
+	switch argString(args, "originals") {
+	case "", "archive":
+	case "delete":
+		deleteOriginals = true
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("invalid originals %q (allowed: archive, delete)", argString(args, "originals"))), nil
+	}
+	ns, err := jobNamespace(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	job, err := storeInstance.GetJob(id)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("getting job failed: %v", err)), nil
+	}
+	if job == nil || job.Type != store.JobTypeFindDups || !jobVisible(job, ns) {
+		return mcp.NewToolResultError(fmt.Sprintf("find_duplicates job not found: %s", id)), nil
+	}
+	if job.Status != store.JobStatusCompleted {
+		return mcp.NewToolResultError(fmt.Sprintf("job %s is %s; wait for it with job_status", id, job.Status)), nil
+	}
+	var result queue.FindDuplicatesResult
+	if err := json.Unmarshal([]byte(job.Result), &result); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("reading job result: %v", err)), nil
+	}
+	g, err := goldieInstance.WithNamespace(job.Namespace)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var resolved, failed int
+	out := make([]map[string]any, 0, len(numbers))
+	for _, n := range numbers {
+		entry := map[string]any{"cluster": n}
+		out = append(out, entry)
+		if n < 1 || n > len(result.Clusters) {
+			entry["error"] = fmt.Sprintf("no cluster %d (the job found %d)", n, len(result.Clusters))
+			failed++
+			continue
+		}
+		ids := make([]string, len(result.Clusters[n-1].Memories))
+		for i, m := range result.Clusters[n-1].Memories {
+			ids[i] = m.ID
+		}
+		kept, others, err := g.ResolveDuplicates(ids, action, deleteOriginals)
+		if err != nil {
+			entry["error"] = err.Error()
+			failed++
+			continue
+		}
+		names := make([]string, len(others))
+		for i, m := range others {
+			names[i] = m.Name
+		}
+		entry["kept"] = kept.Name
+		if action == goldie.ResolveMerge {
+			entry["merged"] = names
+		} else {
+			entry["forgotten"] = names
+		}
+		resolved++
+	}
+
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success":  resolved > 0,
+		"clusters": out,
+		"message":  formatMessage("Resolved %d cluster(s) with %s, %d failed", resolved, action, failed),
+	})), nil
+}
+
+func handleForget(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	filter := filterFromArgs(args)
+	query := goldie.ForgetQuery{
+		Text:  argString(args, "query"),
+		Limit: argInt(args, "limit", 5),
+	}
+	query.MinScore = argMinScore(args)
+	dryRun := argBool(args, "dry_run")
+
+	var deleted []store.Memory
+	if dryRun {
+		deleted, err = g.PreviewForget(filter, query)
+	} else {
+		deleted, err = g.ForgetMemory(filter, query)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(deleted) == 0 {
+		return mcp.NewToolResultText(formatMessage("No memories matched")), nil
+	}
+
+	summaries := make([]map[string]any, 0, len(deleted))
+	for _, m := range deleted {
 		summaries = append(summaries, memorySummary(m))
 	}
+	msg := formatMessage("Forgot %d memory(ies); restore_memory brings them back from the trash", len(deleted))
+	if dryRun {
+		msg = formatMessage("Would forget %d memory(ies)", len(deleted))
+	}
 	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
 		"success": true,
+		"dry_run": dryRun,
 		"deleted": summaries,
 		"count":   len(deleted),
-		"message": formatMessage("Forgot %d memory(ies)", len(deleted)),
+		"message": msg,
+	})), nil
+}
+
+func handleListTrash(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	memories, err := g.ListTrash(filterFromArgs(args), argInt(args, "limit", 0))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(memories) == 0 {
+		return mcp.NewToolResultText(formatMessage("The trash is empty")), nil
+	}
+	retention := g.TrashRetention()
+	summaries := make([]map[string]any, 0, len(memories))
+	for _, m := range memories {
+		entry := memorySummary(m)
+		entry["deleted_at"] = m.DeletedAt
+		if retention >= 0 {
+			entry["purge_at"] = m.DeletedAt.Add(retention)
+		}
+		summaries = append(summaries, entry)
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"count":    len(memories),
+		"memories": summaries,
+		"message":  formatMessage("%d memory(ies) in the trash", len(memories)),
+	})), nil
+}
+
+func handleDueReminders(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	within := 24 * time.Hour
+	if v := argString(args, "within"); v != "" {
+		if within, err = goldie.ParseDuration(v); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	memories, err := g.DueReminders(within, filterFromArgs(args), argInt(args, "limit", 0))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if len(memories) == 0 {
+		return mcp.NewToolResultText(formatMessage("Nothing due within %s", within)), nil
+	}
+	now := time.Now()
+	overdue := 0
+	summaries := make([]map[string]any, 0, len(memories))
+	for _, m := range memories {
+		entry := memorySummary(m)
+		entry["body"] = m.Body
+		entry["overdue"] = m.DueAt.Before(now)
+		if m.DueAt.Before(now) {
+			overdue++
+		}
+		summaries = append(summaries, entry)
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"count":    len(memories),
+		"overdue":  overdue,
+		"memories": summaries,
+		"message":  formatMessage("%d item(s) due within %s, %d overdue", len(memories), within, overdue),
+	})), nil
+}
+
+func handleCompleteMemory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName := argString(args, "id_or_name")
+	if idOrName == "" {
+		return mcp.NewToolResultError("id_or_name is required"), nil
+	}
+
+	m, err := g.CompleteMemory(idOrName)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"memory":  memorySummary(*m),
+		"message": formatMessage("Marked %q done", m.Name),
+	})), nil
+}
+
+func handleSnoozeMemory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName := argString(args, "id_or_name")
+	if idOrName == "" {
+		return mcp.NewToolResultError("id_or_name is required"), nil
+	}
+	until, err := argTime(args, "until")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if until.IsZero() {
+		until = time.Now().Add(24 * time.Hour)
+	}
+
+	m, err := g.SnoozeMemory(idOrName, until)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"memory":  memorySummary(*m),
+		"message": formatMessage("Snoozed %q until %s", m.Name, m.DueAt.Local().Format(time.RFC1123)),
+	})), nil
+}
+
+// linkSummary describes a linked memory without its body.
+func linkSummary(l goldie.LinkedMemory) map[string]any {
+	if l.Missing {
+		return map[string]any{"link_type": l.Type, "direction": l.Direction, "id": l.Memory.ID, "missing": true}
+	}
+	return map[string]any{
+		"link_type":   l.Type,
+		"direction":   l.Direction,
+		"id":          l.Memory.ID,
+		"name":        l.Memory.Name,
+		"type":        l.Memory.Type,
+		"description": l.Memory.Description,
+	}
+}
+
+func handleLinkMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	fromRef, toRef := argString(args, "from"), argString(args, "to")
+	if fromRef == "" || toRef == "" {
+		return mcp.NewToolResultError("from and to are required"), nil
+	}
+	linkType := argString(args, "type")
+	if linkType == "" {
+		linkType = goldie.LinkRelatesTo
+	}
+
+	from, to, created, err := g.LinkMemories(fromRef, toRef, linkType)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	msg := formatMessage("Linked %q %s %q", from.Name, linkType, to.Name)
+	if !created {
+		msg = formatMessage("%q already %s %q", from.Name, linkType, to.Name)
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"from":    memorySummary(*from),
+		"to":      memorySummary(*to),
+		"type":    linkType,
+		"created": created,
+		"message": msg,
+	})), nil
+}
+
+func handleUnlinkMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	fromRef, toRef := argString(args, "from"), argString(args, "to")
+	if fromRef == "" || toRef == "" {
+		return mcp.NewToolResultError("from and to are required"), nil
+	}
+
+	n, err := g.UnlinkMemories(fromRef, toRef, argString(args, "type"))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"count":   n,
+		"message": formatMessage("Removed %d link(s) from %q to %q", n, fromRef, toRef),
+	})), nil
+}
+
+func handleRelated(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName := argString(args, "id_or_name")
+	if idOrName == "" {
+		return mcp.NewToolResultError("id_or_name is required"), nil
+	}
+
+	origin, related, err := g.Related(idOrName, goldie.RelatedOptions{
+		Depth: argInt(args, "depth", 1),
+		Types: argStrings(args, "types"),
+		Limit: argInt(args, "limit", 0),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	entries := make([]map[string]any, 0, len(related))
+	for _, r := range related {
+		var entry map[string]any
+		if r.Missing {
+			entry = map[string]any{"id": r.Memory.ID, "missing": true}
+		} else {
+			entry = memorySummary(r.Memory)
+		}
+		entry["link_type"] = r.Type
+		entry["direction"] = r.Direction
+		entry["via"] = r.Via
+		entry["depth"] = r.Depth
+		entries = append(entries, entry)
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"memory":  memorySummary(*origin),
+		"count":   len(related),
+		"related": entries,
+		"message": formatMessage("%d memory(ies) related to %q", len(related), origin.Name),
 	})), nil
 }
 
 func handleListMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	filter := store.MemoryFilter{
-		Type:   argString(args, "type"),
-		Agent:  argString(args, "agent"),
-		Source: argString(args, "source"),
+		Type:    argString(args, "type"),
+		Agent:   argString(args, "agent"),
+		Source:  argString(args, "source"),
+		TagsAny: argStrings(args, "tags_any"),
+		TagsAll: argStrings(args, "tags_all"),
+		Pinned:  argBool(args, "pinned"),
+
+		IncludeExpired: argBool(args, "include_expired"),
+	}
+	if err := taskFilterFromArgs(args, &filter); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 	limit := argInt(args, "limit", 0)
 
-	memories, err := goldieInstance.ListMemories(filter, limit)
+	memories, err := g.ListMemories(filter, limit)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -513,12 +1807,23 @@ func handleListMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.Ca
 
 func handleCountMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	filter := store.MemoryFilter{
-		Type:   argString(args, "type"),
-		Agent:  argString(args, "agent"),
-		Source: argString(args, "source"),
+		Type:    argString(args, "type"),
+		Agent:   argString(args, "agent"),
+		Source:  argString(args, "source"),
+		TagsAny: argStrings(args, "tags_any"),
+		TagsAll: argStrings(args, "tags_all"),
+
+		IncludeExpired: argBool(args, "include_expired"),
+	}
+	if err := taskFilterFromArgs(args, &filter); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
-	n, err := goldieInstance.CountMemories(filter)
+	n, err := g.CountMemories(filter)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
@@ -528,16 +1833,236 @@ func handleCountMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.C
 	})), nil
 }
 
+func handleMemoryHistory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName := argString(args, "id_or_name")
+	if idOrName == "" {
+		return mcp.NewToolResultError("id_or_name is required"), nil
+	}
+
+	m, revisions, err := g.MemoryHistory(idOrName)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	entries := make([]map[string]any, 0, len(revisions))
+	for _, r := range revisions {
+		entries = append(entries, map[string]any{
+			"revision":    r.Revision,
+			"type":        r.Type,
+			"description": r.Description,
+			"agent":       r.Agent,
+			"source":      r.Source,
+			"body_chars":  len(r.Body),
+			"updated_at":  r.UpdatedAt,
+			"replaced_at": r.ReplacedAt,
+		})
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"memory":    memorySummary(*m),
+		"count":     len(revisions),
+		"revisions": entries,
+		"message":   formatMessage("%q has %d saved revision(s)", m.Name, len(revisions)),
+	})), nil
+}
+
+func handleMemoryDiff(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName := argString(args, "id_or_name")
+	if idOrName == "" {
+		return mcp.NewToolResultError("id_or_name is required"), nil
+	}
+
+	from := argInt(args, "from", -1)
+	if from < 0 {
+		_, revisions, err := g.MemoryHistory(idOrName)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if len(revisions) == 0 {
+			return mcp.NewToolResultText(formatMessage("%q has no saved revisions", idOrName)), nil
+		}
+		from = revisions[0].Revision
+	}
+	to := argInt(args, "to", goldie.CurrentRevision)
+
+	diff, err := g.DiffMemory(idOrName, from, to)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	msg := formatMessage("Diff of %q from revision %d to %d", idOrName, from, to)
+	if diff == "" {
+		msg = formatMessage("Revisions %d and %d of %q are identical", from, to, idOrName)
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"from":    from,
+		"to":      to,
+		"diff":    diff,
+		"message": msg,
+	})), nil
+}
+
+func handleRestoreMemory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	idOrName := argString(args, "id_or_name")
+	if idOrName == "" {
+		return mcp.NewToolResultError("id_or_name is required"), nil
+	}
+	revision := argInt(args, "revision", 0)
+
+	// A memory in the trash comes back first, then optionally to a revision.
+	m, err := g.RestoreFromTrash(idOrName, revision)
+	untrashed := err == nil
+	if errors.Is(err, goldie.ErrNotInTrash) {
+		if revision <= 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("%v; pass a revision to roll back a live memory (see memory_history)", err)), nil
+		}
+		m, err = g.RestoreMemory(idOrName, revision)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var msg string
+	switch {
+	case untrashed && revision > 0:
+		msg = formatMessage("Restored %q from the trash at revision %d", m.Name, revision)
+	case untrashed:
+		msg = formatMessage("Restored %q from the trash", m.Name)
+	default:
+		msg = formatMessage("Restored %q to revision %d", m.Name, revision)
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": true,
+		"memory":  memorySummary(*m),
+		"message": msg,
+	})), nil
+}
+
+func handleExportMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts := goldie.ExportOptions{
+		Filter:            filterFromArgs(args),
+		IncludeEmbeddings: argBool(args, "include_embeddings"),
+	}
+	format := argString(args, "format")
+	if err := goldie.ValidateTransferFormat(format); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	path := argString(args, "path")
+	if path == "" {
+		if format == goldie.FormatMarkdown {
+			return mcp.NewToolResultError("path is required for markdown exports"), nil
+		}
+		buf := &inlineExport{limit: maxInlineExport}
+		n, err := g.Export(buf, opts)
+		if errors.Is(err, errInlineExportTooLarge) {
+			return mcp.NewToolResultError(fmt.Sprintf("export is larger than %d bytes, too large to return inline; pass path to write it to a file", maxInlineExport)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("export failed: %v", err)), nil
+		}
+		return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+			"count":   n,
+			"jsonl":   buf.String(),
+			"message": formatMessage("Exported %d memory(ies)", n),
+		})), nil
+	}
+
+	n, err := exportToPath(g, path, format, opts)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("export failed: %v", err)), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"count":   n,
+		"path":    path,
+		"message": formatMessage("Exported %d memory(ies) to %s", n, path),
+	})), nil
+}
+
+// maxInlineExport caps a JSONL export returned in a tool response; larger
+// exports have to go to a file.
+var maxInlineExport = 4 << 20
+
+var errInlineExportTooLarge = errors.New("inline export too large")
+
+// inlineExport buffers an export for a tool response, failing the export
+// once it grows past limit instead of holding all of it in memory.
+type inlineExport struct {
+	strings.Builder
+	limit int
+}
+
+func (w *inlineExport) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.limit {
+		return 0, errInlineExportTooLarge
+	}
+	return w.Builder.Write(p)
+}
+
+func handleImportMemories(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	path := argString(args, "path")
+	data := argString(args, "data")
+	if path == "" && data == "" {
+		return mcp.NewToolResultError("path or data is required"), nil
+	}
+	opts := goldie.ImportOptions{OnConflict: argString(args, "on_conflict")}
+	if err := goldie.ValidateConflictPolicy(opts.OnConflict); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var res *goldie.ImportResult
+	if path != "" {
+		res, err = importFromPath(g, path, opts)
+	} else {
+		res, err = g.Import(strings.NewReader(data), opts)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("import failed: %v", err)), nil
+	}
+	return mcp.NewToolResultText(safeJSONMarshal(map[string]any{
+		"success": len(res.Errors) == 0,
+		"result":  res,
+		"message": formatMessage("Imported %d, overwrote %d, renamed %d, skipped %d memory(ies)",
+			res.Imported, res.Overwritten, res.Renamed, res.Skipped),
+	})), nil
+}
+
 // --- file/dir indexing handlers ---
 
 func handleIndexFile(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	path := argString(args, "path")
 	if path == "" {
 		return mcp.NewToolResultError("path is required"), nil
 	}
 
-	jobID, err := queueInstance.EnqueueIndexFile(path, argString(args, "agent"))
+	jobID, err := queueInstance.EnqueueIndexFile(g.Namespace(), path, argString(args, "agent"))
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to queue job: %v", err)), nil
 	}
@@ -552,6 +2077,10 @@ func handleIndexFile(_ context.Context, request mcp.CallToolRequest) (*mcp.CallT
 
 func handleIndexDirectory(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.Params.Arguments
+	g, err := goldieFor(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 	dir := argString(args, "directory")
 	if dir == "" {
 		return mcp.NewToolResultError("directory is required"), nil
@@ -562,7 +2091,7 @@ func handleIndexDirectory(_ context.Context, request mcp.CallToolRequest) (*mcp.
 	}
 	recursive := argBool(args, "recursive")
 
-	jobID, err := queueInstance.EnqueueIndexDirectory(dir, pattern, recursive, argString(args, "agent"))
+	jobID, err := queueInstance.EnqueueIndexDirectory(g.Namespace(), dir, pattern, recursive, argString(args, "agent"))
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to queue job: %v", err)), nil
 	}
@@ -585,6 +2114,10 @@ func handleJobStatus(_ context.Context, request mcp.CallToolRequest) (*mcp.CallT
 	if id == "" {
 		return mcp.NewToolResultError("id is required"), nil
 	}
+	ns, err := jobNamespace(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	block := argBool(args, "block")
 	timeout := 30 * time.Second
@@ -592,10 +2125,7 @@ func handleJobStatus(_ context.Context, request mcp.CallToolRequest) (*mcp.CallT
 		timeout = time.Duration(t) * time.Second
 	}
 
-	var (
-		job *store.Job
-		err error
-	)
+	var job *store.Job
 	if block {
 		job, err = storeInstance.WaitForJob(id, timeout)
 	} else {
@@ -604,7 +2134,7 @@ func handleJobStatus(_ context.Context, request mcp.CallToolRequest) (*mcp.CallT
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("getting job status failed: %v", err)), nil
 	}
-	if job == nil {
+	if job == nil || !jobVisible(job, ns) {
 		return mcp.NewToolResultError(fmt.Sprintf("job not found: %s", id)), nil
 	}
 
@@ -616,6 +2146,7 @@ func handleJobStatus(_ context.Context, request mcp.CallToolRequest) (*mcp.CallT
 		response := map[string]any{
 			"id":         job.ID,
 			"type":       job.Type,
+			"namespace":  job.Namespace,
 			"status":     job.Status,
 			"params":     job.Params,
 			"result":     job.Result,
@@ -641,9 +2172,20 @@ func handleJobStatus(_ context.Context, request mcp.CallToolRequest) (*mcp.CallT
 	return mcp.NewToolResultText(safeJSONMarshal(job)), nil
 }
 
+// jobVisible reports whether a job tool scoped to namespace ns may see job.
+// Pool-wide jobs are visible from every namespace.
+func jobVisible(job *store.Job, ns string) bool {
+	return ns == store.AllNamespaces || job.Namespace == ns || job.Namespace == store.AllNamespaces
+}
+
 func handleListJobs(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	status := argString(request.Params.Arguments, "status")
-	jobs, err := storeInstance.ListJobs(status)
+	args := request.Params.Arguments
+	status := argString(args, "status")
+	ns, err := jobNamespace(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	jobs, err := storeInstance.ListJobs(status, ns)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("listing jobs failed: %v", err)), nil
 	}
@@ -665,7 +2207,8 @@ func handleListJobs(_ context.Context, request mcp.CallToolRequest) (*mcp.CallTo
 }
 
 func handleClearQueue(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	status := argString(request.Params.Arguments, "status")
+	args := request.Params.Arguments
+	status := argString(args, "status")
 	if status == "" {
 		return mcp.NewToolResultError("status is required (queued, completed, failed, or all)"), nil
 	}
@@ -678,7 +2221,11 @@ func handleClearQueue(_ context.Context, request mcp.CallToolRequest) (*mcp.Call
 	if !validStatuses[status] {
 		return mcp.NewToolResultError("invalid status: must be queued, completed, failed, or all"), nil
 	}
-	count, err := storeInstance.DeleteJobs(status)
+	ns, err := jobNamespace(args)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	count, err := storeInstance.DeleteJobs(status, ns)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("clearing queue failed: %v", err)), nil
 	}
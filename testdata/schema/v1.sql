@@ -0,0 +1,49 @@
+-- Schema version 1: the unversioned layout written before schema_version
+-- existed. Vectors are 4-dimensional to keep the fixture readable.
+CREATE TABLE memories (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	type TEXT NOT NULL,
+	description TEXT,
+	body TEXT NOT NULL,
+	agent TEXT,
+	source TEXT,
+	checksum TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE memory_chunks (
+	id TEXT PRIMARY KEY,
+	memory_id TEXT NOT NULL,
+	chunk_index INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	UNIQUE(memory_id, chunk_index)
+);
+CREATE INDEX idx_memory_chunks_memory_id ON memory_chunks(memory_id);
+CREATE VIRTUAL TABLE memories_vec USING vec0(
+	id TEXT PRIMARY KEY,
+	embedding FLOAT[4]
+);
+CREATE TABLE jobs (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	status TEXT DEFAULT 'queued',
+	params TEXT NOT NULL,
+	result TEXT,
+	error TEXT,
+	progress INTEGER DEFAULT 0,
+	total INTEGER DEFAULT 0,
+	parent_id TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO memories (id, name, type, description, body, agent, source, created_at, updated_at)
+VALUES ('m-1', 'fixture_memory', 'feedback', 'fixture description',
+	'Fixture body mentioning FIXTURE_TOKEN.', 'fixture-agent', 'fixture',
+	'2024-01-02 03:04:05', '2024-01-02 03:04:05');
+INSERT INTO memory_chunks (id, memory_id, chunk_index, content)
+VALUES ('c-1', 'm-1', 0, 'Fixture body mentioning FIXTURE_TOKEN.');
+INSERT INTO memories_vec (id, embedding) VALUES ('c-1', '[0.1, 0.2, 0.3, 0.4]');
+INSERT INTO jobs (id, type, status, params, progress, total)
+VALUES ('j-1', 'index_file', 'completed', '{"path":"/tmp/fixture.txt"}', 1, 1);
@@ -0,0 +1,107 @@
+-- Schema version 11: vectors ranked by cosine distance.
+-- Vectors are 4-dimensional to keep the fixture readable.
+CREATE TABLE schema_version (
+	version INTEGER PRIMARY KEY,
+	applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE memories (
+	id TEXT PRIMARY KEY,
+	namespace TEXT NOT NULL DEFAULT 'default',
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	description TEXT,
+	body TEXT NOT NULL,
+	agent TEXT,
+	source TEXT,
+	checksum TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	deleted_at DATETIME,
+	expires_at DATETIME,
+	due_at DATETIME,
+	status TEXT,
+	UNIQUE(namespace, name)
+);
+CREATE INDEX idx_memories_deleted_at ON memories(deleted_at);
+CREATE INDEX idx_memories_expires_at ON memories(expires_at);
+CREATE INDEX idx_memories_due_at ON memories(due_at);
+CREATE TABLE memory_chunks (
+	id TEXT PRIMARY KEY,
+	memory_id TEXT NOT NULL,
+	chunk_index INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	UNIQUE(memory_id, chunk_index)
+);
+CREATE INDEX idx_memory_chunks_memory_id ON memory_chunks(memory_id);
+CREATE VIRTUAL TABLE memories_vec USING vec0(
+	id TEXT PRIMARY KEY,
+	embedding FLOAT[4] distance_metric=cosine
+);
+CREATE TABLE jobs (
+	id TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	status TEXT DEFAULT 'queued',
+	params TEXT NOT NULL,
+	result TEXT,
+	error TEXT,
+	progress INTEGER DEFAULT 0,
+	total INTEGER DEFAULT 0,
+	parent_id TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	checkpoint TEXT,
+	namespace TEXT NOT NULL DEFAULT 'default'
+);
+CREATE TABLE store_meta (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE memory_tags (
+	memory_id TEXT NOT NULL,
+	tag TEXT NOT NULL,
+	PRIMARY KEY (memory_id, tag)
+);
+CREATE INDEX idx_memory_tags_tag ON memory_tags(tag);
+CREATE TABLE memory_revisions (
+	memory_id TEXT NOT NULL,
+	revision INTEGER NOT NULL,
+	type TEXT NOT NULL,
+	description TEXT,
+	body TEXT NOT NULL,
+	agent TEXT,
+	source TEXT,
+	checksum TEXT,
+	updated_at DATETIME,
+	replaced_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (memory_id, revision)
+);
+CREATE TABLE memory_links (
+	from_id TEXT NOT NULL,
+	to_id TEXT NOT NULL,
+	type TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (from_id, to_id, type)
+);
+CREATE INDEX idx_memory_links_to_id ON memory_links(to_id);
+
+INSERT INTO schema_version (version) VALUES (1), (2), (3), (4), (5), (6), (7), (8), (9), (10), (11);
+INSERT INTO store_meta (key, value) VALUES
+	('embed_backend', 'mock'),
+	('embed_model', 'fixture'),
+	('embed_dimensions', '4');
+INSERT INTO memories (id, name, type, description, body, agent, source, created_at, updated_at)
+VALUES ('m-1', 'fixture_memory', 'feedback', 'fixture description',
+	'Fixture body mentioning FIXTURE_TOKEN.', 'fixture-agent', 'fixture',
+	'2024-01-02 03:04:05', '2024-01-02 03:04:05');
+INSERT INTO memories (id, name, type, body, status, created_at, updated_at)
+VALUES ('m-2', 'fixture_todo', 'todo', 'Fixture task.', 'open',
+	'2024-01-02 03:04:05', '2024-01-02 03:04:05');
+INSERT INTO memory_chunks (id, memory_id, chunk_index, content)
+VALUES ('c-1', 'm-1', 0, 'Fixture body mentioning FIXTURE_TOKEN.');
+INSERT INTO memories_vec (id, embedding) VALUES ('c-1', '[0.1, 0.2, 0.3, 0.4]');
+INSERT INTO jobs (id, type, status, params, progress, total)
+VALUES ('j-1', 'index_file', 'completed', '{"path":"/tmp/fixture.txt"}', 1, 1);
+INSERT INTO memory_tags (memory_id, tag) VALUES ('m-1', 'fixture');
+INSERT INTO memory_revisions (memory_id, revision, type, body, updated_at)
+VALUES ('m-1', 1, 'feedback', 'Earlier fixture body.', '2024-01-01 00:00:00');
+INSERT INTO memory_links (from_id, to_id, type) VALUES ('m-2', 'm-1', 'relates_to');